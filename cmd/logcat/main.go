@@ -0,0 +1,66 @@
+// logcat prints JSON log files, or JSON log lines read from stdin, in the
+// human readable console format.  Compressed archives are decompressed on the
+// fly.
+//
+//	logcat log/test.log
+//	kubectl logs mypod | logcat
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ebobo/logging_lab5e_go/pkg/logging"
+)
+
+func main() {
+	var (
+		color      = flag.Bool("color", isTerminal(os.Stdout), "color the log levels")
+		fullCaller = flag.Bool("full-caller", false, "print the full path of the caller")
+	)
+	flag.Parse()
+
+	c := logging.ConsoleConfig{Color: *color, FullCaller: *fullCaller}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	if flag.NArg() == 0 {
+		err := logging.PrettyPrint(out, os.Stdin, c)
+		if err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	for _, fn := range flag.Args() {
+		err := catFile(out, fn, c)
+		if err != nil {
+			out.Flush()
+			fail(err)
+		}
+	}
+}
+
+func catFile(w io.Writer, fn string, c logging.ConsoleConfig) error {
+	r, err := logging.OpenArchive(fn)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return logging.PrettyPrint(w, r, c)
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "logcat: %v\n", err)
+	os.Exit(1)
+}
+
+// isTerminal returns true if f is a terminal rather than a file or a pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
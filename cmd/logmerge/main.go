@@ -0,0 +1,53 @@
+// logmerge merges log files and archives, eg. of several services, into one
+// stream ordered by time for investigating incidents that span services.
+// The files may use any of the formats of the logging package and different
+// time formats.  Compressed archives are decompressed on the fly.
+//
+//	logmerge -label api/log/api.log worker/log/worker.log
+//	logmerge -since 2024-05-01T10:00:00Z -until 2024-05-01T11:00:00Z *.log.gz
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ebobo/logging_lab5e_go/internal/logfile"
+	"github.com/ebobo/logging_lab5e_go/pkg/logging"
+)
+
+func main() {
+	var (
+		label = flag.Bool("label", false, "prefix each line with the file it was read from")
+		since = flag.String("since", "", "only print entries logged at or after this time, eg. 2024-05-01T10:00:00Z or 2h")
+		until = flag.String("until", "", "only print entries logged at or before this time")
+	)
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "logmerge: no files given")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := logging.MergeOptions{Label: *label}
+	var err error
+	opts.Since, err = logfile.ParseTime(*since)
+	if err != nil {
+		fail(err)
+	}
+	opts.Until, err = logfile.ParseTime(*until)
+	if err != nil {
+		fail(err)
+	}
+
+	err = logging.MergeLogs(os.Stdout, flag.Args(), opts)
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "logmerge: %v\n", err)
+	os.Exit(1)
+}
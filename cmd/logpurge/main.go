@@ -0,0 +1,71 @@
+// logpurge deletes archives of a log file written by the logging package, so
+// disk space can be reclaimed without waiting for the process writing the log
+// to rotate it.
+//
+//	logpurge -dir log -file test.log -older-than 7d
+//	logpurge -max-archives 10 -max-size-mb 500
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ebobo/logging_lab5e_go/pkg/logging"
+)
+
+func main() {
+	var (
+		dir         = flag.String("dir", logging.GetLogDir(), "log directory")
+		file        = flag.String("file", "test.log", "name of the log file")
+		olderThan   = flag.String("older-than", "", "delete archives older than this, eg. 36h or 7d")
+		maxArchives = flag.Int("max-archives", 0, "keep at most this many archives")
+		maxSizeMB   = flag.Int64("max-size-mb", 0, "keep the log file and its archives below this size in megabytes")
+	)
+	flag.Parse()
+
+	age, err := parseAge(*olderThan)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logpurge: %v\n", err)
+		os.Exit(2)
+	}
+	if age == 0 && *maxArchives == 0 && *maxSizeMB == 0 {
+		fmt.Fprintln(os.Stderr, "logpurge: one of -older-than, -max-archives and -max-size-mb is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	removed, err := logging.PurgeArchives(logging.FileWriterConfig{
+		LogDirName:        *dir,
+		LogFileName:       *file,
+		MaxArchivedFiles:  *maxArchives,
+		MaxTotalSizeBytes: *maxSizeMB * 1024 * 1024,
+	}, age)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logpurge: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d archives removed\n", removed)
+}
+
+// parseAge parses a duration, also accepting a number of days, eg. "7d".
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
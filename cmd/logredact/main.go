@@ -0,0 +1,83 @@
+// logredact rewrites log archives without the entries where the given
+// fields have the given values, eg. to handle a GDPR erasure request
+// against historical logs.  The archives keep their names and compression.
+// With -mask the entries are replaced by an entry with only their time and
+// level rather than removed.
+//
+//	logredact -field user_id=42 /var/log/myservice/myservice-*.log.gz
+//	logredact -mask -field user_id=42 -field tenant=acme *.log.zst
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ebobo/logging_lab5e_go/internal/logfile"
+	"github.com/ebobo/logging_lab5e_go/pkg/logging"
+)
+
+// fieldFilters collects -field flags.
+type fieldFilters map[string]string
+
+func (f fieldFilters) String() string {
+	var s []string
+	for k, v := range f {
+		s = append(s, k+"="+v)
+	}
+	return strings.Join(s, ",")
+}
+
+func (f fieldFilters) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("invalid field filter %q, use name=value", s)
+	}
+	f[k] = v
+	return nil
+}
+
+func main() {
+	fields := fieldFilters{}
+	mask := flag.Bool("mask", false, "replace matching entries by their time and level rather than removing them")
+	flag.Var(fields, "field", "redact entries where the field has this value, eg. user_id=42.  Can be repeated, all must match")
+	flag.Parse()
+
+	if len(fields) == 0 {
+		fmt.Fprintln(os.Stderr, "logredact: no -field given")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "logredact: no files given")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := logging.RedactOptions{
+		Match: func(e logging.Entry) bool {
+			for k, want := range fields {
+				v, ok := logfile.Field(e.Fields, k)
+				if !ok || fmt.Sprint(v) != want {
+					return false
+				}
+			}
+			return true
+		},
+		Mask: *mask,
+	}
+
+	for _, path := range flag.Args() {
+		n, err := logging.RedactArchive(path, opts)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("%s: %d entries redacted\n", path, n)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "logredact: %v\n", err)
+	os.Exit(1)
+}
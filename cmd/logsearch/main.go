@@ -0,0 +1,197 @@
+// logsearch searches the log file written by the logging package and its
+// archives, decompressing the archives on the fly.  Entries can be filtered
+// by level, time range and field values, and matched against a regular
+// expression.
+//
+//	logsearch -level warn -since 24h -field user_id=42 'timeout'
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ebobo/logging_lab5e_go/internal/logfile"
+	"github.com/ebobo/logging_lab5e_go/pkg/logging"
+)
+
+// levels orders the level names used by the different encodings.
+var levels = map[string]int{
+	"debug":     0,
+	"info":      1,
+	"warn":      2,
+	"warning":   2,
+	"error":     3,
+	"dpanic":    4,
+	"critical":  4,
+	"panic":     5,
+	"alert":     5,
+	"fatal":     6,
+	"emergency": 6,
+}
+
+// fieldFilters collects -field flags.
+type fieldFilters map[string]string
+
+func (f fieldFilters) String() string {
+	var s []string
+	for k, v := range f {
+		s = append(s, k+"="+v)
+	}
+	return strings.Join(s, ",")
+}
+
+func (f fieldFilters) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("invalid field filter %q, use name=value", s)
+	}
+	f[k] = v
+	return nil
+}
+
+func main() {
+	fields := fieldFilters{}
+	var (
+		dir        = flag.String("dir", logging.GetLogDir(), "log directory")
+		file       = flag.String("file", "test.log", "name of the log file")
+		level      = flag.String("level", "", "only match entries at or above this level")
+		since      = flag.String("since", "", "only match entries logged at or after this time, eg. 2024-05-01T10:00:00Z or 2h")
+		until      = flag.String("until", "", "only match entries logged at or before this time")
+		withName   = flag.Bool("H", false, "print the name of the file before each match")
+		noArchives = flag.Bool("no-archives", false, "only search the log file")
+	)
+	flag.Var(fields, "field", "only match entries where the field has this value, eg. user_id=42.  Can be repeated")
+	flag.Parse()
+
+	m := &matcher{fields: fields, minLevel: -1}
+	var err error
+	m.since, err = logfile.ParseTime(*since)
+	if err != nil {
+		fail(err)
+	}
+	m.until, err = logfile.ParseTime(*until)
+	if err != nil {
+		fail(err)
+	}
+	if *level != "" {
+		l, ok := levels[strings.ToLower(*level)]
+		if !ok {
+			fail(fmt.Errorf("invalid level %q", *level))
+		}
+		m.minLevel = l
+	}
+	if flag.NArg() > 1 {
+		fail(fmt.Errorf("too many arguments, give at most one pattern"))
+	}
+	if flag.NArg() == 1 {
+		m.pattern, err = regexp.Compile(flag.Arg(0))
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	var fns []string
+	if !*noArchives {
+		archives, err := logging.ListArchives(logging.FileWriterConfig{LogDirName: *dir, LogFileName: *file})
+		if err != nil {
+			fail(err)
+		}
+		for _, archive := range archives {
+			if archive.Covers(m.since, m.until) {
+				fns = append(fns, archive.Path)
+			}
+		}
+	}
+	fns = append(fns, filepath.Join(*dir, *file))
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	for _, fn := range fns {
+		err := search(out, m, fn, *withName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "logsearch: error reading %s: %v\n", fn, err)
+		}
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "logsearch: %v\n", err)
+	os.Exit(2)
+}
+
+// search prints the matching lines of the file.  Lines that don't start an
+// entry, such as stack traces, are printed if the entry they belong to
+// matched.
+func search(out *bufio.Writer, m *matcher, fn string, withName bool) error {
+	r, err := logging.OpenArchive(fn)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	matched := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if _, ok := logfile.Time(line); ok {
+			matched = m.match(line)
+		}
+		if !matched {
+			continue
+		}
+		if withName {
+			out.WriteString(fn)
+			out.WriteByte(':')
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return scanner.Err()
+}
+
+// matcher decides which entries are printed.
+type matcher struct {
+	minLevel int
+	since    time.Time
+	until    time.Time
+	fields   fieldFilters
+	pattern  *regexp.Regexp
+}
+
+func (m *matcher) match(line string) bool {
+	t, _ := logfile.Time(line)
+	if !m.since.IsZero() && t.Before(m.since) {
+		return false
+	}
+	if !m.until.IsZero() && t.After(m.until) {
+		return false
+	}
+
+	if m.minLevel >= 0 {
+		name, _ := logfile.Level(line)
+		level, ok := levels[name]
+		if !ok || level < m.minLevel {
+			return false
+		}
+	}
+
+	if len(m.fields) > 0 {
+		fields := logfile.Fields(line)
+		for k, want := range m.fields {
+			v, ok := logfile.Field(fields, k)
+			if !ok || fmt.Sprint(v) != want {
+				return false
+			}
+		}
+	}
+
+	return m.pattern == nil || m.pattern.MatchString(line)
+}
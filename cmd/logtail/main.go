@@ -0,0 +1,225 @@
+// logtail prints the log file written by the logging package and follows it
+// across rotations.  It can also print the archives of the log file in
+// chronological order, filtered by time.
+//
+//	logtail -dir log -file test.log -f
+//	logtail -archives -since 2h -until 1h
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ebobo/logging_lab5e_go/internal/logfile"
+	"github.com/ebobo/logging_lab5e_go/pkg/logging"
+)
+
+const pollInterval = 250 * time.Millisecond
+
+func main() {
+	var (
+		dir      = flag.String("dir", logging.GetLogDir(), "log directory")
+		file     = flag.String("file", "test.log", "name of the log file")
+		lines    = flag.Int("n", 10, "number of lines of the log file to print, 0 prints all lines")
+		follow   = flag.Bool("f", false, "follow the log file as it grows and is rotated")
+		archives = flag.Bool("archives", false, "print the archives of the log file first, oldest first")
+		since    = flag.String("since", "", "only print entries logged at or after this time, eg. 2024-05-01T10:00:00Z or 2h")
+		until    = flag.String("until", "", "only print entries logged at or before this time")
+	)
+	flag.Parse()
+
+	p := &printer{w: bufio.NewWriter(os.Stdout)}
+	var err error
+	p.since, err = logfile.ParseTime(*since)
+	if err != nil {
+		fail(err)
+	}
+	p.until, err = logfile.ParseTime(*until)
+	if err != nil {
+		fail(err)
+	}
+	p.inRange = p.since.IsZero()
+
+	// when filtering by time we print whole files since the entries we are
+	// after may be anywhere
+	if !p.since.IsZero() || !p.until.IsZero() {
+		*lines = 0
+	}
+
+	if *archives {
+		archives, err := logging.ListArchives(logging.FileWriterConfig{LogDirName: *dir, LogFileName: *file})
+		if err != nil {
+			fail(err)
+		}
+		for _, archive := range archives {
+			if !archive.Covers(p.since, p.until) {
+				continue
+			}
+			err := printArchive(p, archive.Path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error reading %s: %v\n", archive.Path, err)
+			}
+		}
+	}
+
+	path := filepath.Join(*dir, *file)
+	f, err := os.Open(path)
+	if err != nil {
+		fail(err)
+	}
+
+	err = printLast(p, f, *lines)
+	if err != nil {
+		fail(err)
+	}
+	p.w.Flush()
+
+	if *follow {
+		err = followFile(p, path, f)
+		if err != nil {
+			fail(err)
+		}
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "logtail: %v\n", err)
+	os.Exit(1)
+}
+
+func printArchive(p *printer, fn string) error {
+	r, err := logging.OpenArchive(fn)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		p.print(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// printLast prints the last n lines of f, or all of them if n is 0, and
+// leaves f at the end.
+func printLast(p *printer, f *os.File, n int) error {
+	var last []string
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if line != "" && strings.HasSuffix(line, "\n") {
+			line = strings.TrimSuffix(line, "\n")
+			if n == 0 {
+				p.print(line)
+			} else {
+				last = append(last, line)
+				if len(last) > n {
+					last = last[1:]
+				}
+			}
+		}
+		if err == io.EOF {
+			// don't lose a partial line, we'll read it again when following
+			if line != "" {
+				_, err := f.Seek(-int64(len(line)), io.SeekCurrent)
+				if err != nil {
+					return err
+				}
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, line := range last {
+		p.print(line)
+	}
+	return nil
+}
+
+// followFile prints lines appended to the file at path.  When the file is
+// rotated we finish reading the old file and continue with the new one.
+func followFile(p *printer, path string, f *os.File) error {
+	r := bufio.NewReader(f)
+	var partial string
+	for {
+		line, err := r.ReadString('\n')
+		if err == nil {
+			p.print(strings.TrimSuffix(partial+line, "\n"))
+			partial = ""
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		partial += line
+		p.w.Flush()
+
+		time.Sleep(pollInterval)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			// the file is gone for a moment while it is being rotated
+			continue
+		}
+		current, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case !os.SameFile(info, current):
+			// rotated, whatever was left in the old file has been read
+			f.Close()
+			f, err = os.Open(path)
+			if err != nil {
+				return err
+			}
+			r.Reset(f)
+			partial = ""
+
+		case info.Size() < offset(f):
+			// truncated, eg. by logrotate's copytruncate
+			_, err = f.Seek(0, io.SeekStart)
+			if err != nil {
+				return err
+			}
+			r.Reset(f)
+			partial = ""
+		}
+	}
+}
+
+func offset(f *os.File) int64 {
+	pos, _ := f.Seek(0, io.SeekCurrent)
+	return pos
+}
+
+// printer prints the lines within the time range.  Lines without a timestamp,
+// such as stack traces, are printed if the line before them was.
+type printer struct {
+	w       *bufio.Writer
+	since   time.Time
+	until   time.Time
+	inRange bool
+}
+
+func (p *printer) print(line string) {
+	if t, ok := logfile.Time(line); ok {
+		p.inRange = (p.since.IsZero() || !t.Before(p.since)) && (p.until.IsZero() || !t.After(p.until))
+	}
+	if p.inRange {
+		p.w.WriteString(line)
+		p.w.WriteByte('\n')
+	}
+}
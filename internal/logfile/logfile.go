@@ -0,0 +1,227 @@
+// Package logfile contains helpers shared by the commands reading the files
+// written by the logging package.
+package logfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ParseTime parses a time given on the command line, either as a time or as
+// a duration before now, eg. "2h".
+func ParseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// Fields returns the fields of a JSON or logfmt line, or nil if the line is
+// in neither format.
+func Fields(line string) map[string]interface{} {
+	if strings.HasPrefix(line, "{") {
+		var m map[string]interface{}
+		if json.Unmarshal([]byte(line), &m) != nil {
+			return nil
+		}
+		return m
+	}
+	if !strings.Contains(line, "=") || strings.IndexFunc(line, unicode.IsSpace) == 0 {
+		return nil
+	}
+	return parseLogfmt(line)
+}
+
+// Field looks up a field by name.  Dotted names, eg. "http.status", also
+// match nested objects.
+func Field(fields map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := fields[name]; ok {
+		return v, true
+	}
+	if i := strings.Index(name, "."); i > 0 {
+		if nested, ok := fields[name[:i]].(map[string]interface{}); ok {
+			return Field(nested, name[i+1:])
+		}
+	}
+	return nil, false
+}
+
+// Time returns the timestamp of a line written by the logging package in the
+// json, ecs, gcp, logfmt or console formats.
+func Time(line string) (time.Time, bool) {
+	fields := Fields(line)
+	for _, key := range []string{"ts", "@timestamp", "time"} {
+		if t, ok := ParseTimestamp(fields[key]); ok {
+			return t, true
+		}
+	}
+
+	// the console format starts with the timestamp, which may contain
+	// spaces with a custom layout
+	field, _, _ := strings.Cut(line, "\t")
+	return ParseTimestamp(field)
+}
+
+// Level returns the level of a line written by the logging package, in lower
+// case.
+func Level(line string) (string, bool) {
+	fields := Fields(line)
+	for _, key := range []string{"level", "log.level", "severity"} {
+		if v, ok := Field(fields, key); ok {
+			return strings.ToLower(fmt.Sprint(v)), true
+		}
+	}
+
+	// the console format has the level in the second column
+	columns := strings.SplitN(line, "\t", 3)
+	if len(columns) < 2 {
+		return "", false
+	}
+	if _, ok := Time(columns[0]); !ok {
+		return "", false
+	}
+	return strings.ToLower(strings.TrimSpace(columns[1])), true
+}
+
+// layouts are the time layouts the logging package writes timestamps in,
+// see TimeConfig, along with the layouts added with RegisterLayout.
+var (
+	layoutsMu sync.RWMutex
+	layouts   = []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.000Z0700",
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999Z0700",
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999",
+		time.RFC1123Z,
+		time.RFC1123,
+		time.RFC822Z,
+		time.UnixDate,
+	}
+)
+
+// RegisterLayout adds a time layout to those recognized by ParseTimestamp,
+// eg. a custom layout given to TimeConfig.
+func RegisterLayout(layout string) {
+	layoutsMu.Lock()
+	defer layoutsMu.Unlock()
+
+	for _, l := range layouts {
+		if l == layout {
+			return
+		}
+	}
+	layouts = append(layouts, layout)
+}
+
+// ParseTimestamp parses a timestamp field, either seconds, milliseconds,
+// microseconds or nanoseconds since the epoch, told apart by their
+// magnitude, or a time in one of the layouts written by the logging
+// package.  Times without a time zone are in local time.
+func ParseTimestamp(v interface{}) (time.Time, bool) {
+	switch ts := v.(type) {
+	case float64:
+		return epochTime(ts), true
+	case string:
+		if n, err := strconv.ParseInt(ts, 10, 64); err == nil {
+			return epochIntTime(n), true
+		}
+		if f, err := strconv.ParseFloat(ts, 64); err == nil {
+			return epochTime(f), true
+		}
+
+		layoutsMu.RLock()
+		defer layoutsMu.RUnlock()
+		for _, layout := range layouts {
+			if t, err := time.ParseInLocation(layout, ts, time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// epochIntTime is epochTime without the loss of precision of a float.
+func epochIntTime(ts int64) time.Time {
+	abs := ts
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs < 1e11:
+		return time.Unix(ts, 0)
+	case abs < 1e14:
+		return time.UnixMilli(ts)
+	case abs < 1e17:
+		return time.UnixMicro(ts)
+	default:
+		return time.Unix(0, ts)
+	}
+}
+
+// epochTime returns the time ts since the epoch.  Seconds up to year 5138
+// and milliseconds, microseconds and nanoseconds since 1973 are told apart
+// by their magnitude.
+func epochTime(ts float64) time.Time {
+	abs := math.Abs(ts)
+	switch {
+	case abs < 1e11:
+		sec := math.Floor(ts)
+		return time.Unix(int64(sec), int64((ts-sec)*1e9))
+	case abs < 1e14:
+		return time.Unix(0, int64(ts*1e6))
+	case abs < 1e17:
+		return time.Unix(0, int64(ts*1e3))
+	default:
+		return time.Unix(0, int64(ts))
+	}
+}
+
+// parseLogfmt parses a line of space separated key=value pairs.  Values may
+// be quoted.
+func parseLogfmt(line string) map[string]interface{} {
+	fields := map[string]interface{}{}
+	for len(line) > 0 {
+		line = strings.TrimLeft(line, " ")
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			break
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			quoted, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				break
+			}
+			value, _ = strconv.Unquote(quoted)
+			line = line[len(quoted):]
+		} else {
+			end := strings.IndexByte(line, ' ')
+			if end < 0 {
+				end = len(line)
+			}
+			value = line[:end]
+			line = line[end:]
+		}
+		fields[key] = value
+	}
+	return fields
+}
@@ -0,0 +1,85 @@
+package logfile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeAndLevel(t *testing.T) {
+	want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, line := range []string{
+		`{"level":"info","ts":1714557600,"msg":"hello"}`,
+		`{"log.level":"info","@timestamp":"2024-05-01T10:00:00.000Z","message":"hello"}`,
+		`{"severity":"INFO","time":"2024-05-01T10:00:00Z","message":"hello"}`,
+		`level=info ts=2024-05-01T10:00:00.000Z msg="hello x=1"`,
+		"2024-05-01T10:00:00.000Z\tINFO\tmain/main.go:12\thello x=1",
+	} {
+		ts, ok := Time(line)
+		assert.True(t, ok, line)
+		assert.True(t, want.Equal(ts), line)
+
+		level, ok := Level(line)
+		assert.True(t, ok, line)
+		assert.Equal(t, "info", level, line)
+	}
+
+	_, ok := Time("\tat main.main()")
+	assert.False(t, ok)
+}
+
+func TestFields(t *testing.T) {
+	fields := Fields(`{"msg":"hello","http":{"status":404},"user_id":42}`)
+	v, ok := Field(fields, "http.status")
+	assert.True(t, ok)
+	assert.Equal(t, float64(404), v)
+	v, ok = Field(fields, "user_id")
+	assert.True(t, ok)
+	assert.Equal(t, float64(42), v)
+
+	fields = Fields(`level=info msg="a \"quoted\" message" user_id=42`)
+	assert.Equal(t, map[string]interface{}{"level": "info", "msg": `a "quoted" message`, "user_id": "42"}, fields)
+}
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC)
+	RegisterLayout("02.01.2006 15:04:05.000000000 MST")
+
+	for _, tt := range []struct {
+		name string
+		v    interface{}
+		prec time.Duration
+	}{
+		{"epoch", float64(want.UnixNano()) / 1e9, time.Microsecond},
+		{"epoch string", "1714557600.123456789", time.Microsecond},
+		{"epochmillis", float64(want.UnixNano()) / 1e6, time.Microsecond},
+		{"epochmillis integer", float64(want.UnixMilli()), time.Millisecond},
+		{"epochmicros", float64(want.UnixMicro()), time.Microsecond},
+		{"epochnanos", float64(want.UnixNano()), time.Microsecond},
+		{"epochnanos string", "1714557600123456789", 0},
+		{"rfc3339", "2024-05-01T10:00:00Z", time.Second},
+		{"rfc3339nano", "2024-05-01T12:00:00.123456789+02:00", 0},
+		{"iso8601", "2024-05-01T12:00:00.123+0200", time.Millisecond},
+		{"space separated", "2024-05-01 10:00:00.123456789Z", 0},
+		{"registered layout", "01.05.2024 10:00:00.123456789 UTC", 0},
+	} {
+		ts, ok := ParseTimestamp(tt.v)
+		assert.True(t, ok, tt.name)
+		assert.WithinDuration(t, want, ts, tt.prec, tt.name)
+	}
+
+	// without a time zone the time is local
+	ts, ok := ParseTimestamp("2024-05-01 10:00:00")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local), ts)
+
+	_, ok = ParseTimestamp("yesterday")
+	assert.False(t, ok)
+
+	// console lines with a timestamp containing spaces
+	ts, ok = Time("2024-05-01 10:00:00.123456789Z\tINFO\thello")
+	assert.True(t, ok)
+	assert.True(t, want.Equal(ts))
+}
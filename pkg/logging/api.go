@@ -1,7 +1,7 @@
 package logging
 
 import (
-	"os"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -13,6 +13,43 @@ func Get() *zap.Logger {
 	return logger
 }
 
+// WithOptions returns the package logger with opts applied.
+func WithOptions(opts ...zap.Option) *zap.Logger {
+	return logger.WithOptions(opts...)
+}
+
+// WithCallerSkip returns the package logger which skips n additional stack
+// frames when reporting the caller.  Packages wrapping the logger use this so
+// the file and line of their callers are logged rather than their own.
+func WithCallerSkip(n int) *zap.Logger {
+	return WithOptions(zap.AddCallerSkip(n))
+}
+
+// Discard makes the package logger drop all entries, eg. in benchmarks and
+// in tests of code which logs a lot.  Call the returned function to stop
+// discarding.  Changes made in the meantime, eg. by Configure or AddCore,
+// take effect then.
+func Discard() (restore func()) {
+	Start()
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	discarding++
+	rootCore.swap(finishCore(configuredCore))
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			configMu.Lock()
+			defer configMu.Unlock()
+
+			discarding--
+			rootCore.swap(finishCore(configuredCore))
+		})
+	}
+}
+
 // SetLevel sets the log level
 func SetLevel(level zapcore.Level) {
 	atomicLogLevel.SetLevel(level)
@@ -25,8 +62,8 @@ func GetLevel() zapcore.Level {
 
 // GetLogDir returns the directory we log to
 func GetLogDir() string {
-	if os.Getenv(LogDirEnvVar) != "" {
-		return os.Getenv(LogDirEnvVar)
+	if getenv(LogDirEnvVar) != "" {
+		return getenv(LogDirEnvVar)
 	}
 
 	return defaultLogDirName
@@ -49,8 +86,9 @@ func SetLevelTemporarily(level zapcore.Level, d time.Duration) (time.Duration, e
 		d = maxDurationForTemporaryLogLevelChange
 	}
 
+	timer := clock.NewTimer(d)
 	go func() {
-		<-time.After(d)
+		<-timer.C()
 
 		// There may not be a need to reset the log level
 		if atomicLogLevel.Level() == defaultLogLevel {
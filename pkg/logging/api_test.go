@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// logWrapper logs on behalf of its caller, like a wrapper package would.
+func logWrapper(msg string) {
+	WithCallerSkip(1).Info(msg)
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	tl := NewTestLogger(t)
+
+	_, file, line, _ := runtime.Caller(0)
+	logWrapper("wrapped")
+
+	entries := tl.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, file, entries[0].Caller.File)
+	assert.Equal(t, line+1, entries[0].Caller.Line)
+}
+
+func TestWithOptions(t *testing.T) {
+	tl := NewTestLogger(t)
+
+	WithOptions(zap.Fields(zap.String("component", "wrapper"))).Info("hello")
+	tl.AssertLogged(zapcore.InfoLevel, "hello", zap.String("component", "wrapper"))
+}
+
+func TestDiscard(t *testing.T) {
+	defer Configure(Config{})
+	assert.NoError(t, Configure(Config{RecentEntries: 10}))
+
+	restore := Discard()
+	Get().Info("discarded")
+	assert.Empty(t, Recent(10))
+
+	restore()
+	Get().Info("kept")
+	assert.Len(t, Recent(10), 1)
+	restore()
+
+	// cores added while discarding stay quiet until restore
+	restore = Discard()
+	core, logs := observer.New(zapcore.InfoLevel)
+	remove := AddCore(core)
+	defer remove()
+	Get().Info("discarded")
+	assert.Zero(t, logs.Len())
+	restore()
+	Get().Info("kept")
+	assert.Equal(t, 1, logs.Len())
+
+	// a configuration made while discarding survives restore
+	restore = Discard()
+	assert.NoError(t, Configure(Config{RecentEntries: 10, Fields: []zap.Field{zap.String("config", "new")}}))
+	Get().Info("discarded")
+	assert.Len(t, Recent(10), 2)
+	restore()
+	Get().Info("kept")
+	recent := Recent(10)
+	assert.Len(t, recent, 3)
+	assert.Equal(t, "new", recent[2].ContextMap()["config"])
+
+	for _, mode := range []string{"off", "discard"} {
+		core, closers, err := buildFilteredCore(Config{Mode: mode})
+		assert.NoError(t, err)
+		assert.Empty(t, closers)
+		assert.False(t, core.Enabled(zapcore.FatalLevel))
+	}
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// archiveReader closes both the decompressor and the file.
+type archiveReader struct {
+	io.Reader
+	closers []func() error
+}
+
+func (r *archiveReader) Close() error {
+	var err error
+	for _, c := range r.closers {
+		if cerr := c(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// OpenArchive opens a log file or an archive for reading, decompressing it
+// according to its extension.  Encrypted archives must be decrypted first.
+func OpenArchive(fn string) (io.ReadCloser, error) {
+	ext := filepath.Ext(fn)
+	if ext == "."+ageExtension || ext == "."+aesGCMExtension {
+		return nil, fmt.Errorf("%s is encrypted", fn)
+	}
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext {
+	case "." + compressedExtension:
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &archiveReader{Reader: zr, closers: []func() error{zr.Close, f.Close}}, nil
+
+	case "." + zstdExtension:
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &archiveReader{Reader: zr, closers: []func() error{
+			func() error { zr.Close(); return nil },
+			f.Close,
+		}}, nil
+
+	default:
+		return f, nil
+	}
+}
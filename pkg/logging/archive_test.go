@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(name string, wrap func(io.Writer) io.WriteCloser) string {
+		fn := filepath.Join(dir, name)
+		f, err := os.Create(fn)
+		assert.NoError(t, err)
+		defer f.Close()
+
+		w := wrap(f)
+		_, err = io.WriteString(w, "hello\n")
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		return fn
+	}
+
+	files := []string{
+		write("a.log", func(w io.Writer) io.WriteCloser { return nopWriteCloser{w} }),
+		write("b.log.gz", func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }),
+		write("c.log.zst", func(w io.Writer) io.WriteCloser {
+			zw, err := zstd.NewWriter(w)
+			assert.NoError(t, err)
+			return zw
+		}),
+	}
+	for _, fn := range files {
+		r, err := OpenArchive(fn)
+		assert.NoError(t, err)
+		data, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello\n", string(data), fn)
+		assert.NoError(t, r.Close())
+	}
+
+	_, err = OpenArchive(filepath.Join(dir, "d.log.gz.age"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveInfo describes an archive of a log file.
+type ArchiveInfo struct {
+	// Name is the file name of the archive in the log directory.
+	Name string `json:"name"`
+	// Path is the path of the archive.
+	Path string `json:"path"`
+	// Size is the size of the archive in bytes.
+	Size int64 `json:"size"`
+	// Created is when the log file was rotated into the archive, taken
+	// from the archive name if it has the default format and otherwise
+	// from its modification time.
+	Created time.Time `json:"created"`
+	// Compressed is set if the archive is compressed, and possibly
+	// encrypted.
+	Compressed bool `json:"compressed"`
+	// From and To is the time range covered by the archive.  The log file
+	// is opened when the previous archive is created, so From is the
+	// creation time of the previous archive, or zero for the oldest one.
+	// To is the creation time of the archive.
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// Covers reports whether the archive may hold entries logged between since
+// and until.  A zero since or until leaves that end open.
+func (a ArchiveInfo) Covers(since, until time.Time) bool {
+	if !since.IsZero() && a.To.Before(since) {
+		return false
+	}
+	return until.IsZero() || a.From.IsZero() || !a.From.After(until)
+}
+
+// ListArchives returns the archives of the log file, oldest first.  Archives
+// being compressed are left out.
+func (w *FileWriter) ListArchives() ([]ArchiveInfo, error) {
+	archives, err := w.archives()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]ArchiveInfo, len(archives))
+	for i, archive := range archives {
+		name := filepath.Base(archive.path)
+		info := ArchiveInfo{
+			Name:       name,
+			Path:       archive.path,
+			Size:       archive.size,
+			Created:    archive.modTime,
+			Compressed: compressedArchive(name),
+		}
+
+		timestamp := strings.TrimPrefix(name, w.archivePrefix())
+		if len(timestamp) >= len(archiveNameFormat) {
+			created, err := time.ParseInLocation(archiveNameFormat, timestamp[:len(archiveNameFormat)], time.Local)
+			if err == nil {
+				info.Created = created
+			}
+		}
+
+		info.To = info.Created
+		if i > 0 {
+			info.From = infos[i-1].Created
+		}
+		infos[i] = info
+	}
+	return infos, nil
+}
+
+// ListArchives lists the archives of the log file given by c like
+// FileWriter.ListArchives, without opening the log file.  This is meant for
+// tools reading the files of another process.
+func ListArchives(c FileWriterConfig) ([]ArchiveInfo, error) {
+	w := &FileWriter{config: c}
+	return w.ListArchives()
+}
+
+// compressedArchive reports whether the archive name has the extension of a
+// compressed or encrypted archive.
+func compressedArchive(name string) bool {
+	switch strings.TrimPrefix(filepath.Ext(name), ".") {
+	case compressedExtension, zstdExtension, ageExtension, aesGCMExtension:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListArchives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{
+		"logfile-2024-05-01T10-00-00.00000.log.gz",
+		"logfile-2024-05-02T10-00-00.00000.log",
+		"logfile-2024-05-03T10-00-00.00000.log.zst.processing",
+		"other-2024-05-01T10-00-00.00000.log",
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("entry\n"), 0644))
+	}
+	// the modification times don't match the names
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "logfile-2024-05-02T10-00-00.00000.log"), time.Now(), time.Now()))
+
+	archives, err := ListArchives(FileWriterConfig{LogDirName: dir, LogFileName: "logfile.log"})
+	assert.NoError(t, err)
+	assert.Len(t, archives, 2)
+
+	first := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	second := time.Date(2024, 5, 2, 10, 0, 0, 0, time.Local)
+
+	assert.Equal(t, "logfile-2024-05-01T10-00-00.00000.log.gz", archives[0].Name)
+	assert.Equal(t, filepath.Join(dir, archives[0].Name), archives[0].Path)
+	assert.Equal(t, int64(6), archives[0].Size)
+	assert.True(t, archives[0].Compressed)
+	assert.True(t, first.Equal(archives[0].Created))
+	assert.True(t, archives[0].From.IsZero())
+	assert.True(t, first.Equal(archives[0].To))
+
+	assert.False(t, archives[1].Compressed)
+	assert.True(t, second.Equal(archives[1].Created))
+	assert.True(t, first.Equal(archives[1].From))
+	assert.True(t, second.Equal(archives[1].To))
+
+	assert.True(t, archives[1].Covers(first.Add(time.Hour), time.Time{}))
+	assert.False(t, archives[1].Covers(second.Add(time.Hour), time.Time{}))
+	assert.False(t, archives[1].Covers(time.Time{}, first.Add(-time.Hour)))
+	assert.True(t, archives[0].Covers(time.Time{}, first.Add(-time.Hour)))
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultArchiveNameTemplate borrows the formatting from
+// https://github.com/natefinch/lumberjack/ for compatibility
+const defaultArchiveNameTemplate = "{prefix}-{timestamp}.{ext}"
+
+// archiveName returns the name for a new archive using the archive name
+// template.  If an archive with the same name already exists, eg. because we
+// rotate several times within the resolution of the timestamp, we add a
+// sequence number to make the name unique.
+func (w *FileWriter) archiveName(timestamp string) string {
+	dir := filepath.Dir(w.logFileNameFullPath)
+	filename := filepath.Base(w.logFileNameFullPath)
+	ext := filepath.Ext(filename)
+	prefix := filename[:len(filename)-len(ext)]
+
+	template := w.config.ArchiveNameTemplate
+	if template == "" {
+		template = defaultArchiveNameTemplate
+	}
+
+	for seq := 0; ; seq++ {
+		name := filepath.Join(dir, renderArchiveName(template, prefix, timestamp, strings.TrimPrefix(ext, "."), seq))
+		if !w.archiveExists(name) {
+			return name
+		}
+	}
+}
+
+// archiveExists checks if there is an archive with the given name, either
+// compressed or uncompressed.
+func (w *FileWriter) archiveExists(name string) bool {
+	for _, fn := range []string{name, name + "." + w.compressedExtension()} {
+		_, err := w.fs().Stat(fn)
+		if err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// compressedExtension returns the extension added to compressed archives,
+// eg. "gz" or "zst.age" if they are encrypted as well.
+func (w *FileWriter) compressedExtension() string {
+	ext := w.config.CompressionCodec.extension()
+	if w.config.Encryption.enabled() {
+		ext += "." + w.config.Encryption.extension()
+	}
+	return ext
+}
+
+// renderArchiveName fills in the placeholders of the template.  If the
+// template doesn't contain {seq} and seq is nonzero the sequence number is
+// inserted in front of the extension.
+func renderArchiveName(template string, prefix string, timestamp string, ext string, seq int) string {
+	if ext == "" {
+		template = strings.ReplaceAll(template, ".{ext}", "")
+	}
+
+	if seq > 0 && !strings.Contains(template, "{seq}") {
+		i := strings.LastIndex(template, ".{ext}")
+		if i < 0 {
+			i = len(template)
+		}
+		template = template[:i] + "-{seq}" + template[i:]
+	}
+
+	return strings.NewReplacer(
+		"{prefix}", prefix,
+		"{timestamp}", timestamp,
+		"{pid}", strconv.Itoa(os.Getpid()),
+		"{seq}", strconv.Itoa(seq),
+		"{ext}", ext,
+	).Replace(template)
+}
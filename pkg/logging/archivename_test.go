@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderArchiveName(t *testing.T) {
+	pid := os.Getpid()
+
+	assert.Equal(t, "log-ts.log", renderArchiveName(defaultArchiveNameTemplate, "log", "ts", "log", 0))
+	assert.Equal(t, "log-ts-2.log", renderArchiveName(defaultArchiveNameTemplate, "log", "ts", "log", 2))
+	assert.Equal(t, "log-ts", renderArchiveName(defaultArchiveNameTemplate, "log", "ts", "", 0))
+	assert.Equal(t, fmt.Sprintf("log-ts-%d.log", pid), renderArchiveName("{prefix}-{timestamp}-{pid}.{ext}", "log", "ts", "log", 0))
+	assert.Equal(t, "log-ts-3.log", renderArchiveName("{prefix}-{timestamp}-{seq}.{ext}", "log", "ts", "log", 3))
+}
+
+func TestArchiveNameCollisions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		ArchiveNameTemplate: "{prefix}-{timestamp}.{ext}",
+	})
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "logfile-ts.log"), nil, 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "logfile-ts-1.log.gz"), nil, 0644))
+
+	assert.Equal(t, filepath.Join(dir, "logfile-ts-2.log"), fw.archiveName("ts"))
+}
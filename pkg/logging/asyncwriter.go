@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"os"
+	"sync"
+)
+
+// asyncBuffer holds log entries that have not been written to the log file
+// yet when the FileWriter runs in async mode.
+type asyncBuffer struct {
+	mu      sync.Mutex
+	space   *sync.Cond
+	buf     []byte
+	spare   []byte
+	maxSize int
+	closed  bool
+	drop    bool
+	wake    chan struct{}
+
+	// flushMu serializes flushes so batches are written in order
+	flushMu sync.Mutex
+}
+
+// startAsync sets up the buffer and starts the goroutine writing it to disk.
+func (w *FileWriter) startAsync() {
+	size := w.config.AsyncBufferSizeBytes
+	if size <= 0 {
+		size = defaultAsyncBufferSize
+	}
+
+	w.async = &asyncBuffer{
+		buf:     make([]byte, 0, size),
+		spare:   make([]byte, 0, size),
+		maxSize: size,
+		drop:    w.config.DropOnOverload,
+		wake:    make(chan struct{}, 1),
+	}
+	w.async.space = sync.NewCond(&w.async.mu)
+
+	w.backgroundWG.Add(1)
+	go w.asyncFlusher()
+}
+
+// write appends msg to the buffer.  If the buffer is full we either wait for
+// space or, in drop mode, drop the message.  A message larger than the buffer
+// is accepted when the buffer is empty.
+func (a *asyncBuffer) write(msg []byte) (n int, dropped bool, err error) {
+	a.mu.Lock()
+	for !a.closed && len(a.buf) > 0 && len(a.buf)+len(msg) > a.maxSize {
+		if a.drop {
+			a.mu.Unlock()
+			return len(msg), true, nil
+		}
+		a.space.Wait()
+	}
+	if a.closed {
+		a.mu.Unlock()
+		return 0, false, os.ErrClosed
+	}
+	a.buf = append(a.buf, msg...)
+	a.mu.Unlock()
+
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+	return len(msg), false, nil
+}
+
+// close stops the buffer from accepting more writes.
+func (a *asyncBuffer) close() {
+	a.mu.Lock()
+	a.closed = true
+	a.space.Broadcast()
+	a.mu.Unlock()
+}
+
+// asyncFlusher writes the buffer to disk whenever there is something in it.
+// When the FileWriter is closed it writes what is left and exits.  Note that
+// you MUST call w.backgroundWG.Add(1) before starting this goroutine.
+func (w *FileWriter) asyncFlusher() {
+	defer w.backgroundWG.Done()
+
+	for {
+		select {
+		case <-w.async.wake:
+			w.flushAsync()
+		case <-w.done:
+			w.flushAsync()
+			return
+		}
+	}
+}
+
+// flushAsync writes the buffered entries to the log file.
+func (w *FileWriter) flushAsync() {
+	a := w.async
+
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+
+	a.mu.Lock()
+	batch := a.buf
+	a.buf = a.spare[:0]
+	a.space.Broadcast()
+	a.mu.Unlock()
+
+	if len(batch) > 0 {
+		w.mu.Lock()
+		w.writeLocked(batch)
+		w.mu.Unlock()
+	}
+
+	a.mu.Lock()
+	a.spare = batch[:0]
+	a.mu.Unlock()
+}
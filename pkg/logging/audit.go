@@ -0,0 +1,261 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AuditConfig configures the audit log.
+type AuditConfig struct {
+	// If Enabled is set audit events are written to their own file.
+	// Otherwise they are logged by the package logger.
+	Enabled bool
+	// FileWriter configures the audit log file.  Use a separate directory
+	// from the other log files.
+	FileWriter FileWriterConfig
+}
+
+const (
+	auditPrevHashKey = "prev_hash"
+	auditHashKey     = "hash"
+	auditDirName     = "audit"
+	auditFileName    = "audit.log"
+)
+
+// auditHashSuffixLen is the length of the hash field that ends each record,
+// ie. `,"hash":"<64 hex digits>"}`.
+var auditHashSuffixLen = len(`,"":""}`) + len(auditHashKey) + sha256.Size*2
+
+// ErrAuditLogClosed is returned when writing to a closed audit log.
+var ErrAuditLogClosed = errors.New("audit log is closed")
+
+// AuditLogger writes audit events as JSON records to a rotating file.  The
+// records are hash chained: each record holds the hash of the previous record
+// in prev_hash and its own hash in hash, so modified, inserted or removed
+// records can be detected with VerifyAuditLog.
+type AuditLogger struct {
+	mu       sync.Mutex
+	fw       *FileWriter
+	enc      zapcore.Encoder
+	prevHash string
+	closed   bool
+}
+
+var (
+	auditLog   *AuditLogger
+	auditLogMu sync.RWMutex
+)
+
+// NewAuditLogger creates an audit logger.  If the audit log file exists the
+// chain continues from its last record.
+func NewAuditLogger(c FileWriterConfig) (*AuditLogger, error) {
+	if c.LogDirName == "" {
+		c.LogDirName = filepath.Join(defaultLogDirName, auditDirName)
+	}
+	if c.LogFileName == "" {
+		c.LogFileName = auditFileName
+	}
+
+	// read the last hash before the file writer gets to rotate the file
+	prevHash, err := lastAuditHash(filepath.Join(c.LogDirName, c.LogFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	fw, err := newFileWriter(c)
+	if err != nil {
+		return nil, err
+	}
+
+	ec := zap.NewProductionEncoderConfig()
+	ec.LevelKey = ""
+	ec.CallerKey = ""
+	ec.StacktraceKey = ""
+	ec.MessageKey = "event"
+	ec.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+
+	return &AuditLogger{
+		fw:       fw,
+		enc:      zapcore.NewJSONEncoder(ec),
+		prevHash: prevHash,
+	}, nil
+}
+
+// Log writes an audit record for event.
+func (a *AuditLogger) Log(event string, fields ...zap.Field) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return ErrAuditLogClosed
+	}
+
+	all := make([]zap.Field, 0, len(fields)+1)
+	all = append(all, fields...)
+	all = append(all, zap.String(auditPrevHashKey, a.prevHash))
+
+	buf, err := a.enc.EncodeEntry(zapcore.Entry{Time: clock.Now(), Message: event}, all)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	// the hash covers the record up to the closing brace, which is
+	// replaced by the hash field
+	record := bytes.TrimRight(buf.Bytes(), "\n")
+	record = record[:len(record)-1]
+	hash := auditHash(record)
+
+	line := make([]byte, 0, len(record)+auditHashSuffixLen+1)
+	line = append(line, record...)
+	line = append(line, fmt.Sprintf(`,"%s":"%s"}`, auditHashKey, hash)...)
+	line = append(line, '\n')
+
+	_, err = a.fw.Write(line)
+	if err != nil {
+		return err
+	}
+	a.prevHash = hash
+	return nil
+}
+
+// Close closes the audit log file.
+func (a *AuditLogger) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+	return a.fw.Close()
+}
+
+// Audit writes an audit record for event to the audit log configured with
+// Configure.  If there is no audit log the event is logged by the package
+// logger under the name "audit".
+func Audit(event string, fields ...zap.Field) {
+	auditLogMu.RLock()
+	defer auditLogMu.RUnlock()
+
+	if auditLog == nil {
+		logger.Named("audit").Info(event, fields...)
+		return
+	}
+
+	err := auditLog.Log(event, fields...)
+	if err != nil {
+		lg.Errorw("error writing audit record", "event", event, "err", err)
+	}
+}
+
+// configureAudit replaces the audit log of the package.
+func configureAudit(c AuditConfig) error {
+	auditLogMu.Lock()
+	defer auditLogMu.Unlock()
+
+	// close the old log first so the new one continues the chain from its
+	// last record
+	if auditLog != nil {
+		auditLog.Close()
+		auditLog = nil
+	}
+
+	if !c.Enabled {
+		return nil
+	}
+
+	a, err := NewAuditLogger(c.FileWriter)
+	if err != nil {
+		return err
+	}
+	auditLog = a
+	return nil
+}
+
+// VerifyAuditLog checks the hash chain of the audit records read from r and
+// returns the hash of the last record.  If prevHash is set the first record
+// must follow the record with that hash, which is how a chain spanning
+// several files is verified.
+func VerifyAuditLog(r io.Reader, prevHash string) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	first := true
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var record map[string]interface{}
+		err := json.Unmarshal(line, &record)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		hash, _ := record[auditHashKey].(string)
+		if len(line) < auditHashSuffixLen || len(hash) != sha256.Size*2 {
+			return "", fmt.Errorf("line %d: missing hash", lineNum)
+		}
+		if auditHash(line[:len(line)-auditHashSuffixLen]) != hash {
+			return "", fmt.Errorf("line %d: record has been modified", lineNum)
+		}
+
+		recordPrevHash, _ := record[auditPrevHashKey].(string)
+		if (!first || prevHash != "") && recordPrevHash != prevHash {
+			return "", fmt.Errorf("line %d: chain is broken", lineNum)
+		}
+
+		prevHash = hash
+		first = false
+	}
+	return prevHash, scanner.Err()
+}
+
+// lastAuditHash returns the hash of the last record in the audit log file at
+// fn, or an empty string if there is none.
+func lastAuditHash(fn string) (string, error) {
+	f, err := os.Open(fn)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var hash string
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if json.Unmarshal(scanner.Bytes(), &record) != nil {
+			continue
+		}
+		if h, ok := record[auditHashKey].(string); ok {
+			hash = h
+		}
+	}
+	return hash, scanner.Err()
+}
+
+func auditHash(record []byte) string {
+	sum := sha256.Sum256(record)
+	return hex.EncodeToString(sum[:])
+}
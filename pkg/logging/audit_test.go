@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestAuditLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := FileWriterConfig{LogDirName: dir, LogFileName: "audit.log"}
+
+	clock = NewFakeClock(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	defer func() { clock = SystemClock }()
+
+	a, err := NewAuditLogger(c)
+	assert.NoError(t, err)
+	assert.NoError(t, a.Log("login", zap.String("user", "alice")))
+	assert.NoError(t, a.Log("delete", zap.String("user", "alice"), zap.Int("id", 42)))
+	assert.NoError(t, a.Close())
+	assert.ErrorIs(t, a.Log("logout"), ErrAuditLogClosed)
+
+	// a new logger continues the chain
+	a, err = NewAuditLogger(c)
+	assert.NoError(t, err)
+	assert.NoError(t, a.Log("logout", zap.String("user", "alice")))
+	assert.NoError(t, a.Close())
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "audit.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, bytes.Count(data, []byte("\n")))
+	assert.Equal(t, 3, bytes.Count(data, []byte(`"ts":"2024-05-01T10:00:00Z"`)))
+
+	last, err := VerifyAuditLog(bytes.NewReader(data), "")
+	assert.NoError(t, err)
+	assert.Len(t, last, 64)
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	// modified record
+	tampered := bytes.Replace(data, []byte(`"id":42`), []byte(`"id":43`), 1)
+	_, err = VerifyAuditLog(bytes.NewReader(tampered), "")
+	assert.EqualError(t, err, "line 2: record has been modified")
+
+	// removed record
+	removed := append(append([]byte{}, lines[0]...), lines[2]...)
+	_, err = VerifyAuditLog(bytes.NewReader(removed), "")
+	assert.EqualError(t, err, "line 2: chain is broken")
+
+	// the chain must continue from the given hash
+	_, err = VerifyAuditLog(bytes.NewReader(data), last)
+	assert.EqualError(t, err, "line 1: chain is broken")
+}
+
+func TestAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, configureAudit(AuditConfig{
+		Enabled:    true,
+		FileWriter: FileWriterConfig{LogDirName: dir},
+	}))
+	Audit("login", zap.String("user", "bob"))
+	assert.NoError(t, configureAudit(AuditConfig{}))
+
+	f, err := os.Open(filepath.Join(dir, auditFileName))
+	assert.NoError(t, err)
+	defer f.Close()
+	_, err = VerifyAuditLog(f, "")
+	assert.NoError(t, err)
+
+	// without an audit log events go to the package logger
+	tl := NewTestLogger(t)
+	Audit("logout", zap.String("user", "bob"))
+	tl.AssertLogged(zap.InfoLevel, "logout", zap.String("user", "bob"))
+}
@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func newBenchmarkFileWriter(b *testing.B, c FileWriterConfig) *FileWriter {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { os.RemoveAll(dir) })
+
+	c.LogDirName = dir
+	c.LogFileName = "bench.log"
+	fw, err := newFileWriter(c)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { fw.Close() })
+	return fw
+}
+
+func BenchmarkFileWriterWrite(b *testing.B) {
+	fw := newBenchmarkFileWriter(b, FileWriterConfig{MaxLogFileSizeBytes: maxLogFileSizeBytes})
+	msg := []byte(randomString(200) + "\n")
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(msg)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fw.Write(msg)
+	}
+}
+
+func BenchmarkFileWriterWriteParallel(b *testing.B) {
+	fw := newBenchmarkFileWriter(b, FileWriterConfig{MaxLogFileSizeBytes: maxLogFileSizeBytes})
+	msg := []byte(randomString(200) + "\n")
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(msg)))
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			fw.Write(msg)
+		}
+	})
+}
+
+func BenchmarkFileWriterWriteAsync(b *testing.B) {
+	fw := newBenchmarkFileWriter(b, FileWriterConfig{MaxLogFileSizeBytes: maxLogFileSizeBytes, Async: true})
+	msg := []byte(randomString(200) + "\n")
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(msg)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fw.Write(msg)
+	}
+}
+
+func BenchmarkFileWriterWriteBuffered(b *testing.B) {
+	fw := newBenchmarkFileWriter(b, FileWriterConfig{MaxLogFileSizeBytes: maxLogFileSizeBytes, BufferSizeBytes: 64 * 1024})
+	msg := []byte(randomString(200) + "\n")
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(msg)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fw.Write(msg)
+	}
+}
+
+func BenchmarkFileWriterRotate(b *testing.B) {
+	fw := newBenchmarkFileWriter(b, FileWriterConfig{MaxLogFileSizeBytes: maxLogFileSizeBytes})
+	msg := []byte(randomString(200) + "\n")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fw.Write(msg)
+		fw.mu.Lock()
+		fw.rotate()
+		fw.mu.Unlock()
+	}
+}
+
+func BenchmarkFileWriterCompress(b *testing.B) {
+	fw := newBenchmarkFileWriter(b, FileWriterConfig{Compress: true})
+	data := []byte{}
+	for len(data) < 1<<20 {
+		data = append(data, randomString(200)+"\n"...)
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fn := filepath.Join(fw.config.LogDirName, "bench-archive.log")
+		b.StopTimer()
+		if err := os.WriteFile(fn, data, 0644); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		fw.compressorWG.Add(1)
+		fw.compress(fn)
+	}
+}
+
+// BenchmarkLoggerJSONFile logs through zap to a file with the JSON encoder,
+// which is the typical production configuration.
+func BenchmarkLoggerJSONFile(b *testing.B) {
+	fw := newBenchmarkFileWriter(b, FileWriterConfig{MaxLogFileSizeBytes: maxLogFileSizeBytes})
+	enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	log := zap.New(newFileWriterCore(enc, fw, zapcore.InfoLevel))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info("request handled", zap.String("path", "/api/v1/users"), zap.Int("status", 200))
+	}
+}
+
+func TestFileWriterWriteAllocs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{LogDirName: dir, LogFileName: "logfile.log", MaxLogFileSizeBytes: maxLogFileSizeBytes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fw.Close()
+
+	msg := []byte(randomString(200) + "\n")
+	if allocs := testing.AllocsPerRun(100, func() { fw.Write(msg) }); allocs != 0 {
+		t.Errorf("Write allocates %v times", allocs)
+	}
+
+	core := newFileWriterCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), fw, zapcore.InfoLevel)
+	fields := []zapcore.Field{zap.String("path", "/api/v1/users"), zap.Int("status", 200)}
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Message: "request handled"}
+	if allocs := testing.AllocsPerRun(100, func() { core.Write(ent, fields) }); allocs != 0 {
+		t.Errorf("core Write allocates %v times", allocs)
+	}
+}
@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const defaultFlushInterval = time.Second
+
+// out returns the writer entries are written to, which is the buffer if
+// BufferSizeBytes is set.  This assumes that w.mu is locked.
+func (w *FileWriter) out() io.Writer {
+	if w.bufWriter != nil {
+		return w.bufWriter
+	}
+	return w.logFile
+}
+
+// resetBuffer points the buffer at the newly opened log file.  Whatever is
+// left in the buffer is discarded, so flush it before closing the previous
+// file.  This assumes that w.mu is locked.
+func (w *FileWriter) resetBuffer() {
+	if w.config.BufferSizeBytes <= 0 {
+		return
+	}
+	if w.bufWriter == nil {
+		w.bufWriter = bufio.NewWriterSize(w.logFile, w.config.BufferSizeBytes)
+		return
+	}
+	w.bufWriter.Reset(w.logFile)
+}
+
+// flushBuffer writes the buffered entries to the log file.  This assumes
+// that w.mu is locked.
+func (w *FileWriter) flushBuffer() error {
+	if w.bufWriter == nil || w.bufWriter.Buffered() == 0 {
+		return nil
+	}
+	err := w.bufWriter.Flush()
+	if err != nil {
+		w.recordError(err)
+		w.retryBuffer()
+	}
+	return err
+}
+
+// retryBuffer clears the error of the buffer after a failed write, which
+// bufio.Writer would otherwise return for every write after it, so the next
+// write tries the log file again.  The entries left in the buffer are
+// discarded.  This assumes that w.mu is locked.
+func (w *FileWriter) retryBuffer() {
+	if w.bufWriter != nil {
+		w.bufWriter.Reset(w.logFile)
+	}
+}
+
+// Flush writes the buffered entries to the log file without syncing it to
+// disk.  It does nothing unless BufferSizeBytes is set.
+func (w *FileWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed.Load() {
+		return os.ErrClosed
+	}
+	return w.flushBuffer()
+}
+
+// flushPeriodically flushes the buffer every FlushInterval.  Note that you
+// MUST call w.backgroundWG.Add(1) before starting this goroutine.
+func (w *FileWriter) flushPeriodically() {
+	defer w.backgroundWG.Done()
+
+	interval := w.config.FlushInterval
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := w.Flush()
+			if err != nil && err != os.ErrClosed {
+				fmt.Printf("error flushing logfile %s: %v\n", w.logFileNameFullPath, err)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestFileWriterBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:      dir,
+		LogFileName:     "logfile.log",
+		BufferSizeBytes: 4096,
+		FlushInterval:   time.Hour,
+	})
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	logFile := filepath.Join(dir, "logfile.log")
+	fileSize := func() int64 {
+		info, err := os.Stat(logFile)
+		assert.NoError(t, err)
+		return info.Size()
+	}
+
+	_, err = fw.Write([]byte("buffered\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), fileSize())
+
+	assert.NoError(t, fw.Flush())
+	assert.Equal(t, int64(len("buffered\n")), fileSize())
+
+	// errors are flushed right away
+	log := zap.New(newFileWriterCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), fw, zapcore.InfoLevel))
+	log.Info("info")
+	size := fileSize()
+	log.Error("error")
+	assert.Greater(t, fileSize(), size)
+}
+
+func TestFileWriterFlushInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:      dir,
+		LogFileName:     "logfile.log",
+		BufferSizeBytes: 4096,
+		FlushInterval:   10 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	_, err = fw.Write([]byte("buffered\n"))
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(filepath.Join(dir, "logfile.log"))
+		return err == nil && string(data) == "buffered\n"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+// flakyFS is a memFS where writes fail while full is set, like a full disk.
+type flakyFS struct {
+	*memFS
+	full atomic.Bool
+}
+
+func (f *flakyFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	file, err := f.memFS.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &flakyFile{File: file, fs: f}, nil
+}
+
+type flakyFile struct {
+	File
+	fs *flakyFS
+}
+
+func (f *flakyFile) Write(p []byte) (int, error) {
+	if f.fs.full.Load() {
+		return 0, syscall.ENOSPC
+	}
+	return f.File.Write(p)
+}
+
+func TestFileWriterBufferRecovers(t *testing.T) {
+	for _, spill := range []int{0, 1024} {
+		fs := &flakyFS{memFS: newMemFS()}
+		fw, err := NewFileWriter(FileWriterConfig{
+			LogDirName:           filepath.Join(os.TempDir(), "filewriter-flaky-does-not-exist"),
+			LogFileName:          "logfile.log",
+			BufferSizeBytes:      4096,
+			FlushInterval:        time.Hour,
+			SpillBufferSizeBytes: spill,
+			SpillRetryInterval:   time.Nanosecond,
+			FS:                   fs,
+		})
+		assert.NoError(t, err)
+
+		_, err = fw.Write([]byte("first\n"))
+		assert.NoError(t, err)
+		assert.NoError(t, fw.Flush())
+
+		fs.full.Store(true)
+		_, err = fw.Write([]byte("lost\n"))
+		assert.NoError(t, err)
+		assert.ErrorIs(t, fw.Flush(), syscall.ENOSPC)
+
+		// once there is room again the buffer is written
+		fs.full.Store(false)
+		_, err = fw.Write([]byte("second\n"))
+		assert.NoError(t, err)
+		assert.NoError(t, fw.Flush())
+		assert.NoError(t, fw.Close())
+
+		f, err := fs.OpenFile(fw.logFileNameFullPath, os.O_RDONLY, 0)
+		assert.NoError(t, err)
+		data, err := io.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Equal(t, "first\nsecond\n", string(data), "spill buffer size %d", spill)
+	}
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCEFEncoder(t *testing.T) {
+	enc := NewCEFEncoder(CEFConfig{
+		DeviceVendor:  "Acme",
+		DeviceProduct: "Gate|way",
+		DeviceVersion: "1.2",
+		Extensions: map[string]string{
+			"user_id":   "suser",
+			"client.ip": "src",
+		},
+	})
+
+	var buf bytes.Buffer
+	l := zap.New(zapcore.NewCore(enc, zapcore.AddSync(&buf), zapcore.InfoLevel))
+
+	l.With(zap.String("user_id", "bob")).Warn("login failed",
+		zap.String("event", "auth-001"),
+		zap.Object("client", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("ip", "10.0.0.1")
+			return nil
+		})),
+		zap.String("reason", "bad password=yes\nreally"),
+	)
+
+	line := buf.String()
+	assert.Regexp(t, `^CEF:0\|Acme\|Gate\\\|way\|1\.2\|auth-001\|login failed\|5\|rt=\d+ `, line)
+	assert.Contains(t, line, ` src=10.0.0.1 `)
+	assert.Contains(t, line, ` reason=bad password\=yes\nreally `)
+	assert.Contains(t, line, ` suser=bob`+"\n")
+	assert.NotContains(t, line, "event=")
+}
+
+func TestCEFEncoderDefaults(t *testing.T) {
+	enc := NewCEFEncoder(CEFConfig{DeviceProduct: "app"})
+	buf, err := enc.EncodeEntry(zapcore.Entry{
+		Level:      zapcore.ErrorLevel,
+		Time:       time.Unix(1, 0),
+		LoggerName: "db",
+		Message:    "failed",
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "CEF:0|Lab5e|app|0|db|failed|7|rt=1000\n", buf.String())
+}
+
+func TestCEFEncoderNamespace(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewCEFEncoder(CEFConfig{DeviceProduct: "app"})
+	l := zap.New(zapcore.NewCore(enc, zapcore.AddSync(&buf), zapcore.InfoLevel))
+
+	req := l.With(zap.String("app", "gw"), zap.Namespace("req"), zap.String("id", "1"))
+	req.With(zap.Namespace("user")).Info("first", zap.String("name", "bob"))
+	req.Info("second", zap.String("method", "GET"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[0], " app=gw req.id=1 req.user.name=bob"), lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], " app=gw req.id=1 req.method=GET"), lines[1])
+}
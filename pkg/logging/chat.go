@@ -0,0 +1,257 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ChatKind is the kind of chat service a ChatConfig posts to.
+type ChatKind string
+
+// The chat services supported by NewChatCore.
+const (
+	ChatSlack ChatKind = "slack"
+	ChatTeams ChatKind = "teams"
+)
+
+// ChatConfig configures posting summaries of entries to a Slack or Microsoft
+// Teams channel.
+type ChatConfig struct {
+	// URL is the incoming webhook of the channel.  Configure only posts to
+	// the channel if it is set.
+	URL string
+	// Kind is the chat service.  Defaults to ChatSlack.
+	Kind ChatKind
+	// Level is the lowest level posted.  Levels below Warn are raised to
+	// Warn.
+	Level zapcore.Level
+	// Interval is how long entries are collected before they are posted
+	// as a single message.  Defaults to 30 seconds.
+	Interval time.Duration
+	// MaxEntries is the number of entries listed in a message.  Further
+	// entries are only counted.  Defaults to 20.
+	MaxEntries int
+	// RateLimit limits the number of identical entries posted.
+	RateLimit RateLimitConfig
+}
+
+const (
+	defaultChatInterval   = 30 * time.Second
+	defaultChatMaxEntries = 20
+	chatPostTimeout       = 10 * time.Second
+)
+
+// chatNotifier collects the entries of a chat core and its derived cores
+// and posts them in batches.
+type chatNotifier struct {
+	config ChatConfig
+	client *http.Client
+	host   string
+
+	mu      sync.Mutex
+	lines   []string
+	skipped int
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// chatCore is a zapcore.Core which posts summaries of entries to a chat
+// channel.
+type chatCore struct {
+	n      *chatNotifier
+	fields []zapcore.Field
+}
+
+// NewChatCore returns a core posting entries at Warn level and above to a
+// Slack or Teams channel.  The entries are collected for c.Interval and
+// posted as a single message listing at most c.MaxEntries of them, so a
+// burst of errors does not flood the channel.  The returned closer posts the
+// remaining entries and must be closed when the core is no longer in use.
+func NewChatCore(c ChatConfig) (zapcore.Core, io.Closer, error) {
+	if c.Kind == "" {
+		c.Kind = ChatSlack
+	}
+	if c.Kind != ChatSlack && c.Kind != ChatTeams {
+		return nil, nil, fmt.Errorf("unknown chat kind %q", c.Kind)
+	}
+	if c.Level < zapcore.WarnLevel {
+		c.Level = zapcore.WarnLevel
+	}
+	if c.Interval <= 0 {
+		c.Interval = defaultChatInterval
+	}
+	if c.MaxEntries <= 0 {
+		c.MaxEntries = defaultChatMaxEntries
+	}
+
+	host, _ := os.Hostname()
+	n := &chatNotifier{
+		config: c,
+		client: &http.Client{Timeout: chatPostTimeout},
+		host:   host,
+		done:   make(chan struct{}),
+	}
+	n.wg.Add(1)
+	go n.postPeriodically()
+
+	return NewRateLimitCore(&chatCore{n: n}, c.RateLimit), n, nil
+}
+
+func (c *chatCore) Enabled(l zapcore.Level) bool {
+	return l >= c.n.config.Level
+}
+
+func (c *chatCore) With(fields []zapcore.Field) zapcore.Core {
+	return &chatCore{
+		n:      c.n,
+		fields: append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *chatCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *chatCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	var line strings.Builder
+	line.WriteString(ent.Level.CapitalString())
+	if ent.LoggerName != "" {
+		line.WriteString(" " + ent.LoggerName + ":")
+	}
+	line.WriteString(" " + ent.Message)
+
+	keys := make([]string, 0, len(enc.Fields))
+	for key := range enc.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&line, " %s=%v", key, enc.Fields[key])
+	}
+
+	c.n.add(line.String())
+	return nil
+}
+
+// Sync posts the collected entries right away, so they are not lost if the
+// process exits.
+func (c *chatCore) Sync() error {
+	return c.n.post()
+}
+
+func (n *chatNotifier) add(line string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.lines) >= n.config.MaxEntries {
+		n.skipped++
+		return
+	}
+	n.lines = append(n.lines, line)
+}
+
+func (n *chatNotifier) postPeriodically() {
+	defer n.wg.Done()
+
+	ticker := time.NewTicker(n.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := n.post()
+			if err != nil {
+				fmt.Printf("error posting log entries to %s: %v\n", n.config.Kind, err)
+			}
+		case <-n.done:
+			return
+		}
+	}
+}
+
+// post posts the collected entries as one message.
+func (n *chatNotifier) post() error {
+	n.mu.Lock()
+	lines, skipped := n.lines, n.skipped
+	n.lines, n.skipped = nil, 0
+	n.mu.Unlock()
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	summary := fmt.Sprintf("%d log entries from %s", len(lines)+skipped, n.host)
+	text := strings.Join(lines, "\n")
+	if skipped > 0 {
+		text += fmt.Sprintf("\n... and %d more", skipped)
+	}
+
+	var payload interface{}
+	switch n.config.Kind {
+	case ChatTeams:
+		payload = map[string]string{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  summary,
+			"title":    summary,
+			"text":     "<pre>" + html.EscapeString(text) + "</pre>",
+		}
+	default:
+		payload = map[string]string{
+			"text": summary + "\n```\n" + text + "\n```",
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(n.config.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s webhook returned %s", n.config.Kind, resp.Status)
+	}
+	return nil
+}
+
+// Close posts the remaining entries and stops posting.
+func (n *chatNotifier) Close() error {
+	close(n.done)
+	n.wg.Wait()
+	return n.post()
+}
+
+// chatConfigFromEnv returns the chat configuration given by the environment
+// variables.
+func chatConfigFromEnv() ChatConfig {
+	return ChatConfig{
+		URL:  getenv(LogChatWebhookEnvVar),
+		Kind: ChatKind(getenv(LogChatKindEnvVar)),
+	}
+}
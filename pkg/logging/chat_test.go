@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestChatCore(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		messages []map[string]string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		mu.Lock()
+		messages = append(messages, message)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	core, closer, err := NewChatCore(ChatConfig{URL: server.URL, MaxEntries: 2})
+	assert.NoError(t, err)
+
+	log := zap.New(core).Named("billing")
+	log.Info("not posted")
+	log.Warn("low balance", zap.Int("balance", 3))
+	log.Error("payment failed")
+	log.Error("payment failed again")
+	assert.NoError(t, log.Sync())
+
+	log.Error("after sync")
+	assert.NoError(t, closer.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, messages, 2)
+	assert.Contains(t, messages[0]["text"], "3 log entries from")
+	assert.Contains(t, messages[0]["text"], "WARN billing: low balance balance=3\nERROR billing: payment failed\n... and 1 more")
+	assert.Contains(t, messages[1]["text"], "ERROR billing: after sync")
+
+	_, _, err = NewChatCore(ChatConfig{URL: server.URL, Kind: "irc"})
+	assert.Error(t, err)
+}
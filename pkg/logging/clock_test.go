@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+
+	t1 := c.NewTimer(time.Minute)
+	t2 := c.NewTimer(time.Hour)
+	t3 := c.NewTimer(time.Hour)
+	assert.True(t, t3.Stop())
+
+	c.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), c.Now())
+	assert.Equal(t, start.Add(time.Minute), <-t1.C())
+	assert.Len(t, t2.C(), 0)
+	assert.Equal(t, 1, c.Timers())
+
+	c.Advance(time.Hour)
+	assert.Len(t, t2.C(), 1)
+	assert.Len(t, t3.C(), 0)
+}
+
+func TestFileWriterFakeClock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := NewFakeClock(time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local))
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:     dir,
+		LogFileName:    "logfile.log",
+		RotateInterval: time.Hour,
+		Clock:          c,
+	})
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	_, err = fw.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+
+	// wait for the rotation timer before moving the clock
+	assert.Eventually(t, func() bool { return c.Timers() == 1 }, 2*time.Second, time.Millisecond)
+	c.Advance(time.Hour)
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(dir, "logfile-2024-05-01T11-00-00.00000.log"))
+		return err == nil
+	}, 2*time.Second, time.Millisecond)
+}
+
+func TestSetLevelTemporarilyFakeClock(t *testing.T) {
+	c := NewFakeClock(time.Now())
+	clock = c
+	defer func() { clock = SystemClock }()
+	defer atomicLogLevel.SetLevel(defaultLogLevel)
+
+	_, err := SetLevelTemporarily(zapcore.DebugLevel, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, zapcore.DebugLevel, atomicLogLevel.Level())
+
+	c.Advance(time.Minute)
+	assert.Eventually(t, func() bool {
+		return atomicLogLevel.Level() == defaultLogLevel
+	}, 2*time.Second, time.Millisecond)
+}
@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec is the compression format used for archived log files.
+type Codec string
+
+const (
+	// CodecGzip compresses archives with gzip.  This is the default.
+	CodecGzip Codec = "gzip"
+	// CodecZstd compresses archives with zstd, which is considerably faster
+	// than gzip for large files at a comparable compression ratio.
+	CodecZstd Codec = "zstd"
+)
+
+const zstdExtension = "zst"
+
+// extension returns the file extension used for files compressed with c.
+func (c Codec) extension() string {
+	if c == CodecZstd {
+		return zstdExtension
+	}
+	return compressedExtension
+}
+
+// newWriter returns a writer compressing to w.
+func (c Codec) newWriter(w io.Writer) (io.WriteCloser, error) {
+	switch c {
+	case CodecGzip, "":
+		return gzip.NewWriter(w), nil
+	case CodecZstd:
+		return zstd.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown compression codec %q", c)
+	}
+}
@@ -0,0 +1,296 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// FileConfig is the format of logging configuration files.  Values that are
+// not set in the file are taken from the environment, see ConfigFromEnv.
+type FileConfig struct {
+	// Mode is the logger mode, see LoggerSpecEnvVar.
+	Mode string `yaml:"mode" json:"mode"`
+	// Encoding is the encoding of the structured output, see Config.
+	Encoding string `yaml:"encoding" json:"encoding"`
+	// Level is the log level, eg. "debug", or a level spec with levels for
+	// individual packages, eg. "info,storage=debug".  See SetLevelSpec.
+	Level string `yaml:"level" json:"level"`
+	// File configures the file output.
+	File FileConfigFile `yaml:"file" json:"file"`
+	// Syslog configures the "syslog" mode.
+	Syslog FileConfigSyslog `yaml:"syslog" json:"syslog"`
+	// Routes routes entries to several files, see Config.FileRoutes.  The
+	// settings of each route are applied on top of File.
+	Routes []FileConfigRoute `yaml:"routes" json:"routes"`
+	// Fields are attached to every entry, eg. service: billing.  They
+	// replace the fields given by the environment.
+	Fields map[string]string `yaml:"fields" json:"fields"`
+}
+
+// FileConfigRoute is a file route of a FileConfig.
+type FileConfigRoute struct {
+	// Level is the lowest level written to the file.  If it is empty all
+	// entries are written.
+	Level string `yaml:"level" json:"level"`
+	// Loggers lists the named loggers whose entries are written to the
+	// file, see FileRoute.
+	Loggers []string       `yaml:"loggers" json:"loggers"`
+	File    FileConfigFile `yaml:"file" json:"file"`
+}
+
+// FileConfigFile is the file writer part of a FileConfig.
+type FileConfigFile struct {
+	Dir          string `yaml:"dir" json:"dir"`
+	Name         string `yaml:"name" json:"name"`
+	MaxSizeMB    int64  `yaml:"maxSizeMB" json:"maxSizeMB"`
+	MaxDirSizeMB int64  `yaml:"maxDirSizeMB" json:"maxDirSizeMB"`
+	MaxArchives  int    `yaml:"maxArchives" json:"maxArchives"`
+	MaxAgeDays   int    `yaml:"maxAgeDays" json:"maxAgeDays"`
+	Compression  string `yaml:"compression" json:"compression"`
+	Async        bool   `yaml:"async" json:"async"`
+}
+
+// FileConfigSyslog is the syslog part of a FileConfig.
+type FileConfigSyslog struct {
+	Network string `yaml:"network" json:"network"`
+	Address string `yaml:"address" json:"address"`
+}
+
+// ReadConfigFile reads a YAML or JSON configuration file.  Files ending in
+// ".json" are parsed as JSON, everything else as YAML.
+func ReadConfigFile(path string) (FileConfig, error) {
+	var fc FileConfig
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fc, err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &fc)
+	} else {
+		err = yaml.Unmarshal(data, &fc)
+	}
+	if err != nil {
+		return fc, fmt.Errorf("invalid logging config file %s: %w", path, err)
+	}
+
+	for _, route := range fc.Routes {
+		var level zapcore.Level
+		if route.Level != "" && level.UnmarshalText([]byte(route.Level)) != nil {
+			return fc, fmt.Errorf("invalid logging config file %s: unknown route level %q", path, route.Level)
+		}
+	}
+	return fc, nil
+}
+
+// Config returns the logger configuration given by the file on top of the
+// configuration given by the environment variables.
+func (fc FileConfig) Config() Config {
+	c := ConfigFromEnv()
+	if fc.Mode != "" {
+		c.Mode = fc.Mode
+	}
+	if fc.Encoding != "" {
+		c.Encoding = fc.Encoding
+	}
+
+	fc.File.apply(&c.FileWriter)
+	c.FileRoutes = fileRoutesFromEnv(c.FileWriter)
+
+	if len(fc.Routes) > 0 {
+		c.FileRoutes = make([]FileRoute, 0, len(fc.Routes))
+		for _, r := range fc.Routes {
+			route := FileRoute{Loggers: r.Loggers, FileWriter: c.FileWriter}
+			r.File.apply(&route.FileWriter)
+
+			var level zapcore.Level
+			if r.Level != "" && level.UnmarshalText([]byte(r.Level)) == nil {
+				route.Level = level
+			}
+			c.FileRoutes = append(c.FileRoutes, route)
+		}
+	}
+
+	if len(fc.Fields) > 0 {
+		keys := make([]string, 0, len(fc.Fields))
+		for key := range fc.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		c.Fields = make([]zap.Field, 0, len(keys))
+		for _, key := range keys {
+			c.Fields = append(c.Fields, zap.String(key, fc.Fields[key]))
+		}
+	}
+
+	if fc.Syslog.Network != "" {
+		c.Syslog.Network = fc.Syslog.Network
+	}
+	if fc.Syslog.Address != "" {
+		c.Syslog.Address = fc.Syslog.Address
+	}
+	return c
+}
+
+// apply applies the settings of the file to fw.
+func (f FileConfigFile) apply(fw *FileWriterConfig) {
+	if f.Dir != "" {
+		fw.LogDirName = f.Dir
+	}
+	if f.Name != "" {
+		fw.LogFileName = f.Name
+	}
+	if f.MaxSizeMB > 0 {
+		fw.MaxLogFileSizeBytes = f.MaxSizeMB * 1024 * 1024
+	}
+	if f.MaxDirSizeMB > 0 {
+		fw.MaxTotalSizeBytes = f.MaxDirSizeMB * 1024 * 1024
+	}
+	if f.MaxArchives > 0 {
+		fw.MaxArchivedFiles = f.MaxArchives
+	}
+	if f.MaxAgeDays > 0 {
+		fw.MaxTimeTimeToKeep = time.Duration(f.MaxAgeDays) * 24 * time.Hour
+	}
+	if f.Compression != "" {
+		fw.CompressionCodec = Codec(f.Compression)
+	}
+	if f.Async {
+		fw.Async = true
+	}
+}
+
+var (
+	// loadedFileConfig is the last configuration applied by LoadConfigFile.
+	// We use it to avoid rebuilding the sinks when only the level changes.
+	loadedFileConfig *FileConfig
+	loadedFileMu     sync.Mutex
+)
+
+// LoadConfigFile reads the configuration file and applies it to the package
+// logger.  The sinks are only rebuilt if something other than the level has
+// changed since the last time the file was loaded.
+func LoadConfigFile(path string) error {
+	fc, err := ReadConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	// check the level spec before we change anything
+	if fc.Level != "" {
+		_, _, err = parseLevelSpec(fc.Level)
+		if err != nil {
+			return err
+		}
+	}
+
+	loadedFileMu.Lock()
+	defer loadedFileMu.Unlock()
+
+	sinks := fc
+	sinks.Level = ""
+	if loadedFileConfig == nil || !reflect.DeepEqual(*loadedFileConfig, sinks) {
+		err = Configure(fc.Config())
+		if err != nil {
+			return err
+		}
+		loadedFileConfig = &sinks
+	}
+
+	if fc.Level != "" {
+		return SetLevelSpec(fc.Level)
+	}
+	return nil
+}
+
+// ConfigWatcher reloads a configuration file when it changes.
+type ConfigWatcher struct {
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// WatchConfigFile loads the configuration file and reloads it whenever it
+// changes, so operators can adjust logging without restarting the service.
+// Errors while reloading are logged and the previous configuration stays in
+// effect.  Close the watcher to stop watching.
+func WatchConfigFile(path string) (*ConfigWatcher, error) {
+	err := LoadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// we watch the directory since editors and config management tools
+	// usually replace the file rather than write to it
+	err = watcher.Add(filepath.Dir(path))
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	w := &ConfigWatcher{
+		watcher: watcher,
+		done:    make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.watch(filepath.Clean(path))
+	return w, nil
+}
+
+func (w *ConfigWatcher) watch(path string) {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(ev.Name) != path || ev.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			err := LoadConfigFile(path)
+			if err != nil {
+				lg.Errorw("error reloading logging config", "file", path, "err", err)
+				continue
+			}
+			lg.Infow("reloaded logging config", "file", path)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			lg.Errorw("error watching logging config", "file", path, "err", err)
+
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Close stops watching the configuration file.
+func (w *ConfigWatcher) Close() error {
+	close(w.done)
+	err := w.watcher.Close()
+	w.wg.Wait()
+	return err
+}
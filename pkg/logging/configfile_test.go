@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configfile-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer func() {
+		loadedFileConfig = nil
+		SetLevel(defaultLogLevel)
+		Configure(ConfigFromEnv())
+	}()
+
+	path := filepath.Join(dir, "logging.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{
+		"mode": "file",
+		"level": "debug",
+		"file": {"dir": "`+filepath.Join(dir, "log")+`", "name": "app.log", "maxSizeMB": 5}
+	}`), 0644))
+
+	assert.NoError(t, LoadConfigFile(path))
+	assert.Equal(t, zapcore.DebugLevel, GetLevel())
+
+	fileWriters := currentFileWriters()
+	assert.Len(t, fileWriters, 1)
+	assert.Equal(t, filepath.Join(dir, "log", "app.log"), fileWriters[0].logFileNameFullPath)
+	assert.Equal(t, int64(5*1024*1024), fileWriters[0].config.MaxLogFileSizeBytes)
+
+	// a level change doesn't rebuild the file writer
+	assert.NoError(t, os.WriteFile(path, []byte(`{
+		"mode": "file",
+		"level": "warn",
+		"file": {"dir": "`+filepath.Join(dir, "log")+`", "name": "app.log", "maxSizeMB": 5}
+	}`), 0644))
+	assert.NoError(t, LoadConfigFile(path))
+	assert.Equal(t, zapcore.WarnLevel, GetLevel())
+	assert.Equal(t, fileWriters, currentFileWriters())
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"level": "loud"}`), 0644))
+	assert.Error(t, LoadConfigFile(path))
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configfile-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer func() {
+		loadedFileConfig = nil
+		SetLevel(defaultLogLevel)
+		Configure(ConfigFromEnv())
+	}()
+
+	path := filepath.Join(dir, "logging.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("mode: console\nlevel: info\n"), 0644))
+
+	w, err := WatchConfigFile(path)
+	assert.NoError(t, err)
+	defer w.Close()
+
+	assert.NoError(t, os.WriteFile(path, []byte("mode: console\nlevel: error\n"), 0644))
+	assert.Eventually(t, func() bool { return GetLevel() == zapcore.ErrorLevel }, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ConsoleConfig configures the human readable console output.
+type ConsoleConfig struct {
+	// If Color is set levels are colored using ANSI escape codes.
+	Color bool
+	// If FullCaller is set the caller is logged with its full path rather
+	// than just the package directory and file name.
+	FullCaller bool
+	// FieldOrder lists fields that are logged first, in this order.  The
+	// other fields follow in the order they were given.
+	FieldOrder []string
+	// If SortFields is set the fields not listed in FieldOrder are sorted
+	// by key.
+	SortFields bool
+}
+
+// encoder returns the console encoder for the configuration with timestamps
+// formatted according to t.
+func (c ConsoleConfig) encoder(t TimeConfig) zapcore.Encoder {
+	ec := t.apply(zap.NewDevelopmentEncoderConfig())
+	if c.Color {
+		ec.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+	if c.FullCaller {
+		ec.EncodeCaller = zapcore.FullCallerEncoder
+	}
+	return zapcore.NewConsoleEncoder(ec)
+}
+
+// wrap wraps core so fields are ordered according to the configuration.
+func (c ConsoleConfig) wrap(core zapcore.Core) zapcore.Core {
+	if len(c.FieldOrder) == 0 && !c.SortFields {
+		return core
+	}
+
+	rank := make(map[string]int, len(c.FieldOrder))
+	for i, key := range c.FieldOrder {
+		if _, ok := rank[key]; !ok {
+			rank[key] = i
+		}
+	}
+	return &fieldOrderCore{Core: core, rank: rank, sortFields: c.SortFields}
+}
+
+// fieldOrderCore is a zapcore.Core which reorders fields before passing them
+// on.  Since encoders encode the fields given to With right away we hold on
+// to them and pass them on with the fields of each entry instead.
+type fieldOrderCore struct {
+	zapcore.Core
+	rank       map[string]int
+	sortFields bool
+	fields     []zapcore.Field
+}
+
+func (c *fieldOrderCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	return &fieldOrderCore{Core: c.Core, rank: c.rank, sortFields: c.sortFields, fields: all}
+}
+
+func (c *fieldOrderCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *fieldOrderCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+
+	sort.SliceStable(all, func(i, j int) bool {
+		ri, iRanked := c.rank[all[i].Key]
+		rj, jRanked := c.rank[all[j].Key]
+		switch {
+		case iRanked && jRanked:
+			return ri < rj
+		case iRanked != jRanked:
+			return iRanked
+		case c.sortFields:
+			return all[i].Key < all[j].Key
+		default:
+			return false
+		}
+	})
+	return c.Core.Write(ent, all)
+}
@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestConsoleConfig(t *testing.T) {
+	var buf bytes.Buffer
+	c := ConsoleConfig{
+		Color:      true,
+		FullCaller: true,
+		FieldOrder: []string{"requestID"},
+		SortFields: true,
+	}
+	l := zap.New(c.wrap(zapcore.NewCore(c.encoder(TimeConfig{}), zapcore.AddSync(&buf), zapcore.DebugLevel)), zap.AddCaller())
+
+	l.With(zap.String("b", "2"), zap.String("requestID", "42")).Info("hello", zap.String("a", "1"))
+
+	out := buf.String()
+	assert.Contains(t, out, "\x1b[34mINFO\x1b[0m")
+	assert.Contains(t, out, "/pkg/logging/console_test.go:")
+	assert.Contains(t, out, `{"requestID": "42", "a": "1", "b": "2"}`)
+
+	// no options, no wrapping
+	core := zapcore.NewNopCore()
+	assert.Equal(t, core, ConsoleConfig{}.wrap(core))
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"os"
+	"strings"
+)
+
+var (
+	// containerMarkerFiles are created by container runtimes in the root of
+	// the container.
+	containerMarkerFiles = []string{"/.dockerenv", "/run/.containerenv"}
+
+	// containerCgroupFile lists the control groups of the process.  With
+	// cgroup v1 they name the container runtime.
+	containerCgroupFile = "/proc/self/cgroup"
+
+	containerCgroupMarkers = []string{"docker", "kubepods", "containerd", "libpod", "lxc"}
+)
+
+// inContainer reports whether the process runs in a container.
+func inContainer() bool {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return true
+	}
+	for _, fn := range containerMarkerFiles {
+		if _, err := os.Stat(fn); err == nil {
+			return true
+		}
+	}
+
+	cgroups, err := os.ReadFile(containerCgroupFile)
+	if err != nil {
+		return false
+	}
+	for _, marker := range containerCgroupMarkers {
+		if strings.Contains(string(cgroups), marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// modeFromEnv returns the logger mode given by LoggerSpecEnvVar.  If it is
+// not set the mode is "container" when running in a container, so
+// deployments log JSON to stderr without having to be told to.
+func modeFromEnv() string {
+	mode := getenv(LoggerSpecEnvVar)
+	if mode == "" && inContainer() {
+		return "container"
+	}
+	return mode
+}
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModeFromEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer func(files []string, cgroup string) {
+		containerMarkerFiles, containerCgroupFile = files, cgroup
+	}(containerMarkerFiles, containerCgroupFile)
+
+	containerMarkerFiles = []string{filepath.Join(dir, ".dockerenv")}
+	containerCgroupFile = filepath.Join(dir, "cgroup")
+	assert.NoError(t, ioutil.WriteFile(containerCgroupFile, []byte("0::/\n"), 0644))
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv(LoggerSpecEnvVar, "")
+	assert.Equal(t, "", modeFromEnv())
+
+	assert.NoError(t, ioutil.WriteFile(containerCgroupFile, []byte("12:pids:/kubepods/burstable/pod1234\n"), 0644))
+	assert.Equal(t, "container", modeFromEnv())
+
+	// an explicit mode wins
+	t.Setenv(LoggerSpecEnvVar, "console")
+	assert.Equal(t, "console", modeFromEnv())
+
+	t.Setenv(LoggerSpecEnvVar, "")
+	assert.NoError(t, ioutil.WriteFile(containerCgroupFile, []byte("0::/\n"), 0644))
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	assert.Equal(t, "container", modeFromEnv())
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// contextKey is the type of the key we use to store loggers in contexts.
+type contextKey struct{}
+
+const (
+	traceIDKey = "trace_id"
+	spanIDKey  = "span_id"
+)
+
+// NewContext returns a copy of ctx carrying a logger with the given fields
+// added to the logger already in ctx, if any.  Use this to attach request
+// scoped fields such as request ids to every log line of a request.
+func NewContext(ctx context.Context, fields ...zap.Field) context.Context {
+	return context.WithValue(ctx, contextKey{}, storedLogger(ctx).With(fields...))
+}
+
+// FromContext returns the logger stored in ctx by NewContext.  If there is no
+// logger in ctx the package logger is returned.  If ctx carries an
+// OpenTelemetry span the trace_id and span_id fields are added so logs can be
+// correlated with traces.
+func FromContext(ctx context.Context) *zap.Logger {
+	l := storedLogger(ctx)
+	if ctx == nil {
+		return l
+	}
+
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return l
+	}
+	return l.With(
+		zap.String(traceIDKey, sc.TraceID().String()),
+		zap.String(spanIDKey, sc.SpanID().String()),
+	)
+}
+
+// storedLogger returns the logger stored in ctx or the package logger.  Unlike
+// FromContext it doesn't add trace fields, since the span may change while the
+// stored logger stays the same.
+func storedLogger(ctx context.Context) *zap.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+			return l
+		}
+	}
+	return Get()
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestContext(t *testing.T) {
+	assert.Equal(t, Get(), FromContext(context.Background()))
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	old := rootCore.swap(core)
+	defer rootCore.swap(old)
+
+	ctx := NewContext(context.Background(), zap.String("requestID", "42"))
+	ctx = NewContext(ctx, zap.String("userID", "bob"))
+	FromContext(ctx).Info("handled")
+
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, map[string]interface{}{"requestID": "42", "userID": "bob"}, logs.All()[0].ContextMap())
+}
+
+func TestContextTrace(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	old := rootCore.swap(core)
+	defer rootCore.swap(old)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	ctx = NewContext(ctx, zap.String("requestID", "42"))
+	FromContext(ctx).Info("traced")
+
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, map[string]interface{}{
+		"requestID": "42",
+		"trace_id":  "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":   "00f067aa0ba902b7",
+	}, logs.All()[0].ContextMap())
+}
@@ -0,0 +1,172 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DedupConfig configures suppression of consecutive duplicate entries.
+type DedupConfig struct {
+	// Window is the maximum time between two identical entries for the
+	// second one to be counted as a repeat.  Deduplication is disabled if
+	// Window is 0.
+	Window time.Duration
+	// MaxHold is the maximum time repeats are held back before a "last
+	// message repeated N times" entry is logged.  Defaults to 30 seconds.
+	MaxHold time.Duration
+}
+
+const defaultDedupMaxHold = 30 * time.Second
+
+// dedupState is the state shared between a dedup core and the cores derived
+// from it.  It holds the last entry written.
+type dedupState struct {
+	config DedupConfig
+	mu     sync.Mutex
+
+	// last is the last entry written and the core it was written by
+	last       *dedupCore
+	lastEnt    zapcore.Entry
+	lastFields []zapcore.Field
+
+	repeats     int
+	firstRepeat time.Time
+	timer       *time.Timer
+}
+
+// dedupCore is a zapcore.Core which collapses consecutive identical entries.
+// The first entry is written right away, repeats are counted and reported in
+// a single "last message repeated N times" entry once a different entry is
+// logged, the window passes or MaxHold is reached.
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+}
+
+// NewDedupCore wraps core so consecutive identical entries are collapsed
+// into a single entry annotated with a repeat count.
+func NewDedupCore(core zapcore.Core, c DedupConfig) zapcore.Core {
+	if c.Window <= 0 {
+		return core
+	}
+	if c.MaxHold <= 0 {
+		c.MaxHold = defaultDedupMaxHold
+	}
+	return &dedupCore{
+		Core:  core,
+		state: &dedupState{config: c},
+	}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{
+		Core:  c.Core.With(fields),
+		state: c.state,
+	}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	s := c.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isRepeat(c, ent, fields) {
+		if s.repeats == 0 {
+			s.firstRepeat = ent.Time
+			s.timer = time.AfterFunc(s.config.MaxHold, s.flushHeld)
+		}
+		s.repeats++
+		s.lastEnt.Time = ent.Time
+
+		if ent.Time.Sub(s.firstRepeat) < s.config.MaxHold {
+			return nil
+		}
+		return s.flush()
+	}
+
+	err := s.flush()
+	s.last = c
+	s.lastEnt = ent
+	s.lastFields = fields
+
+	writeErr := c.Core.Write(ent, fields)
+	if writeErr != nil {
+		return writeErr
+	}
+	return err
+}
+
+func (c *dedupCore) Sync() error {
+	c.state.mu.Lock()
+	err := c.state.flush()
+	c.state.mu.Unlock()
+
+	syncErr := c.Core.Sync()
+	if err != nil {
+		return err
+	}
+	return syncErr
+}
+
+// isRepeat reports whether the entry is identical to the last entry and
+// within the window.  This assumes that s.mu is locked.
+func (s *dedupState) isRepeat(c *dedupCore, ent zapcore.Entry, fields []zapcore.Field) bool {
+	if s.last != c ||
+		s.lastEnt.Level != ent.Level ||
+		s.lastEnt.Message != ent.Message ||
+		s.lastEnt.LoggerName != ent.LoggerName ||
+		ent.Time.Sub(s.lastEnt.Time) > s.config.Window ||
+		len(s.lastFields) != len(fields) {
+		return false
+	}
+	for i := range fields {
+		if !fields[i].Equals(s.lastFields[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// flush writes the "last message repeated" entry if there are held repeats.
+// This assumes that s.mu is locked.
+func (s *dedupState) flush() error {
+	if s.repeats == 0 {
+		return nil
+	}
+
+	repeats := s.repeats
+	s.repeats = 0
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+
+	summary := s.lastEnt
+	summary.Message = fmt.Sprintf("last message repeated %d times", repeats)
+	return s.last.Core.Write(summary, []zapcore.Field{
+		zap.String("repeatedMessage", s.lastEnt.Message),
+		zap.Int("repeated", repeats),
+	})
+}
+
+// flushHeld is called by the timer when repeats have been held for MaxHold.
+func (s *dedupState) flushHeld() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.flush()
+	if err != nil {
+		fmt.Printf("error writing repeated message summary: %v\n", err)
+	}
+}
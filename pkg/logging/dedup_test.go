@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDedupCore(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	assert.Equal(t, core, NewDedupCore(core, DedupConfig{}))
+
+	l := zap.New(NewDedupCore(core, DedupConfig{Window: time.Minute, MaxHold: time.Hour}))
+
+	for i := 0; i < 4; i++ {
+		l.Warn("retrying", zap.Int("attempt", 1))
+	}
+	l.Warn("retrying", zap.Int("attempt", 2))
+	l.Info("done")
+	l.Info("done")
+	assert.NoError(t, l.Sync())
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 5)
+	assert.Equal(t, "retrying", entries[0].Message)
+	assert.Equal(t, "last message repeated 3 times", entries[1].Message)
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	assert.Equal(t, int64(3), entries[1].ContextMap()["repeated"])
+	assert.Equal(t, int64(2), entries[2].ContextMap()["attempt"])
+	assert.Equal(t, "done", entries[3].Message)
+	assert.Equal(t, "last message repeated 1 times", entries[4].Message)
+}
+
+func TestDedupCoreMaxHold(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := zap.New(NewDedupCore(core, DedupConfig{Window: time.Minute, MaxHold: 50 * time.Millisecond}))
+
+	l.Info("tick")
+	l.Info("tick")
+
+	assert.Eventually(t, func() bool { return logs.Len() == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "last message repeated 1 times", logs.All()[1].Message)
+}
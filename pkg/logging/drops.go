@@ -0,0 +1,134 @@
+package logging
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// DropCounts reports how many log entries have been dropped because the
+// FileWriter could not keep up.
+type DropCounts struct {
+	// Total is the total number of dropped entries.
+	Total uint64
+	// ByLevel is the number of dropped entries per level.  Entries written
+	// through FileWriter.Write directly have no level and are only included
+	// in Total.
+	ByLevel map[zapcore.Level]uint64
+}
+
+// dropCounter counts dropped entries per level.
+type dropCounter struct {
+	total   atomic.Uint64
+	byLevel [zapcore.FatalLevel - zapcore.DebugLevel + 1]atomic.Uint64
+}
+
+func (d *dropCounter) add(level *zapcore.Level) {
+	d.total.Add(1)
+	if level == nil {
+		return
+	}
+
+	l := *level
+	if l < zapcore.DebugLevel {
+		l = zapcore.DebugLevel
+	}
+	if l > zapcore.FatalLevel {
+		l = zapcore.FatalLevel
+	}
+	d.byLevel[l-zapcore.DebugLevel].Add(1)
+}
+
+func (d *dropCounter) counts() DropCounts {
+	counts := DropCounts{
+		Total:   d.total.Load(),
+		ByLevel: map[zapcore.Level]uint64{},
+	}
+	for i := range d.byLevel {
+		if n := d.byLevel[i].Load(); n > 0 {
+			counts.ByLevel[zapcore.DebugLevel+zapcore.Level(i)] = n
+		}
+	}
+	return counts
+}
+
+// Dropped returns the number of entries dropped in DropOnOverload mode.
+func (w *FileWriter) Dropped() DropCounts {
+	return w.drops.counts()
+}
+
+// Dropped returns the number of entries the package logger has dropped
+// because its file writer could not keep up.
+func Dropped() DropCounts {
+	counts := DropCounts{ByLevel: map[zapcore.Level]uint64{}}
+	for _, fw := range currentFileWriters() {
+		c := fw.Dropped()
+		counts.Total += c.Total
+		for level, n := range c.ByLevel {
+			counts.ByLevel[level] += n
+		}
+	}
+	return counts
+}
+
+// fileWriterCore is a zapcore.Core writing to a FileWriter.  Unlike the
+// standard zapcore ioCore it passes the level of each entry on to the
+// FileWriter so dropped entries can be counted by level.
+type fileWriterCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	fw  *FileWriter
+}
+
+func newFileWriterCore(enc zapcore.Encoder, fw *FileWriter, enab zapcore.LevelEnabler) zapcore.Core {
+	return &fileWriterCore{
+		LevelEnabler: enab,
+		enc:          enc,
+		fw:           fw,
+	}
+}
+
+func (c *fileWriterCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &fileWriterCore{
+		LevelEnabler: c.LevelEnabler,
+		enc:          c.enc.Clone(),
+		fw:           c.fw,
+	}
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *fileWriterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *fileWriterCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	level := ent.Level
+	_, err = c.fw.writeEntry(buf.Bytes(), &level)
+	buf.Free()
+	if err != nil {
+		return err
+	}
+
+	// like the ioCore we sync on levels that will make the process exit,
+	// and errors are never left in the buffer
+	if ent.Level > zapcore.ErrorLevel {
+		c.Sync()
+	} else if ent.Level == zapcore.ErrorLevel && c.fw.config.BufferSizeBytes > 0 {
+		c.fw.Flush()
+	}
+	return nil
+}
+
+func (c *fileWriterCore) Sync() error {
+	return c.fw.Sync()
+}
@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// ecsVersion is the version of the Elastic Common Schema we produce.
+const ecsVersion = "8.11.0"
+
+// ECS field names.  See https://www.elastic.co/guide/en/ecs/current/index.html
+const (
+	ecsTimestampKey    = "@timestamp"
+	ecsLevelKey        = "log.level"
+	ecsMessageKey      = "message"
+	ecsLoggerKey       = "log.logger"
+	ecsStackTraceKey   = "error.stack_trace"
+	ecsErrorMessageKey = "error.message"
+	ecsErrorTypeKey    = "error.type"
+	ecsFileNameKey     = "log.origin.file.name"
+	ecsFileLineKey     = "log.origin.file.line"
+	ecsFunctionKey     = "log.origin.function"
+	ecsVersionKey      = "ecs.version"
+)
+
+// ecsEncoder is a JSON encoder which renames the standard fields to their
+// Elastic Common Schema names so the output can be ingested by Elastic
+// without ingest pipelines.
+type ecsEncoder struct {
+	zapcore.Encoder
+}
+
+// NewECSEncoder creates an encoder producing ECS formatted JSON.
+func NewECSEncoder() zapcore.Encoder {
+	return newECSEncoder(ecsEncoderConfig())
+}
+
+func newECSEncoder(ec zapcore.EncoderConfig) zapcore.Encoder {
+	enc := zapcore.NewJSONEncoder(ec)
+	enc.AddString(ecsVersionKey, ecsVersion)
+	return ecsEncoder{enc}
+}
+
+func ecsEncoderConfig() zapcore.EncoderConfig {
+	ec := zap.NewProductionEncoderConfig()
+	ec.TimeKey = ecsTimestampKey
+	ec.LevelKey = ecsLevelKey
+	ec.MessageKey = ecsMessageKey
+	ec.NameKey = ecsLoggerKey
+	ec.StacktraceKey = ecsStackTraceKey
+	ec.CallerKey = ""
+	ec.EncodeTime = ecsTimeEncoder
+	return ec
+}
+
+func ecsTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(t.UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+}
+
+func (e ecsEncoder) Clone() zapcore.Encoder {
+	return ecsEncoder{e.Encoder.Clone()}
+}
+
+// AddString renames error fields added through With().
+func (e ecsEncoder) AddString(key, value string) {
+	switch key {
+	case "error":
+		key = ecsErrorMessageKey
+	case "errorVerbose":
+		key = ecsStackTraceKey
+	}
+	e.Encoder.AddString(key, value)
+}
+
+func (e ecsEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	ecsFields := make([]zapcore.Field, 0, len(fields)+3)
+	for _, f := range fields {
+		if f.Type == zapcore.ErrorType && f.Key == "error" {
+			if err, _ := f.Interface.(error); err != nil {
+				// like zap's JSON encoder we write "<nil>" for typed nils
+				msg, ok := safeString(err)
+				if !ok {
+					msg = "<nil>"
+				}
+				ecsFields = append(ecsFields,
+					zap.String(ecsErrorMessageKey, msg),
+					zap.String(ecsErrorTypeKey, fmt.Sprintf("%T", err)))
+			}
+			continue
+		}
+		ecsFields = append(ecsFields, f)
+	}
+
+	if ent.Caller.Defined {
+		ecsFields = append(ecsFields,
+			zap.String(ecsFileNameKey, ent.Caller.File),
+			zap.Int(ecsFileLineKey, ent.Caller.Line))
+		if ent.Caller.Function != "" {
+			ecsFields = append(ecsFields, zap.String(ecsFunctionKey, ent.Caller.Function))
+		}
+	}
+
+	return e.Encoder.EncodeEntry(ent, ecsFields)
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestECSEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	l := zap.New(zapcore.NewCore(NewECSEncoder(), zapcore.AddSync(&buf), zapcore.InfoLevel), zap.AddCaller())
+
+	l.Named("api").Error("request failed", zap.Error(errors.New("boom")), zap.Int("status", 500))
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+
+	assert.Contains(t, entry, ecsTimestampKey)
+	assert.Equal(t, "error", entry[ecsLevelKey])
+	assert.Equal(t, "request failed", entry[ecsMessageKey])
+	assert.Equal(t, "api", entry[ecsLoggerKey])
+	assert.Equal(t, ecsVersion, entry[ecsVersionKey])
+	assert.Equal(t, "boom", entry[ecsErrorMessageKey])
+	assert.Equal(t, "*errors.errorString", entry[ecsErrorTypeKey])
+	assert.Contains(t, entry[ecsFileNameKey], "ecs_test.go")
+	assert.NotZero(t, entry[ecsFileLineKey])
+	assert.Equal(t, float64(500), entry["status"])
+	assert.NotContains(t, entry, "error")
+	assert.NotContains(t, entry, "caller")
+
+	// errors added through With are renamed too
+	buf.Reset()
+	l.With(zap.Error(errors.New("context"))).Warn("warning")
+	entry = map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "context", entry[ecsErrorMessageKey])
+
+	// typed nil errors don't panic
+	buf.Reset()
+	assert.NotPanics(t, func() {
+		l.Error("nil error", zap.Error((*maskStringer)(nil)))
+	})
+	entry = map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "<nil>", entry[ecsErrorMessageKey])
+	assert.Equal(t, "*logging.maskStringer", entry[ecsErrorTypeKey])
+}
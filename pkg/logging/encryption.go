@@ -0,0 +1,253 @@
+package logging
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"filippo.io/age"
+)
+
+// EncryptionConfig configures encryption of archives.  Archives are
+// encrypted after they have been compressed, so it only applies when
+// Compress is set.  If both AgeRecipients and AESKey are set AgeRecipients
+// is used.
+type EncryptionConfig struct {
+	// AgeRecipients are age X25519 recipients ("age1...").  Archives can be
+	// decrypted with the age command line tool and the matching identity.
+	AgeRecipients []string
+	// AESKey is a 16, 24 or 32 byte key used to encrypt archives with
+	// AES-GCM.  Use NewAESGCMReader to decrypt them.
+	AESKey []byte
+}
+
+const (
+	ageExtension    = "age"
+	aesGCMExtension = "enc"
+
+	// aesGCMChunkSize is the size of the plaintext chunks sealed separately
+	// so archives can be encrypted and decrypted as streams.
+	aesGCMChunkSize = 64 * 1024
+	aesGCMMagic     = "LOGAESGCM1"
+	// aesGCMPrefixSize is the size of the random nonce prefix.  The rest of
+	// the nonce is a chunk counter and a flag marking the last chunk, which
+	// makes reordered and truncated archives fail to decrypt.
+	aesGCMPrefixSize = 7
+)
+
+// ErrArchiveTruncated is returned when decrypting an archive that has been
+// truncated.
+var ErrArchiveTruncated = errors.New("encrypted archive is truncated")
+
+// enabled returns true if archives should be encrypted.
+func (c EncryptionConfig) enabled() bool {
+	return len(c.AgeRecipients) > 0 || len(c.AESKey) > 0
+}
+
+// extension returns the file extension of encrypted archives.
+func (c EncryptionConfig) extension() string {
+	if len(c.AgeRecipients) > 0 {
+		return ageExtension
+	}
+	return aesGCMExtension
+}
+
+// newWriter returns a writer encrypting to w.  The writer must be closed to
+// write the last chunk.
+func (c EncryptionConfig) newWriter(w io.Writer) (io.WriteCloser, error) {
+	if len(c.AgeRecipients) > 0 {
+		recipients := make([]age.Recipient, 0, len(c.AgeRecipients))
+		for _, r := range c.AgeRecipients {
+			recipient, err := age.ParseX25519Recipient(r)
+			if err != nil {
+				return nil, err
+			}
+			recipients = append(recipients, recipient)
+		}
+		return age.Encrypt(w, recipients...)
+	}
+	return newAESGCMWriter(w, c.AESKey)
+}
+
+// aesGCMWriter encrypts a stream with AES-GCM in chunks of aesGCMChunkSize.
+type aesGCMWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	nonce   []byte
+	counter uint32
+	buf     []byte
+}
+
+func newAESGCMWriter(w io.Writer, key []byte) (*aesGCMWriter, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce[:aesGCMPrefixSize])
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.WriteString(w, aesGCMMagic)
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.Write(nonce[:aesGCMPrefixSize])
+	if err != nil {
+		return nil, err
+	}
+
+	return &aesGCMWriter{
+		w:     w,
+		aead:  aead,
+		nonce: nonce,
+		buf:   make([]byte, 0, aesGCMChunkSize),
+	}, nil
+}
+
+func (e *aesGCMWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		// hold on to a full chunk until we know whether it is the last one
+		if len(e.buf) == aesGCMChunkSize {
+			err := e.seal(false)
+			if err != nil {
+				return n, err
+			}
+		}
+
+		c := copy(e.buf[len(e.buf):aesGCMChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// Close seals the last chunk.  It does not close the underlying writer.
+func (e *aesGCMWriter) Close() error {
+	return e.seal(true)
+}
+
+func (e *aesGCMWriter) seal(last bool) error {
+	setAESGCMNonce(e.nonce, e.counter, last)
+	_, err := e.w.Write(e.aead.Seal(nil, e.nonce, e.buf, nil))
+	if err != nil {
+		return err
+	}
+	e.counter++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+// aesGCMReader decrypts streams written by aesGCMWriter.
+type aesGCMReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	nonce   []byte
+	counter uint32
+	chunk   []byte
+	plain   []byte
+	done    bool
+}
+
+// NewAESGCMReader returns a reader decrypting an archive encrypted with
+// EncryptionConfig.AESKey.
+func NewAESGCMReader(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, len(aesGCMMagic)+aesGCMPrefixSize)
+	_, err = io.ReadFull(r, header)
+	if err != nil {
+		return nil, err
+	}
+	if string(header[:len(aesGCMMagic)]) != aesGCMMagic {
+		return nil, errors.New("not an AES-GCM encrypted archive")
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, header[len(aesGCMMagic):])
+
+	return &aesGCMReader{
+		r:     bufio.NewReader(r),
+		aead:  aead,
+		nonce: nonce,
+		chunk: make([]byte, aesGCMChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (d *aesGCMReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		err := d.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// open reads and decrypts the next chunk.
+func (d *aesGCMReader) open() error {
+	n, err := io.ReadFull(d.r, d.chunk)
+	if err == io.EOF {
+		return ErrArchiveTruncated
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+
+	// a short chunk is the last one, a full chunk is the last one if
+	// nothing follows it
+	last := n < len(d.chunk)
+	if !last {
+		_, err := d.r.Peek(1)
+		last = err == io.EOF
+	}
+
+	setAESGCMNonce(d.nonce, d.counter, last)
+	plain, err := d.aead.Open(d.chunk[:0], d.nonce, d.chunk[:n], nil)
+	if err != nil {
+		if !last {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrArchiveTruncated, err)
+	}
+
+	d.counter++
+	d.plain = plain
+	d.done = last
+	return nil
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// setAESGCMNonce sets the counter and last chunk flag of nonce.
+func setAESGCMNonce(nonce []byte, counter uint32, last bool) {
+	binary.BigEndian.PutUint32(nonce[aesGCMPrefixSize:], counter)
+	nonce[len(nonce)-1] = 0
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAESGCM(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+
+	for _, size := range []int{0, 100, aesGCMChunkSize, 2*aesGCMChunkSize + 5} {
+		plain := []byte(randomString(size))
+
+		var buf bytes.Buffer
+		enc, err := newAESGCMWriter(&buf, key)
+		assert.NoError(t, err)
+		_, err = enc.Write(plain)
+		assert.NoError(t, err)
+		assert.NoError(t, enc.Close())
+		encrypted := buf.Bytes()
+
+		dec, err := NewAESGCMReader(bytes.NewReader(encrypted), key)
+		assert.NoError(t, err)
+		data, err := io.ReadAll(dec)
+		assert.NoError(t, err)
+		assert.Equal(t, plain, append([]byte{}, data...), "size %d", size)
+
+		// wrong key
+		dec, err = NewAESGCMReader(bytes.NewReader(encrypted), bytes.Repeat([]byte{2}, 32))
+		assert.NoError(t, err)
+		_, err = io.ReadAll(dec)
+		assert.Error(t, err)
+
+		// truncated at a chunk boundary
+		if size > aesGCMChunkSize {
+			truncated := encrypted[:len(aesGCMMagic)+aesGCMPrefixSize+aesGCMChunkSize+16]
+			dec, err = NewAESGCMReader(bytes.NewReader(truncated), key)
+			assert.NoError(t, err)
+			_, err = io.ReadAll(dec)
+			assert.ErrorIs(t, err, ErrArchiveTruncated)
+		}
+	}
+}
+
+func TestFileWriterEncryption(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	identity, err := age.GenerateX25519Identity()
+	assert.NoError(t, err)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		Compress:            true,
+		MaxLogFileSizeBytes: 100,
+		Encryption: EncryptionConfig{
+			AgeRecipients: []string{identity.Recipient().String()},
+		},
+	}, withMinSize(1))
+	assert.NoError(t, err)
+
+	line := randomString(150)
+	_, err = fw.Write([]byte(line))
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "logfile-*.log.gz.age"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	f, err := os.Open(files[0])
+	assert.NoError(t, err)
+	defer f.Close()
+
+	dec, err := age.Decrypt(f, identity)
+	assert.NoError(t, err)
+	zr, err := gzip.NewReader(dec)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Equal(t, line, string(data))
+}
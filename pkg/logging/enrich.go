@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewEnrichCore returns a core which adds the host name, the pid, the Go
+// version and the version of the binary to every entry, so entries from
+// several hosts can be told apart once they are aggregated.
+func NewEnrichCore(core zapcore.Core) zapcore.Core {
+	return core.With(enrichFields())
+}
+
+// enrichFields returns the fields describing the process.
+func enrichFields() []zap.Field {
+	host, _ := os.Hostname()
+	fields := []zap.Field{
+		zap.String("host", host),
+		zap.Int("pid", os.Getpid()),
+		zap.String("go_version", runtime.Version()),
+		zap.String("version", buildVersion()),
+	}
+	if revision := buildSetting("vcs.revision"); revision != "" {
+		fields = append(fields, zap.String("revision", revision))
+	}
+	return fields
+}
+
+// buildVersion returns the version of the main module of the binary.
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	return info.Main.Version
+}
+
+// buildSetting returns a setting recorded in the build info, eg. the VCS
+// revision, or an empty string.
+func buildSetting(key string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, s := range info.Settings {
+		if s.Key == key {
+			return s.Value
+		}
+	}
+	return ""
+}
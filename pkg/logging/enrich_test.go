@@ -0,0 +1,27 @@
+package logging
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestEnrichCore(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	zap.New(NewEnrichCore(core)).Info("enriched")
+
+	entries := logs.All()
+	assert.Len(t, entries, 1)
+
+	host, _ := os.Hostname()
+	fields := entries[0].ContextMap()
+	assert.Equal(t, host, fields["host"])
+	assert.Equal(t, int64(os.Getpid()), fields["pid"])
+	assert.Equal(t, runtime.Version(), fields["go_version"])
+	assert.Contains(t, fields, "version")
+}
@@ -0,0 +1,324 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ebobo/logging_lab5e_go/internal/logfile"
+	"go.uber.org/zap/zapcore"
+)
+
+// Entry is an entry read back from a log file.  The comments give the keys
+// of the json and logfmt formats.
+type Entry struct {
+	Time  time.Time     // ts
+	Level zapcore.Level // level
+	// Logger, Caller, Message, Stacktrace and Fields are set for entries
+	// in the json and logfmt formats, and Message and Fields for the other
+	// structured formats.
+	Logger     string // logger
+	Caller     string // caller
+	Message    string // msg
+	Stacktrace string // stacktrace
+	// Fields holds the other fields of the entry.  JSON numbers are
+	// float64 and logfmt values strings.
+	Fields map[string]interface{}
+	// Line is the entry as written, including continuation lines such as
+	// the stack traces of the console format.
+	Line string
+}
+
+// ParseLine parses a line written in the json format, the default format of
+// the log files.
+func ParseLine(line []byte) (Entry, error) {
+	var fields map[string]interface{}
+	err := json.Unmarshal(line, &fields)
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid log line: %w", err)
+	}
+
+	ts, ok := logfile.ParseTimestamp(fields["ts"])
+	if !ok {
+		return Entry{}, fmt.Errorf("invalid log line: no timestamp")
+	}
+	e := Entry{Time: ts, Line: strings.TrimRight(string(line), "\r\n")}
+
+	if level, ok := fields["level"].(string); ok {
+		err := e.Level.UnmarshalText([]byte(level))
+		if err != nil {
+			return Entry{}, fmt.Errorf("invalid log line: %w", err)
+		}
+	}
+	e.setFields(fields)
+	return e, nil
+}
+
+// errStopReading stops reading entries once they are past until.
+var errStopReading = errors.New("stop reading")
+
+// ReadEntries calls fn with the entries logged between since and until, in
+// the order they were written, reading the archives that cover the period
+// and then the log file.  A zero since or until leaves that end open.  It
+// stops at the first error returned by fn.  Archives that can't be read, eg.
+// because they are encrypted, are skipped and reported in the error
+// returned.
+func (w *FileWriter) ReadEntries(since, until time.Time, fn func(Entry) error) error {
+	// make sure what has been logged so far is in the log file
+	w.Sync()
+
+	archives, err := w.ListArchives()
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, archive := range archives {
+		if archive.Covers(since, until) {
+			paths = append(paths, archive.Path)
+		}
+	}
+	paths = append(paths, w.logFileNameFullPath)
+
+	var errs []error
+	for _, path := range paths {
+		err := readEntries(path, since, until, fn)
+		if err == errStopReading {
+			break
+		}
+		if err != nil {
+			var readErr *readError
+			if !errors.As(err, &readErr) {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReadEntries reads the entries of the log file of the package logger, see
+// FileWriter.ReadEntries.  If there are several log files, eg. an error
+// file, the first one is read.
+func ReadEntries(since, until time.Time, fn func(Entry) error) error {
+	fileWriters := currentFileWriters()
+	if len(fileWriters) == 0 {
+		return errors.New("not logging to file")
+	}
+	return fileWriters[0].ReadEntries(since, until, fn)
+}
+
+// readError is an error reading a log file or an archive, as opposed to an
+// error returned by the callback.
+type readError struct {
+	path string
+	err  error
+}
+
+func (e *readError) Error() string {
+	return fmt.Sprintf("error reading %s: %v", e.path, e.err)
+}
+
+func (e *readError) Unwrap() error {
+	return e.err
+}
+
+// readEntries calls fn with the entries of the file at path logged between
+// since and until.  It returns errStopReading once it finds an entry logged
+// after until.
+func readEntries(path string, since, until time.Time, fn func(Entry) error) error {
+	r, err := openEntries(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !since.IsZero() && e.Time.Before(since) {
+			continue
+		}
+		if !until.IsZero() && e.Time.After(until) {
+			return errStopReading
+		}
+		err = fn(e)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// entryReader reads the entries of a log file or an archive one at a time.
+// Lines without a timestamp, eg. stack traces in the console format, are
+// added to the entry before them.
+type entryReader struct {
+	path    string
+	f       io.ReadCloser
+	r       *bufio.Reader
+	pending Entry
+	started bool
+	eof     bool
+
+	// If keepLeading is set the lines before the first entry, eg. a file
+	// header, are returned as an entry without a time rather than
+	// skipped.
+	keepLeading bool
+	leading     []string
+}
+
+// openEntries opens a log file or an archive for reading its entries.
+func openEntries(path string) (*entryReader, error) {
+	f, err := OpenArchive(path)
+	if err != nil {
+		return nil, &readError{path: path, err: err}
+	}
+	return &entryReader{path: path, f: f, r: bufio.NewReader(f)}, nil
+}
+
+// Next returns the next entry, or io.EOF at the end of the file.
+func (r *entryReader) Next() (Entry, error) {
+	for !r.eof {
+		line, err := r.r.ReadString('\n')
+		if err == io.EOF {
+			r.eof = true
+		} else if err != nil {
+			return Entry{}, &readError{path: r.path, err: err}
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			continue
+		}
+		ts, ok := logfile.Time(line)
+		if !ok {
+			if r.started {
+				r.pending.Line += "\n" + line
+			} else if r.keepLeading {
+				r.leading = append(r.leading, line)
+			}
+			continue
+		}
+
+		e, started := r.pending, r.started
+		r.pending = parseEntry(line, ts)
+		r.started = true
+		if started {
+			return e, nil
+		}
+		if leading := r.takeLeading(); leading != nil {
+			return *leading, nil
+		}
+	}
+
+	if leading := r.takeLeading(); leading != nil {
+		return *leading, nil
+	}
+	if !r.started {
+		return Entry{}, io.EOF
+	}
+	r.started = false
+	return r.pending, nil
+}
+
+// takeLeading returns the lines before the first entry as an entry, or nil
+// if there are none.
+func (r *entryReader) takeLeading() *Entry {
+	if len(r.leading) == 0 {
+		return nil
+	}
+	e := &Entry{Line: strings.Join(r.leading, "\n")}
+	r.leading = nil
+	return e
+}
+
+func (r *entryReader) Close() error {
+	return r.f.Close()
+}
+
+// parseEntry parses a line logged at ts in any of the formats of the log
+// files.
+func parseEntry(line string, ts time.Time) Entry {
+	e, err := ParseLine([]byte(line))
+	if err == nil {
+		return e
+	}
+
+	e = Entry{Time: ts, Line: line}
+	if level, ok := logfile.Level(line); ok {
+		e.Level.UnmarshalText([]byte(level))
+	}
+	fields := logfile.Fields(line)
+	if fields != nil {
+		e.setFields(fields)
+	} else if fields := consoleFields(line); fields != nil {
+		e.Fields = fields
+	}
+	return e
+}
+
+// consoleFields returns the fields of a line in the console format, which
+// are written as a JSON object in the last column.
+func consoleFields(line string) map[string]interface{} {
+	i := strings.LastIndex(line, "\t{")
+	if i < 0 {
+		return nil
+	}
+	var fields map[string]interface{}
+	if json.Unmarshal([]byte(line[i+1:]), &fields) != nil {
+		return nil
+	}
+	return fields
+}
+
+// entryKeys are the keys of the fields with their own Entry field, the
+// keys of the json format first.  The message keys are handled by
+// setFields.
+var entryKeys = map[string]bool{
+	"ts": true, "level": true, "logger": true, "caller": true, "stacktrace": true,
+	"@timestamp": true, "log.level": true, "time": true, "severity": true,
+}
+
+// messageKeys are the keys of the message in order of precedence: "msg" of
+// the json and logfmt formats, then "message" of the ecs and gcp formats.
+var messageKeys = []string{"msg", "message"}
+
+// setFields sets the message, logger, caller and stack trace of e from
+// fields and the other fields.  If a line has more than one message key the
+// first of messageKeys is the message and the others are fields.
+func (e *Entry) setFields(fields map[string]interface{}) {
+	for key, dst := range map[string]*string{
+		"logger":     &e.Logger,
+		"caller":     &e.Caller,
+		"stacktrace": &e.Stacktrace,
+	} {
+		if v, ok := fields[key].(string); ok {
+			*dst = v
+		}
+	}
+
+	messageKey := ""
+	for _, key := range messageKeys {
+		if v, ok := fields[key].(string); ok {
+			e.Message, messageKey = v, key
+			break
+		}
+	}
+
+	e.Fields = make(map[string]interface{}, len(fields))
+	for key, v := range fields {
+		if !entryKeys[key] && key != messageKey {
+			e.Fields[key] = v
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestReadEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the archives are named after the time they were rotated
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	clock := NewFakeClock(start)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		Compress:            true,
+		MaxLogFileSizeBytes: 100,
+		Clock:               clock,
+	}, withMinSize(1))
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	for i := 0; i < 10; i++ {
+		clock.Advance(time.Minute)
+		_, err := fmt.Fprintf(fw, `{"level":"info","ts":%d,"msg":"entry %d","n":%d}`+"\n", start.Add(time.Duration(i)*time.Minute).Unix(), i, i)
+		assert.NoError(t, err)
+		// make sure the archives get distinct names and modification times
+		time.Sleep(2 * time.Millisecond)
+	}
+	fw.Write([]byte("2024-05-01T10:10:00.000Z\tERROR\tmain.go:1\tfailed\nmain.main()\n"))
+	fw.compressorWG.Wait()
+
+	var entries []Entry
+	err = fw.ReadEntries(time.Time{}, time.Time{}, func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 11)
+	for i, e := range entries[:10] {
+		assert.Equal(t, fmt.Sprintf("entry %d", i), e.Message)
+		assert.Equal(t, zapcore.InfoLevel, e.Level)
+		assert.Equal(t, float64(i), e.Fields["n"])
+		assert.True(t, start.Add(time.Duration(i)*time.Minute).Equal(e.Time), e.Time)
+	}
+	// continuation lines belong to the entry
+	assert.Equal(t, zapcore.ErrorLevel, entries[10].Level)
+	assert.Equal(t, "2024-05-01T10:10:00.000Z\tERROR\tmain.go:1\tfailed\nmain.main()", entries[10].Line)
+
+	entries = nil
+	err = fw.ReadEntries(start.Add(3*time.Minute), start.Add(5*time.Minute), func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, "entry 3", entries[0].Message)
+		assert.Equal(t, "entry 5", entries[2].Message)
+	}
+
+	// errors returned by the callback stop reading
+	stop := fmt.Errorf("stop")
+	n := 0
+	err = fw.ReadEntries(time.Time{}, time.Time{}, func(e Entry) error {
+		n++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestParseLine(t *testing.T) {
+	// a line written by the production encoder
+	var buf bytes.Buffer
+	l := zap.New(zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(&buf),
+		zapcore.DebugLevel,
+	), zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	l.Named("api").Error("request failed", zap.Int("status", 500), zap.String("path", "/x"))
+
+	e, err := ParseLine(buf.Bytes())
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), e.Time, time.Minute)
+	assert.Equal(t, zapcore.ErrorLevel, e.Level)
+	assert.Equal(t, "api", e.Logger)
+	assert.Contains(t, e.Caller, "logging/entries_test.go:")
+	assert.Equal(t, "request failed", e.Message)
+	assert.Contains(t, e.Stacktrace, "TestParseLine")
+	assert.Equal(t, map[string]interface{}{"status": float64(500), "path": "/x"}, e.Fields)
+	assert.Equal(t, strings.TrimSpace(buf.String()), e.Line)
+
+	// msg takes precedence over a message field
+	e, err = ParseLine([]byte(`{"level":"info","ts":1714557600,"message":"user text","msg":"hello"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", e.Message)
+	assert.Equal(t, map[string]interface{}{"message": "user text"}, e.Fields)
+
+	e = parseEntry(`{"log.level":"info","@timestamp":"2024-05-01T10:00:00Z","message":"ecs"}`, time.Now())
+	assert.Equal(t, "ecs", e.Message)
+	assert.Empty(t, e.Fields)
+
+	for _, line := range []string{
+		"not json",
+		`{"level":"info","msg":"no timestamp"}`,
+		`{"level":"loud","ts":1714557600,"msg":"bad level"}`,
+	} {
+		_, err := ParseLine([]byte(line))
+		assert.Error(t, err, line)
+	}
+}
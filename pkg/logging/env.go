@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultEnvPrefix is the prefix of the names of the *EnvVar constants.
+const defaultEnvPrefix = "TEST"
+
+var (
+	// envPrefix replaces defaultEnvPrefix in the names of the environment
+	// variables.  It can be set at build time with
+	//
+	//	-ldflags "-X github.com/ebobo/logging_lab5e_go/pkg/logging.envPrefix=MYAPP"
+	envPrefix   = "TEST"
+	envPrefixMu sync.Mutex
+
+	// stopEnvLevelSignals stops handling the level signals enabled by
+	// LogLevelSignalsEnvVar.
+	stopEnvLevelSignals func()
+)
+
+// EnvVar returns the name of the environment variable given by one of the
+// *EnvVar constants with the prefix set by SetEnvPrefix, eg. "MYAPP_LOG_LEVEL"
+// for LogLevelEnvVar.
+func EnvVar(name string) string {
+	envPrefixMu.Lock()
+	defer envPrefixMu.Unlock()
+	return envPrefix + strings.TrimPrefix(name, defaultEnvPrefix)
+}
+
+// getenv returns the value of the environment variable given by one of the
+// *EnvVar constants.
+func getenv(name string) string {
+	return os.Getenv(EnvVar(name))
+}
+
+// SetEnvPrefix makes the package read its environment variables with prefix
+// instead of "TEST", eg. MYAPP_LOG_LEVEL rather than TEST_LOG_LEVEL for
+// SetEnvPrefix("MYAPP"), so several services on the same host can be
+// configured separately.  The package logger is configured again from the
+// environment, so call this at the start of main.
+func SetEnvPrefix(prefix string) error {
+	envPrefixMu.Lock()
+	envPrefix = strings.TrimSuffix(prefix, "_")
+	envPrefixMu.Unlock()
+
+	return configureFromEnv()
+}
+
+// configureFromEnv configures the package logger from the environment
+// variables or the configuration file given by LogConfigFileEnvVar.
+func configureFromEnv() error {
+	levelFromEnv()
+	return configureSinksFromEnv()
+}
+
+// levelFromEnv applies the level spec and the level signals given by the
+// environment variables.
+func levelFromEnv() {
+	if spec := getenv(LogLevelEnvVar); spec != "" {
+		err := SetLevelSpec(spec)
+		if err != nil {
+			lg.Errorw("invalid log level spec", "spec", spec, "err", err)
+		}
+	}
+
+	if levelSignals, _ := strconv.ParseBool(getenv(LogLevelSignalsEnvVar)); levelSignals && stopEnvLevelSignals == nil {
+		stopEnvLevelSignals = HandleLevelSignals()
+	}
+}
+
+// configureSinksFromEnv creates the sinks given by the environment variables
+// or the configuration file given by LogConfigFileEnvVar.
+func configureSinksFromEnv() error {
+	if path := getenv(LogConfigFileEnvVar); path != "" {
+		return LoadConfigFile(path)
+	}
+	return Configure(ConfigFromEnv())
+}
+
+// Start creates the sinks of the package logger given by the environment,
+// eg. the log directory and files.  Merely importing the package does not
+// create them, they are created when the first entry is logged.  Call Start
+// to create them right away, eg. to fail early if the log directory is not
+// writable.  Start does nothing once the sinks have been created or the
+// logger has been configured with Configure.
+func Start() error {
+	if !rootCore.claimStart() {
+		return nil
+	}
+	return configureSinksFromEnv()
+}
+
+// startFromEnv is the deferred start function of the package logger.
+func startFromEnv() {
+	err := configureSinksFromEnv()
+	if err != nil {
+		lg.Errorw("error configuring logger, logging to console", "err", err)
+	}
+}
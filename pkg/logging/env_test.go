@@ -0,0 +1,22 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetEnvPrefix(t *testing.T) {
+	t.Setenv("MYAPP_LOG_RECENT_ENTRIES", "10")
+	t.Setenv("MYAPP_LOG_FIELDS", "service=billing")
+	t.Setenv(LogFieldsEnvVar, "service=other")
+	defer SetEnvPrefix(defaultEnvPrefix)
+
+	assert.NoError(t, SetEnvPrefix("MYAPP_"))
+	assert.Equal(t, "MYAPP_LOG_LEVEL", EnvVar(LogLevelEnvVar))
+
+	Get().Info("with prefix")
+	entries := Recent(1)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{"service": "billing"}, entries[0].ContextMap())
+}
@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// escapeCore is a zapcore.Core which escapes control characters in the
+// messages and string fields before passing them on.
+type escapeCore struct {
+	zapcore.Core
+}
+
+// NewEscapeCore wraps core so newlines, ANSI escape sequences and other
+// control characters in the message, the logger name and the top level
+// string, byte string, error and stringer fields are escaped, eg. "\n" and
+// "\x1b".  This keeps attacker controlled input from forging additional
+// lines in the log files or corrupting terminals showing the console output.
+// Stack traces are left alone.
+func NewEscapeCore(core zapcore.Core) zapcore.Core {
+	return &escapeCore{Core: core}
+}
+
+func (c *escapeCore) With(fields []zapcore.Field) zapcore.Core {
+	return &escapeCore{Core: c.Core.With(escapeFields(fields))}
+}
+
+func (c *escapeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *escapeCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = escapeControl(ent.Message)
+	ent.LoggerName = escapeControl(ent.LoggerName)
+	return c.Core.Write(ent, escapeFields(fields))
+}
+
+// escapeFields returns fields with control characters in the values of
+// string, byte string, error and fmt.Stringer fields escaped.  The fields are only copied
+// if something changed.
+func escapeFields(fields []zapcore.Field) []zapcore.Field {
+	var escaped []zapcore.Field
+	for i, f := range fields {
+		var s string
+		switch f.Type {
+		case zapcore.StringType:
+			s = f.String
+		case zapcore.ByteStringType:
+			s = string(f.Interface.([]byte))
+		case zapcore.ErrorType, zapcore.StringerType:
+			var ok bool
+			if s, ok = safeString(f.Interface); !ok {
+				// the encoders write "<nil>" for nil pointers
+				continue
+			}
+		default:
+			continue
+		}
+		if !needsEscaping(s) {
+			continue
+		}
+
+		if escaped == nil {
+			escaped = make([]zapcore.Field, len(fields))
+			copy(escaped, fields)
+		}
+		escaped[i] = zap.String(f.Key, escapeControl(s))
+	}
+	if escaped == nil {
+		return fields
+	}
+	return escaped
+}
+
+// isControl reports whether r must be escaped: the C0 and C1 control
+// characters, DEL and the Unicode line and paragraph separators.
+func isControl(r rune) bool {
+	return r < 0x20 || (r >= 0x7f && r <= 0x9f) || r == '\u2028' || r == '\u2029'
+}
+
+func needsEscaping(s string) bool {
+	return strings.IndexFunc(s, isControl) >= 0
+}
+
+// escapeControl returns s with the control characters replaced by Go
+// escape sequences, eg. "\n", "\t" and "\x1b".
+func escapeControl(s string) string {
+	if !needsEscaping(s) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r <= 0xff && isControl(r):
+			fmt.Fprintf(&b, `\x%02x`, r)
+		case isControl(r):
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestEscapeControl(t *testing.T) {
+	assert.Equal(t, "plain text, æøå", escapeControl("plain text, æøå"))
+	assert.Equal(t, `a\nb\r\tc`, escapeControl("a\nb\r\tc"))
+	assert.Equal(t, `\x1b[31mred\x1b[0m\x00\x7f\x9b`, escapeControl("\x1b[31mred\x1b[0m\x00\x7f\u009b"))
+	assert.Equal(t, `a\u2028b`, escapeControl("a\u2028b"))
+}
+
+func TestEscapeCore(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := zap.New(NewEscapeCore(core)).Named("evil\n")
+
+	l.With(zap.String("user", "bob\n{\"level\":\"error\"}")).Info("login\nfailed\x1b[2J",
+		zap.ByteString("agent", []byte("curl\r")),
+		zap.Error(errors.New("bad\ninput")),
+		zap.Int("attempt", 3),
+		zap.String("ok", "fine"))
+
+	entries := logs.All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, `login\nfailed\x1b[2J`, entries[0].Message)
+	assert.Equal(t, `evil\n`, entries[0].LoggerName)
+	assert.Equal(t, map[string]interface{}{
+		"user":    `bob\n{"level":"error"}`,
+		"agent":   `curl\r`,
+		"error":   `bad\ninput`,
+		"attempt": int64(3),
+		"ok":      "fine",
+	}, entries[0].ContextMap())
+
+	// typed nils are left to the encoder, which writes "<nil>"
+	assert.NotPanics(t, func() {
+		l.Info("nil", zap.Error((*maskStringer)(nil)), zap.Stringer("who", (*maskStringer)(nil)))
+	})
+	entries = logs.All()
+	assert.Equal(t, map[string]interface{}{
+		"error": "<nil>",
+		"who":   "<nil>",
+	}, entries[1].ContextMap())
+
+	l.Info("stringer", zap.Stringer("who", &maskStringer{"mallory\x1b[2J"}))
+	entries = logs.All()
+	assert.Equal(t, `mallory\x1b[2J`, entries[2].ContextMap()["who"])
+
+	fields := []zapcore.Field{zap.String("ok", "fine")}
+	assert.Equal(t, &fields[0], &escapeFields(fields)[0], "unchanged fields are not copied")
+}
@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"errors"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// EventLogConfig contains the configuration for logging to the Windows Event Log.
+type EventLogConfig struct {
+	// Source is the event source name the entries are logged under.  Defaults
+	// to the name of the binary.
+	Source string
+	// Level is the minimum level written to the event log.  Entries below Warn
+	// are never written since operators only look for problems in Event Viewer.
+	Level zapcore.Level
+}
+
+// ErrEventLogUnsupported is returned when trying to use the Windows Event Log
+// on other platforms.
+var ErrEventLogUnsupported = errors.New("the windows event log is only supported on windows")
+
+// eventLogEncoder returns the encoder used for event log messages.  The event
+// log records the time and severity itself so we leave them out.
+func eventLogEncoder() zapcore.Encoder {
+	ec := zap.NewProductionEncoderConfig()
+	ec.TimeKey = ""
+	ec.LevelKey = ""
+	return zapcore.NewJSONEncoder(ec)
+}
+
+// eventLogLevel returns the level enabler for the event log core.  Entries
+// must satisfy both the configured minimum level and the global log level.
+func eventLogLevel(c EventLogConfig) zapcore.LevelEnabler {
+	minLevel := c.Level
+	if minLevel < zapcore.WarnLevel {
+		minLevel = zapcore.WarnLevel
+	}
+	return zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= minLevel && coreLevel.Enabled(l)
+	})
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package logging
+
+import (
+	"io"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// NewEventLogCore always fails with ErrEventLogUnsupported on platforms other
+// than windows.
+func NewEventLogCore(c EventLogConfig) (zapcore.Core, io.Closer, error) {
+	return nil, nil, ErrEventLogUnsupported
+}
@@ -0,0 +1,89 @@
+//go:build windows
+
+package logging
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventID is the event id used for all log entries.
+const eventID = 1
+
+// eventLogCore is a zapcore.Core writing entries to the Windows Event Log.
+type eventLogCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	log *eventlog.Log
+}
+
+// NewEventLogCore registers the event source (if it isn't already) and
+// returns a core that writes to the Windows Event Log.  Warn entries are
+// logged as warnings and everything above as errors.  The returned closer
+// must be closed when the core is no longer in use.
+func NewEventLogCore(c EventLogConfig) (zapcore.Core, io.Closer, error) {
+	if c.Source == "" {
+		c.Source = strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0]))
+	}
+
+	// Registering the source requires administrative privileges and fails if
+	// the source already exists, so we only care whether we can open it.
+	eventlog.InstallAsEventCreate(c.Source, eventlog.Error|eventlog.Warning|eventlog.Info)
+
+	log, err := eventlog.Open(c.Source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &eventLogCore{
+		LevelEnabler: eventLogLevel(c),
+		enc:          eventLogEncoder(),
+		log:          log,
+	}, log, nil
+}
+
+func (c *eventLogCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &eventLogCore{
+		LevelEnabler: c.LevelEnabler,
+		enc:          c.enc.Clone(),
+		log:          c.log,
+	}
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *eventLogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *eventLogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	msg := strings.TrimSuffix(buf.String(), "\n")
+	switch {
+	case ent.Level >= zapcore.ErrorLevel:
+		return c.log.Error(eventID, msg)
+	case ent.Level == zapcore.WarnLevel:
+		return c.log.Warning(eventID, msg)
+	default:
+		return c.log.Info(eventID, msg)
+	}
+}
+
+func (c *eventLogCore) Sync() error {
+	return nil
+}
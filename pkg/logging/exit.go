@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	exitHooks   []func()
+	exitHooksMu sync.Mutex
+)
+
+// RegisterExitHook registers f to be called before the process exits
+// because something was logged at Fatal level or Exit was called.  Hooks are
+// called in reverse order of registration, before the sinks are closed, so
+// they can still log.
+func RegisterExitHook(f func()) {
+	exitHooksMu.Lock()
+	defer exitHooksMu.Unlock()
+	exitHooks = append(exitHooks, f)
+}
+
+// Exit runs the exit hooks, flushes and closes the sinks of the package
+// logger and exits with the given code.  Use it instead of os.Exit so
+// buffered entries are written and archives being compressed are completed.
+func Exit(code int) {
+	runExitHooks()
+	os.Exit(code)
+}
+
+// Flush writes the entries held back by the sinks of the package logger, eg.
+// in async mode or in write buffers, and syncs the log files to disk.
+func Flush() error {
+	var errs []error
+	for _, fw := range currentFileWriters() {
+		errs = append(errs, fw.Sync())
+	}
+	// syncing the console fails on some terminals, which is not worth
+	// reporting
+	rootCore.Sync()
+	return errors.Join(errs...)
+}
+
+// Shutdown runs the exit hooks, flushes and closes the sinks of the package
+// logger and waits for archives being compressed, like Exit without exiting.
+// Entries logged afterwards go to the console.  If ctx is done before the
+// sinks are closed Shutdown returns the error of ctx and the sinks are closed
+// in the background.
+func Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runExitHooks()
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runExitHooks runs the registered exit hooks and closes the sinks.  The
+// hooks are only run once.
+func runExitHooks() {
+	exitHooksMu.Lock()
+	hooks := exitHooks
+	exitHooks = nil
+	exitHooksMu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+
+	auditLogMu.Lock()
+	if auditLog != nil {
+		auditLog.Close()
+	}
+	auditLogMu.Unlock()
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	rootCore.claimStart()
+	rootCore.Sync()
+	// entries logged by other goroutines from now on go to the console
+	configuredCore = newPackageLevelCore(consoleCore(Config{}))
+	rootCore.swap(finishCore(configuredCore))
+	for _, closer := range closers {
+		closer.Close()
+	}
+	closers = nil
+}
+
+// exitCore runs the exit hooks after entries at Fatal level have been
+// written to all other cores, just before zap exits the process.  The hooks
+// run even if the other cores drop the entry, eg. because of their level or
+// sampling, since zap exits regardless.  exit replaces runExitHooks if set.
+type exitCore struct {
+	zapcore.Core
+	exit func()
+}
+
+func (c exitCore) With(fields []zapcore.Field) zapcore.Core {
+	return exitCore{c.Core.With(fields), c.exit}
+}
+
+func (c exitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ce = c.Core.Check(ent, ce)
+	if ent.Level >= zapcore.FatalLevel {
+		ce = ce.AddCore(ent, exitHookCore{c.exit})
+	}
+	return ce
+}
+
+// exitHookCore is added to the checked entries at Fatal level by exitCore.
+type exitHookCore struct {
+	exit func()
+}
+
+func (exitHookCore) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (c exitHookCore) With([]zapcore.Field) zapcore.Core {
+	return c
+}
+
+func (c exitHookCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c exitHookCore) Write(zapcore.Entry, []zapcore.Field) error {
+	if c.exit != nil {
+		c.exit()
+		return nil
+	}
+	runExitHooks()
+	return nil
+}
+
+func (exitHookCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestExitCore(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := zap.New(exitCore{Core: core}, zap.OnFatal(zapcore.WriteThenGoexit))
+
+	var calls []string
+	RegisterExitHook(func() { calls = append(calls, "first") })
+	RegisterExitHook(func() {
+		// the fatal entry has been written when the hooks run
+		assert.Equal(t, 2, logs.Len())
+		calls = append(calls, "second")
+	})
+
+	l.Error("not fatal")
+	assert.Empty(t, calls)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Fatal("fatal")
+	}()
+	<-done
+
+	assert.Equal(t, []string{"second", "first"}, calls)
+
+	// hooks only run once
+	runExitHooks()
+	assert.Len(t, calls, 2)
+
+	// the hooks run when the fatal entry is dropped as well
+	l = zap.New(exitCore{Core: zapcore.NewNopCore()}, zap.OnFatal(zapcore.WriteThenGoexit))
+	RegisterExitHook(func() { calls = append(calls, "dropped") })
+
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Fatal("dropped")
+	}()
+	<-done
+
+	assert.Equal(t, []string{"second", "first", "dropped"}, calls)
+}
+
+func TestFlushAndShutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer Configure(Config{})
+	assert.NoError(t, Configure(Config{
+		Mode: "file",
+		FileWriter: FileWriterConfig{
+			LogDirName:      dir,
+			LogFileName:     "shutdown.log",
+			BufferSizeBytes: 4096,
+			Async:           true,
+		},
+	}))
+	path := filepath.Join(dir, "shutdown.log")
+
+	Get().Info("flushed entry")
+	assert.NoError(t, Flush())
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "flushed entry")
+
+	Get().Info("last entry")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, Shutdown(ctx))
+	data, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "last entry")
+
+	// later entries go to the console
+	Get().Info("after shutdown")
+	data, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "after shutdown")
+}
@@ -0,0 +1,130 @@
+package logging
+
+import (
+	"io"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// EncoderKind is the encoding of the entries written by AddWriter.
+type EncoderKind string
+
+// The encodings supported by AddWriter.
+const (
+	EncoderJSON    EncoderKind = "json"
+	EncoderLogfmt  EncoderKind = "logfmt"
+	EncoderECS     EncoderKind = "ecs"
+	EncoderConsole EncoderKind = "console"
+)
+
+// addedCore and coreWrapper are pointers so they can be told apart when they
+// are removed.
+type (
+	addedCore   struct{ core zapcore.Core }
+	coreWrapper struct {
+		wrap func(zapcore.Core) zapcore.Core
+	}
+)
+
+var (
+	// addedCores and coreWrappers are applied to the core built by
+	// Configure, in the order they were added.  They are protected by
+	// configMu.
+	addedCores   []*addedCore
+	coreWrappers []*coreWrapper
+
+	// discarding is the number of calls to Discard which have not been
+	// restored yet.  It is protected by configMu.
+	discarding int
+)
+
+// AddCore makes the package logger write to core in addition to the
+// configured sinks, eg. to ship entries to a custom backend.  The core stays
+// attached when the logger is reconfigured.  Call the returned function to
+// detach it.  Closing the core is up to the caller.
+func AddCore(core zapcore.Core) (remove func()) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	added := &addedCore{core: NewRedactCore(core)}
+	addedCores = append(addedCores, added)
+	rootCore.swap(finishCore(configuredCore))
+
+	return func() {
+		configMu.Lock()
+		defer configMu.Unlock()
+
+		for i, a := range addedCores {
+			if a == added {
+				addedCores = append(addedCores[:i:i], addedCores[i+1:]...)
+				break
+			}
+		}
+		rootCore.swap(finishCore(configuredCore))
+	}
+}
+
+// AddWriter makes the package logger mirror entries at level and above to
+// w, eg. a test buffer, a pipe or a socket, encoded as enc.  Unknown
+// encodings fall back to JSON.  Writes to w are serialized.  Call the
+// returned function to stop writing to w.
+func AddWriter(w io.Writer, enc EncoderKind, level zapcore.Level) (remove func()) {
+	var encoder zapcore.Encoder
+	if enc == EncoderConsole {
+		encoder = ConsoleConfig{}.encoder(TimeConfig{})
+	} else {
+		encoder = structuredEncoder(Config{Encoding: string(enc)})
+	}
+	return AddCore(zapcore.NewCore(encoder, zapcore.Lock(zapcore.AddSync(w)), level))
+}
+
+// WrapCore wraps the core of the package logger, including the cores added
+// with AddCore, eg. to filter or modify entries.  The wrapper is applied
+// again when the logger is reconfigured.  Call the returned function to
+// remove it.
+func WrapCore(wrap func(zapcore.Core) zapcore.Core) (remove func()) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	wrapper := &coreWrapper{wrap: wrap}
+	coreWrappers = append(coreWrappers, wrapper)
+	rootCore.swap(finishCore(configuredCore))
+
+	return func() {
+		configMu.Lock()
+		defer configMu.Unlock()
+
+		for i, w := range coreWrappers {
+			if w == wrapper {
+				coreWrappers = append(coreWrappers[:i:i], coreWrappers[i+1:]...)
+				break
+			}
+		}
+		rootCore.swap(finishCore(configuredCore))
+	}
+}
+
+// finishCore adds the cores added with AddCore, the wrappers of WrapCore
+// and the global fields to the core built by Configure and wraps it in an
+// exitCore.  While Discard is in effect it returns a core dropping all
+// entries instead.  This must be called with configMu locked.
+func finishCore(core zapcore.Core) zapcore.Core {
+	if discarding > 0 {
+		return exitCore{Core: zapcore.NewNopCore()}
+	}
+	if len(addedCores) > 0 {
+		cores := make([]zapcore.Core, 0, len(addedCores)+1)
+		cores = append(cores, core)
+		for _, a := range addedCores {
+			cores = append(cores, a.core)
+		}
+		core = newLevelTee(cores...)
+	}
+	for _, w := range coreWrappers {
+		core = w.wrap(core)
+	}
+	if len(globalFields) > 0 {
+		core = core.With(globalFields)
+	}
+	return exitCore{Core: core}
+}
@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAddCore(t *testing.T) {
+	defer Configure(Config{})
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	remove := AddCore(core)
+
+	// the core stays attached when the logger is reconfigured
+	assert.NoError(t, Configure(Config{}))
+	Get().Info("added")
+	assert.Equal(t, 1, logs.FilterMessage("added").Len())
+
+	remove()
+	Get().Info("removed")
+	assert.Equal(t, 0, logs.FilterMessage("removed").Len())
+}
+
+func TestWrapCore(t *testing.T) {
+	defer Configure(Config{})
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	removeCore := AddCore(core)
+	defer removeCore()
+
+	// drop entries of the noisy logger
+	remove := WrapCore(func(c zapcore.Core) zapcore.Core {
+		return &loggerFilterCore{Core: c, loggers: []string{"noisy"}, include: false}
+	})
+
+	Get().Named("noisy").Info("dropped")
+	Get().Info("kept")
+	assert.Equal(t, 0, logs.FilterMessage("dropped").Len())
+	assert.Equal(t, 1, logs.FilterMessage("kept").Len())
+
+	remove()
+	Get().Named("noisy").Info("dropped")
+	assert.Equal(t, 1, logs.FilterMessage("dropped").Len())
+}
+
+func TestAddWriter(t *testing.T) {
+	defer Configure(Config{})
+
+	var buf bytes.Buffer
+	remove := AddWriter(&buf, EncoderLogfmt, zapcore.WarnLevel)
+
+	Get().Info("info message")
+	Get().Warn("warn message", zap.Int("attempt", 2))
+	remove()
+	Get().Warn("after remove")
+
+	assert.NotContains(t, buf.String(), "info message")
+	assert.Contains(t, buf.String(), `msg="warn message"`)
+	assert.Contains(t, buf.String(), "attempt=2")
+	assert.NotContains(t, buf.String(), "after remove")
+}
@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultFallbackRetryInterval = 30 * time.Second
+
+// fallbackState tracks failover to the fallback writer.  It is protected by
+// the mutex of the FileWriter.
+type fallbackState struct {
+	failures  int
+	active    bool
+	lastRetry time.Time
+	// failing is set while writes to the log file fail
+	failing bool
+}
+
+// fallbackWriter returns the writer used when the log file fails.
+func (w *FileWriter) fallbackWriter() zapcore.WriteSyncer {
+	if w.config.Fallback != nil {
+		return w.config.Fallback
+	}
+	return zapcore.AddSync(os.Stderr)
+}
+
+// useFallback reports whether we should write to the fallback writer.  When
+// the retry interval has passed we reopen the log file and give it another
+// chance.  This assumes that w.mu is locked.
+func (w *FileWriter) useFallback() bool {
+	if !w.fallback.active {
+		return false
+	}
+
+	interval := w.config.FallbackRetryInterval
+	if interval <= 0 {
+		interval = defaultFallbackRetryInterval
+	}
+	if w.clock().Now().Sub(w.fallback.lastRetry) < interval {
+		return true
+	}
+	w.fallback.lastRetry = w.clock().Now()
+
+	w.flushBuffer()
+	w.logFile.Close()
+	err := w.openLogFile()
+	if err != nil {
+		w.recordError(err)
+		return true
+	}
+	if info, err := w.logFile.Stat(); err == nil {
+		w.byteCounter = info.Size()
+	}
+
+	w.fallback.active = false
+	return false
+}
+
+// writeFallback writes msg to the fallback writer after a failed write to the
+// log file if we have failed often enough.  It reports whether msg was
+// handled.  This assumes that w.mu is locked.
+func (w *FileWriter) writeFallback(msg []byte, cause error) (int, bool, error) {
+	if w.config.FallbackAfterFailures <= 0 {
+		return 0, false, nil
+	}
+
+	w.fallback.failures++
+	if w.fallback.failures < w.config.FallbackAfterFailures {
+		return 0, false, nil
+	}
+
+	fallback := w.fallbackWriter()
+	if !w.fallback.active {
+		w.fallback.active = true
+		w.fallback.lastRetry = w.clock().Now()
+		fmt.Fprintf(fallback, "writing to logfile %s failed %d times (%v), switching to fallback\n", w.logFileNameFullPath, w.fallback.failures, cause)
+	}
+
+	n, err := fallback.Write(msg)
+	return n, true, err
+}
+
+// writeSucceeded resets the failure count after a successful write to the
+// log file.  This assumes that w.mu is locked.
+func (w *FileWriter) writeSucceeded() {
+	if w.config.FallbackAfterFailures > 0 && w.fallback.failures >= w.config.FallbackAfterFailures {
+		fmt.Fprintf(w.fallbackWriter(), "logfile %s is writable again, switching back from fallback\n", w.logFileNameFullPath)
+	}
+	w.fallback.failures = 0
+	w.fallback.failing = false
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestFileWriterFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var fallback bytes.Buffer
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:            dir,
+		LogFileName:           "logfile.log",
+		FallbackAfterFailures: 2,
+		Fallback:              zapcore.AddSync(&fallback),
+		FallbackRetryInterval: 50 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	// close the file behind the FileWriter's back to make writes fail
+	fw.logFile.Close()
+
+	_, err = fw.Write([]byte("lost\n"))
+	assert.Error(t, err)
+	assert.Empty(t, fallback.String())
+
+	n, err := fw.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 7, n)
+	_, err = fw.Write([]byte("third\n"))
+	assert.NoError(t, err)
+	assert.Contains(t, fallback.String(), "switching to fallback")
+	assert.Contains(t, fallback.String(), "second\nthird\n")
+
+	// the log file is reopened once the retry interval has passed
+	time.Sleep(60 * time.Millisecond)
+	_, err = fw.Write([]byte("recovered\n"))
+	assert.NoError(t, err)
+	assert.Contains(t, fallback.String(), "switching back")
+
+	data, err := os.ReadFile(fw.logFileNameFullPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "recovered\n", string(data))
+}
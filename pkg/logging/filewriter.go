@@ -1,28 +1,49 @@
 package logging
 
 import (
-	"compress/gzip"
+	"bufio"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
+
+	"go.uber.org/zap/zapcore"
 )
 
 // FileWriter writes logs to the filesystem.
 type FileWriter struct {
-	closed              atomic.Value
+	closed              atomic.Bool
 	config              FileWriterConfig
 	mu                  sync.Mutex
-	logFile             *os.File
+	logFile             File
+	bufWriter           *bufio.Writer
 	logFileNameFullPath string
 	byteCounter         int64
+	headerSize          int64
 	compressorWG        sync.WaitGroup
+	compressSlots       chan struct{}
+	compressingMu       sync.Mutex
+	compressing         map[string]bool
+	rotated             map[string]bool
+	openedAt            time.Time
+	scheduledRotation   time.Time
+	done                chan struct{}
+	closeOnce           sync.Once
+	backgroundWG        sync.WaitGroup
+	async               *asyncBuffer
+	drops               dropCounter
+	lockFile            *os.File
+	stats               writerStats
+	fallback            fallbackState
+	spillBuf            spillBuffer
 }
 
 // FileWriterConfig contains the configuration for a FileWriter
@@ -33,26 +54,199 @@ type FileWriterConfig struct {
 	// If MaxDaysToKeep is 0 we keep the all log files regardless of age
 	MaxTimeTimeToKeep   time.Duration
 	MaxLogFileSizeBytes int64
+	// FilePermissions are the permissions of the log files and archives.
+	// Defaults to 0644.
+	FilePermissions os.FileMode
+	// DirPermissions are the permissions of the log directory if we create
+	// it.  Defaults to 0755.
+	DirPermissions os.FileMode
+	// If Chown is set the log files, the archives and the log directory, if
+	// we create it, are owned by UID and GID.  This is for log shipping
+	// agents running as a separate user.  Changing the owner usually
+	// requires privileges and is not supported on Windows.
+	Chown bool
+	UID   int
+	GID   int
+	// CleanupInterval is how often the archives are checked against
+	// MaxTimeTimeToKeep, MaxTotalSizeBytes and MaxArchivedFiles, or the
+	// RetentionPolicy, while the FileWriter is running.  Defaults to an
+	// hour if MaxTimeTimeToKeep or RetentionPolicy is set, otherwise
+	// archives are only checked at startup and on rotation.
+	CleanupInterval time.Duration
+	// If ReopenOnSIGHUP is set the log file is closed and reopened when the
+	// process receives SIGHUP.  This is for use with external tools such as
+	// logrotate which move the log file out of the way.
+	ReopenOnSIGHUP bool
+	// If RotateInterval is set the log file is rotated when it has been open
+	// for this long even if it hasn't reached MaxLogFileSizeBytes.  This gives
+	// a predictable archive cadence under low write volume.  Empty log files
+	// are not rotated.
+	RotateInterval time.Duration
+	// If RotateSchedule is set the log file is rotated at the times given by
+	// the schedule, eg. DailyUTC, and the archive is named after the period it
+	// covers (log-2024-05-01.log).  Empty log files are not rotated.
+	RotateSchedule RotationSchedule
+	// If MaxTotalSizeBytes is set the oldest archives are deleted until the
+	// log file and its archives take up no more than this many bytes.  This
+	// is checked at startup and after each rotation.
+	MaxTotalSizeBytes int64
+	// If MaxArchivedFiles is set only the MaxArchivedFiles most recent
+	// archives are kept regardless of their age.  This corresponds to
+	// MaxBackups in lumberjack.
+	MaxArchivedFiles int
+	// CompressionCodec is the codec used to compress archives when Compress
+	// is set.  Defaults to CodecGzip.
+	CompressionCodec Codec
+	// CompressionConcurrency is the maximum number of archives compressed
+	// at the same time, so a backlog of uncompressed archives found at
+	// startup doesn't hog the disk and the CPU.  Defaults to 2.
+	CompressionConcurrency int
+	// Encryption configures encryption of compressed archives, for logs
+	// that must be encrypted at rest.
+	Encryption EncryptionConfig
+	// If Header is set its result is written at the top of each new log
+	// file, at startup and after rotation, so every archive is
+	// self-describing.  See Config.FileHeader.
+	Header func() []byte
+	// OnArchived is called with the path of each archive once it is
+	// finished, ie. after compression if Compress is set.  Use this to ship
+	// archives to object storage.  The hook runs in the background and Close
+	// waits for it to return.
+	OnArchived func(path string) error
+	// If RemoveAfterOnArchived is set the archive is deleted locally when
+	// OnArchived returns without error.
+	RemoveAfterOnArchived bool
+	// OnRotate is called after each rotation with the path of the archive,
+	// once it is compressed if Compress is set, and the path of the new log
+	// file.  It is called before OnArchived, runs in the background and
+	// Close waits for it to return.
+	OnRotate func(old, new string)
+	// ArchiveNameTemplate is the template used to name archives.  It can
+	// contain the placeholders {prefix} (the log file name without
+	// extension), {timestamp}, {pid}, {seq} and {ext}.  The default is
+	// "{prefix}-{timestamp}.{ext}" which is compatible with lumberjack.  The
+	// template should start with "{prefix}-" so retention can tell archives
+	// apart from other files.
+	ArchiveNameTemplate string
+	// If FsyncEvery is set the log file is synced to disk at this interval.
+	FsyncEvery time.Duration
+	// If FsyncOnError is set the log file is synced to disk after every entry
+	// at Error level or above, so the last lines before a crash survive.  This
+	// is handled by the logging core, see Configure.
+	FsyncOnError bool
+	// If BufferSizeBytes is set writes to the log file go through a buffer
+	// of this size, which saves a system call per entry for chatty
+	// services.  The buffer is flushed when it is full, every
+	// FlushInterval and after every entry at Error level or above, which
+	// bounds how much is lost if the process dies.
+	BufferSizeBytes int
+	// FlushInterval is how often the buffer is flushed.  Defaults to one
+	// second.
+	FlushInterval time.Duration
+	// If Async is set Write only appends to an in-memory buffer which is
+	// written to disk in batches by a background goroutine.  Write blocks
+	// when the buffer is full.  Close writes whatever is left in the buffer.
+	Async bool
+	// AsyncBufferSizeBytes is the size of the in-memory buffer used in async
+	// mode.  Defaults to 1MB.
+	AsyncBufferSizeBytes int
+	// If DropOnOverload is set entries are dropped rather than blocking the
+	// caller when the async buffer is full.  Use Dropped to find out how
+	// many entries were lost.  DropOnOverload implies Async.
+	DropOnOverload bool
+	// If CurrentSymlink is set we maintain a symlink with this name pointing
+	// at the active log file, eg. "current.log".  Relative paths are relative
+	// to LogDirName.  The link is refreshed whenever the log file is opened.
+	CurrentSymlink string
+	// OnError is called with every error the FileWriter runs into while
+	// writing, syncing, rotating or compressing log files, so applications
+	// can count or alert on logging failures.  It may be called while the
+	// FileWriter is locked, so it must not write to the FileWriter.
+	OnError func(err error)
+	// If FallbackAfterFailures is set we switch to the Fallback writer after
+	// this many consecutive failed writes to the log file, eg. when the disk
+	// is full.  The log file is retried every FallbackRetryInterval.
+	FallbackAfterFailures int
+	// Fallback is the writer used when the log file fails.  Defaults to
+	// stderr.
+	Fallback zapcore.WriteSyncer
+	// FallbackRetryInterval is how often we retry the log file while writing
+	// to the fallback writer.  Defaults to 30 seconds.
+	FallbackRetryInterval time.Duration
+	// If SpillBufferSizeBytes is set entries that can't be written to the
+	// log file, eg. because the disk is full, are held in memory up to this
+	// many bytes and written in order once the log file can be written
+	// again.  When the buffer is full we fall back to the Fallback writer if
+	// FallbackAfterFailures is set.
+	SpillBufferSizeBytes int
+	// SpillRetryInterval is how often we try to write the spilled entries.
+	// Defaults to one second.
+	SpillRetryInterval time.Duration
+	// FS is the filesystem the log files are written to.  Defaults to
+	// OSFS.  CurrentSymlink, FilePermissions, Chown and LockLogDir only
+	// apply to OSFS.
+	FS FS
+	// Clock is used for rotation, archive names and retention.  Defaults
+	// to SystemClock.  Tests can use a FakeClock to rotate without sleeping.
+	Clock Clock
+	// RetentionPolicy decides which archives are deleted.  If it is set
+	// MaxTimeTimeToKeep, MaxArchivedFiles and MaxTotalSizeBytes are
+	// ignored.
+	RetentionPolicy RetentionPolicy
+	// RotationPolicy decides when the log file is rotated after a write.
+	// Defaults to SizePolicy(MaxLogFileSizeBytes).  RotateInterval and
+	// RotateSchedule apply in addition to it.
+	RotationPolicy RotationPolicy
+	// If LockLogDir is set we take an advisory lock on a lock file next to
+	// the log file, so a second FileWriter using the same log file, usually
+	// another instance of the same binary, fails with a *LockedError rather
+	// than interleaving writes and rotations.  The lock is released by
+	// Close.
+	LockLogDir bool
+
+	// minSizeBytes replaces minLogFileSizeBytes if it is set, see
+	// withMinSize.
+	minSizeBytes int64
 }
 
 const (
-	minLogFileSizeBytes     = int64(100000)
-	maxLogFileSizeBytes     = math.MaxInt64
-	logDirPermissions       = 0755
-	logFilePermissions      = 0644
-	defaultLogFileSizeBytes = int64(1000000)
-	defaultLogDirName       = "./log"
-	defaultLogFileName      = "log.log"
-	archiveNameFormat       = "2006-01-02T15-04-05.00000"
-	compressedExtension     = "gz"
-	processingExtenstion    = "processing"
+	minLogFileSizeBytes           = int64(100000)
+	maxLogFileSizeBytes           = math.MaxInt64
+	logDirPermissions             = 0755
+	logFilePermissions            = 0644
+	defaultLogFileSizeBytes       = int64(1000000)
+	defaultAsyncBufferSize        = 1 << 20
+	defaultCompressionConcurrency = 2
+	defaultCleanupInterval        = time.Hour
+	defaultLogDirName             = "./log"
+	defaultLogFileName            = "log.log"
+	archiveNameFormat             = "2006-01-02T15-04-05.00000"
+	compressedExtension           = "gz"
+	processingExtenstion          = "processing"
 )
 
-// NewFileWriter creates a new FileWriter given a FileWriterConfig
-func NewFileWriter(c FileWriterConfig) *FileWriter {
+// NewFileWriter creates a new FileWriter given a FileWriterConfig and
+// options applied on top of it, eg.
+//
+//	fw, err := NewFileWriter(FileWriterConfig{LogDirName: dir},
+//		WithMaxSize(10*1024*1024), WithCompression(CodecZstd))
+func NewFileWriter(c FileWriterConfig, opts ...FileWriterOption) (*FileWriter, error) {
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return newFileWriter(c)
+}
+
+// newFileWriter creates a new FileWriter, returning initialization errors to
+// the caller.
+func newFileWriter(c FileWriterConfig) (*FileWriter, error) {
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
 	if c.MaxLogFileSizeBytes == 0 {
 		c.MaxLogFileSizeBytes = defaultLogFileSizeBytes
-		fmt.Printf("filesize %d\n", c.MaxLogFileSizeBytes)
 	}
 	if c.LogDirName == "" {
 		c.LogDirName = defaultLogDirName
@@ -60,54 +254,403 @@ func NewFileWriter(c FileWriterConfig) *FileWriter {
 	if c.LogFileName == "" {
 		c.LogFileName = defaultLogFileName
 	}
+	if c.CompressionConcurrency <= 0 {
+		c.CompressionConcurrency = defaultCompressionConcurrency
+	}
+	if c.CleanupInterval == 0 && (c.MaxTimeTimeToKeep > 0 || c.RetentionPolicy != nil) {
+		c.CleanupInterval = defaultCleanupInterval
+	}
+	if c.RotationPolicy == nil {
+		c.RotationPolicy = SizePolicy(c.MaxLogFileSizeBytes)
+	}
 
 	fileWriter := FileWriter{
 		config:              c,
 		logFileNameFullPath: path.Join(c.LogDirName, c.LogFileName),
+		done:                make(chan struct{}),
+		compressSlots:       make(chan struct{}, c.CompressionConcurrency),
+		compressing:         map[string]bool{},
+		rotated:             map[string]bool{},
 	}
 
-	err := fileWriter.initialize()
+	err = fileWriter.initialize()
 	if err != nil {
-		lg.Fatalw("error initializing filewriter", "err", err)
+		fileWriter.releaseLock()
+		return nil, err
+	}
+
+	if c.ReopenOnSIGHUP {
+		fileWriter.handleSIGHUP()
+	}
+
+	if c.Async || c.DropOnOverload {
+		fileWriter.startAsync()
+	}
+
+	if c.FsyncEvery > 0 {
+		fileWriter.backgroundWG.Add(1)
+		go fileWriter.syncPeriodically()
+	}
+
+	if c.BufferSizeBytes > 0 {
+		fileWriter.backgroundWG.Add(1)
+		go fileWriter.flushPeriodically()
+	}
+
+	if c.CleanupInterval > 0 {
+		fileWriter.backgroundWG.Add(1)
+		go fileWriter.cleanupPeriodically()
+	}
+
+	if c.RotateSchedule != nil {
+		fileWriter.scheduledRotation = c.RotateSchedule.Next(fileWriter.clock().Now())
+	}
+
+	if c.RotateInterval > 0 || c.RotateSchedule != nil {
+		fileWriter.backgroundWG.Add(1)
+		go fileWriter.rotateOnSchedule()
 	}
 
-	return &fileWriter
+	return &fileWriter, nil
 }
 
 // Close the logger.
 func (w *FileWriter) Close() error {
+	if w.async != nil {
+		w.async.close()
+	}
+	w.closeOnce.Do(func() { close(w.done) })
+	w.backgroundWG.Wait()
+
+	w.mu.Lock()
+	// give spilled entries a last chance
+	if w.spillBuf.pending() && !w.closed.Load() {
+		w.spillBuf.lastRetry = time.Time{}
+		if !w.replaySpill() {
+			fmt.Printf("lost %d spilled bytes for logfile %s\n", len(w.spillBuf.buf), w.logFileNameFullPath)
+		}
+	}
 	w.closed.Store(true)
+	w.flushBuffer()
+	err := w.logFile.Close()
+	lockErr := w.releaseLock()
+	w.mu.Unlock()
+
 	w.compressorWG.Wait()
-	return w.logFile.Close()
+	if err != nil {
+		return err
+	}
+	return lockErr
 }
 
 func (w *FileWriter) Write(msg []byte) (int, error) {
+	return w.writeEntry(msg, nil)
+}
+
+// writeEntry writes msg.  If level is given and the entry is dropped the
+// drop is counted for that level.
+func (w *FileWriter) writeEntry(msg []byte, level *zapcore.Level) (int, error) {
+	if w.async != nil {
+		n, dropped, err := w.async.write(msg)
+		if dropped {
+			w.drops.add(level)
+		}
+		return n, err
+	}
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if w.closed.Load() != nil {
+	return w.writeLocked(msg)
+}
+
+// writeLocked writes msg to the log file and rotates it if it has grown too
+// large.  This assumes that w.mu is locked.
+func (w *FileWriter) writeLocked(msg []byte) (int, error) {
+	if w.closed.Load() {
 		return 0, os.ErrClosed
 	}
 
-	n, err := w.logFile.Write(msg)
+	if w.useFallback() {
+		return w.fallbackWriter().Write(msg)
+	}
+
+	// keep entries in order while there are spilled entries left
+	if !w.replaySpill() {
+		if w.spill(msg) {
+			return len(msg), nil
+		}
+		fn, handled, fallbackErr := w.writeFallback(msg, ErrSpillBufferFull)
+		if handled {
+			return fn, fallbackErr
+		}
+		return 0, ErrSpillBufferFull
+	}
+
+	n, err := w.out().Write(msg)
+	now := w.clock().Now()
+	if err != nil && w.bufWriter != nil {
+		// the part of msg taken by the buffer is discarded with it
+		w.retryBuffer()
+		n = 0
+	}
 	if err != nil {
-		fmt.Printf("logfile error : %v", err)
+		// only report the first of a run of failures
+		if !w.fallback.failing {
+			fmt.Printf("error writing logfile %s: %v\n", w.logFileNameFullPath, err)
+			w.fallback.failing = true
+		}
+		w.recordError(err)
+
+		if w.spill(msg[n:]) {
+			return len(msg), nil
+		}
+
+		fn, handled, fallbackErr := w.writeFallback(msg[n:], err)
+		if handled {
+			return n + fn, fallbackErr
+		}
+	} else {
+		w.stats.lastWrite.Store(now.UnixNano())
+		w.writeSucceeded()
 	}
 
 	w.byteCounter += int64(n)
+	w.stats.bytesWritten.Add(int64(n))
 
-	if w.byteCounter > w.config.MaxLogFileSizeBytes {
-		w.rotate()
+	if w.config.RotationPolicy.ShouldRotate(FileStats{
+		Path:     w.logFileNameFullPath,
+		Size:     w.byteCounter,
+		OpenedAt: w.openedAt,
+		Now:      now,
+	}) {
+		rotateErr := w.rotate()
+		if rotateErr != nil {
+			w.recordError(rotateErr)
+		}
 	}
 
 	return n, err
 }
 
+// Sync commits the contents of the log file to stable storage.  In async
+// mode the buffered entries are written first.
+func (w *FileWriter) Sync() error {
+	if w.async != nil {
+		w.flushAsync()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed.Load() {
+		return os.ErrClosed
+	}
+
+	err := w.flushBuffer()
+	if err != nil {
+		return err
+	}
+	return w.logFile.Sync()
+}
+
+// syncPeriodically syncs the log file every FsyncEvery.  Note that you MUST
+// call w.backgroundWG.Add(1) before starting this goroutine.
+func (w *FileWriter) syncPeriodically() {
+	defer w.backgroundWG.Done()
+
+	ticker := time.NewTicker(w.config.FsyncEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := w.Sync()
+			if err != nil {
+				fmt.Printf("error syncing logfile %s: %v\n", w.logFileNameFullPath, err)
+				w.recordError(err)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// cleanupPeriodically runs cleanup every CleanupInterval until the
+// FileWriter is closed.
+func (w *FileWriter) cleanupPeriodically() {
+	defer w.backgroundWG.Done()
+
+	ticker := time.NewTicker(w.config.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			err := w.cleanup()
+			w.mu.Unlock()
+			if err != nil {
+				fmt.Printf("error cleaning up log dir %s: %v\n", w.config.LogDirName, err)
+				w.recordError(err)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Reopen closes and reopens the current log file.  If the log file has been
+// moved or removed a new one is created in its place.
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed.Load() {
+		return os.ErrClosed
+	}
+
+	w.flushBuffer()
+	err := w.logFile.Close()
+	if err != nil {
+		return err
+	}
+
+	// ensure the logdir exists
+	err = w.makeLogDir()
+	if err != nil {
+		return err
+	}
+
+	err = w.openLogFile()
+	if err != nil {
+		return err
+	}
+
+	info, err := w.logFile.Stat()
+	if err != nil {
+		return err
+	}
+	w.byteCounter = info.Size()
+
+	return nil
+}
+
+// handleSIGHUP reopens the log file whenever we receive SIGHUP.  The signal
+// handler is removed when the FileWriter is closed.
+func (w *FileWriter) handleSIGHUP() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	w.backgroundWG.Add(1)
+	go func() {
+		defer w.backgroundWG.Done()
+		defer signal.Stop(sigCh)
+		for {
+			select {
+			case <-sigCh:
+				err := w.Reopen()
+				if err != nil {
+					fmt.Printf("error reopening logfile %s: %v\n", w.logFileNameFullPath, err)
+					w.recordError(err)
+				}
+			case <-w.done:
+				return
+			}
+		}
+	}()
+}
+
+// clock returns the Clock of the writer.
+func (w *FileWriter) clock() Clock {
+	if w.config.Clock == nil {
+		return SystemClock
+	}
+	return w.config.Clock
+}
+
+// rotateOnSchedule rotates the log file when it has been open for
+// RotateInterval or when RotateSchedule says so.  Note that you MUST call
+// w.backgroundWG.Add(1) before starting this goroutine.
+func (w *FileWriter) rotateOnSchedule() {
+	defer w.backgroundWG.Done()
+
+	for {
+		w.mu.Lock()
+		next := w.nextRotation()
+		w.mu.Unlock()
+
+		timer := w.clock().NewTimer(next.Sub(w.clock().Now()))
+		select {
+		case <-timer.C():
+		case <-w.done:
+			timer.Stop()
+			return
+		}
+
+		w.mu.Lock()
+		now := w.clock().Now()
+		switch {
+		case w.config.RotateSchedule != nil && !now.Before(w.scheduledRotation):
+			if w.byteCounter > w.headerSize {
+				err := w.rotateTo(w.archiveName(w.config.RotateSchedule.PeriodName(w.scheduledRotation)))
+				if err != nil {
+					fmt.Printf("error rotating logfile %s: %v\n", w.logFileNameFullPath, err)
+					w.recordError(err)
+				}
+			}
+			w.scheduledRotation = w.config.RotateSchedule.Next(now)
+
+		case w.config.RotateInterval > 0 && !now.Before(w.openedAt.Add(w.config.RotateInterval)):
+			if w.byteCounter > w.headerSize {
+				err := w.rotate()
+				if err != nil {
+					fmt.Printf("error rotating logfile %s: %v\n", w.logFileNameFullPath, err)
+					w.recordError(err)
+				}
+			} else {
+				// nothing to rotate, start a new period
+				w.openedAt = now
+			}
+		}
+		w.mu.Unlock()
+	}
+}
+
+// nextRotation returns the next time a time based rotation is due.  This
+// assumes that w.mu is locked.
+func (w *FileWriter) nextRotation() time.Time {
+	var next time.Time
+	if w.config.RotateInterval > 0 {
+		next = w.openedAt.Add(w.config.RotateInterval)
+	}
+	if w.config.RotateSchedule != nil && (next.IsZero() || w.scheduledRotation.Before(next)) {
+		next = w.scheduledRotation
+	}
+	return next
+}
+
 // initialize should only be called from NewFileWriter and assumes that
 // w.byteCounter is already 0.
 func (w *FileWriter) initialize() error {
 	// ensure the logdir exists
-	err := os.MkdirAll(w.config.LogDirName, logDirPermissions)
+	err := w.makeLogDir()
+	if err != nil {
+		return err
+	}
+
+	// make sure nobody else is writing to the log file before we touch
+	// anything
+	if w.config.LockLogDir && w.onOSFS() {
+		err = w.acquireLock()
+		if err != nil {
+			return err
+		}
+	}
+
+	// redo the compressions interrupted by a crash before cleanup finds
+	// their leftovers
+	err = w.recoverCompressions()
 	if err != nil {
 		return err
 	}
@@ -119,11 +662,11 @@ func (w *FileWriter) initialize() error {
 	}
 
 	// check if a logfile exists
-	info, err := os.Stat(w.logFileNameFullPath)
+	info, err := w.fs().Stat(w.logFileNameFullPath)
 	if err == nil {
 		// if the size is above the threshold we archive it
 		if info.Size() >= w.config.MaxLogFileSizeBytes {
-			err = w.archive(w.logFileNameFullPath)
+			err = w.archive(w.archiveName(w.clock().Now().Format(archiveNameFormat)))
 			if err != nil {
 				return err
 			}
@@ -136,23 +679,125 @@ func (w *FileWriter) initialize() error {
 	}
 
 	// this will create the file if it doesn't exist and keep appending to it if it does
-	w.logFile, err = os.OpenFile(w.logFileNameFullPath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, logFilePermissions)
+	return w.openLogFile()
+}
+
+// openLogFile opens the log file for appending, creating it if it doesn't
+// exist.
+func (w *FileWriter) openLogFile() error {
+	f, err := w.fs().OpenFile(w.logFileNameFullPath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, w.filePermissions())
 	if err != nil {
 		return err
 	}
+	w.fixOwnership(w.logFileNameFullPath)
+	w.logFile = f
+	w.openedAt = w.clock().Now()
+	w.resetBuffer()
+
+	w.headerSize = 0
+	if w.config.Header != nil {
+		err = w.writeHeader()
+		if err != nil {
+			fmt.Printf("error writing header to logfile %s: %v\n", w.logFileNameFullPath, err)
+			w.recordError(err)
+		}
+	}
+
+	if w.config.CurrentSymlink != "" && w.onOSFS() {
+		err = w.updateSymlink()
+		if err != nil {
+			fmt.Printf("error updating symlink %s: %v\n", w.config.CurrentSymlink, err)
+		}
+	}
 
 	return nil
 }
 
-// cleanup performs housekeeping.
+// writeHeader writes the Header to the log file if it is empty.
+func (w *FileWriter) writeHeader() error {
+	info, err := w.logFile.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > 0 {
+		return nil
+	}
+
+	n, err := w.logFile.Write(w.config.Header())
+	w.byteCounter += int64(n)
+	w.headerSize = int64(n)
+	return err
+}
+
+// updateSymlink points CurrentSymlink at the active log file.  The link is
+// replaced atomically so readers never see it missing.
+func (w *FileWriter) updateSymlink() error {
+	link := w.config.CurrentSymlink
+	if !filepath.IsAbs(link) {
+		link = filepath.Join(w.config.LogDirName, link)
+	}
+
+	// use a relative target when the link lives next to the log file so the
+	// log directory can be moved around
+	target, err := filepath.Abs(w.logFileNameFullPath)
+	if err != nil {
+		return err
+	}
+	if filepath.Dir(link) == filepath.Dir(w.logFileNameFullPath) {
+		target = filepath.Base(w.logFileNameFullPath)
+	}
+
+	tmp := link + "." + processingExtenstion
+	os.Remove(tmp)
+	err = os.Symlink(target, tmp)
+	if err != nil {
+		return err
+	}
+	if w.config.Chown {
+		err = os.Lchown(tmp, w.config.UID, w.config.GID)
+		if err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp, link)
+}
+
+// cleanup performs housekeeping.  It runs at startup, before the log file
+// is opened, and every CleanupInterval with w.mu locked.
 func (w *FileWriter) cleanup() error {
+	// make room before we start compressing anything
+	_, err := w.enforceRetention()
+	if err != nil {
+		return err
+	}
+
 	// check if we have logfiles that are too old
-	dirEnts, err := os.ReadDir(w.config.LogDirName)
+	dirEnts, err := w.fs().ReadDir(w.config.LogDirName)
 	if err != nil {
 		return err
 	}
 
 	for _, dirEnt := range dirEnts {
+		// leave directories and symlinks such as CurrentSymlink alone
+		if dirEnt.IsDir() || dirEnt.Type()&os.ModeSymlink != 0 {
+			continue
+		}
+
+		// removing the lock file would break locking for whoever holds it
+		if dirEnt.Name() == w.config.LogFileName+"."+lockExtension {
+			continue
+		}
+
+		// leave the files of other writers sharing the directory alone
+		if dirEnt.Name() != w.config.LogFileName && !strings.HasPrefix(dirEnt.Name(), w.archivePrefix()) {
+			continue
+		}
+
+		// once the log file is open it is only removed by rotation
+		if dirEnt.Name() == w.config.LogFileName && w.logFile != nil {
+			continue
+		}
+
 		info, err := dirEnt.Info()
 		if err != nil {
 			continue
@@ -161,20 +806,21 @@ func (w *FileWriter) cleanup() error {
 		fullPath := filepath.Join(w.config.LogDirName, info.Name())
 
 		// if the age is greater than MaxDaysToKeep we delete the file
-		if w.config.MaxTimeTimeToKeep > 0 && time.Since(info.ModTime()) > w.config.MaxTimeTimeToKeep {
-			err := os.Remove(fullPath)
+		if w.config.RetentionPolicy == nil && w.config.MaxTimeTimeToKeep > 0 && w.clock().Now().Sub(info.ModTime()) > w.config.MaxTimeTimeToKeep {
+			err := w.fs().Remove(fullPath)
 			if err != nil {
 				fmt.Printf("error removing %s: %v\n", fullPath, err)
 			}
-			fmt.Printf("%s removed %d\n", fullPath, time.Since(info.ModTime()))
+			fmt.Printf("%s removed %d\n", fullPath, w.clock().Now().Sub(info.ModTime()))
 			continue
 		}
 
-		// if we find an uncompressed archive file we compress it
-		if strings.HasSuffix(info.Name(), "log") && info.Name() != w.config.LogFileName {
+		// if we find an uncompressed archive file we compress it.  Once we
+		// are running uncompressed archives are left alone unless Compress
+		// is set.
+		if strings.HasSuffix(info.Name(), "log") && info.Name() != w.config.LogFileName && (w.logFile == nil || w.config.Compress) {
 			fmt.Printf("compress %s\n", info.Name())
-			w.compressorWG.Add(1)
-			go w.compress(fullPath)
+			w.startCompress(fullPath)
 			continue
 		}
 	}
@@ -182,9 +828,79 @@ func (w *FileWriter) cleanup() error {
 	return nil
 }
 
+// recoverCompressions deletes the temporary files left behind by
+// compressions that were interrupted, eg. by a crash, and compresses their
+// sources again.
+func (w *FileWriter) recoverCompressions() error {
+	dirEnts, err := w.fs().ReadDir(w.config.LogDirName)
+	if err != nil {
+		return err
+	}
+
+	for _, dirEnt := range dirEnts {
+		name := dirEnt.Name()
+		if dirEnt.IsDir() || !strings.HasPrefix(name, w.archivePrefix()) || !strings.HasSuffix(name, "."+processingExtenstion) {
+			continue
+		}
+
+		fullPath := filepath.Join(w.config.LogDirName, name)
+		err := w.fs().Remove(fullPath)
+		if err != nil {
+			fmt.Printf("error removing %s: %v\n", fullPath, err)
+			continue
+		}
+		fmt.Printf("%s removed after interrupted compression\n", fullPath)
+
+		source := w.compressionSource(fullPath)
+		if source == "" {
+			continue
+		}
+		fmt.Printf("compress %s\n", filepath.Base(source))
+		w.startCompress(source)
+	}
+	return nil
+}
+
+// compressionSource returns the file that was being compressed into the
+// temporary file fn, or an empty string if it no longer exists.
+func (w *FileWriter) compressionSource(fn string) string {
+	source := strings.TrimSuffix(fn, "."+processingExtenstion)
+	for _, ext := range []string{ageExtension, aesGCMExtension, compressedExtension, zstdExtension} {
+		source = strings.TrimSuffix(source, "."+ext)
+	}
+	info, err := w.fs().Stat(source)
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return source
+}
+
+// startCompress compresses fn in the background unless it is already being
+// compressed.  The file counts towards the compression backlog until it has
+// been compressed, including while it waits for a compression slot.
+func (w *FileWriter) startCompress(fn string) {
+	w.compressingMu.Lock()
+	defer w.compressingMu.Unlock()
+
+	if w.compressing[fn] {
+		return
+	}
+	w.compressing[fn] = true
+	w.stats.compressing.Add(1)
+	w.compressorWG.Add(1)
+	go w.compress(fn)
+}
+
 // rotate the log file.  This assumes that the w.mu is locked.
 func (w *FileWriter) rotate() error {
+	return w.rotateTo(w.archiveName(w.clock().Now().Format(archiveNameFormat)))
+}
+
+// rotateTo rotates the log file, archiving it under newName.  This assumes
+// that the w.mu is locked.
+func (w *FileWriter) rotateTo(newName string) error {
 	if w.logFile != nil {
+		w.flushBuffer()
 		err := w.logFile.Close()
 		if err != nil {
 			return err
@@ -194,94 +910,166 @@ func (w *FileWriter) rotate() error {
 	}
 
 	// ensure the logdir exists
-	err := os.MkdirAll(w.config.LogDirName, logDirPermissions)
+	err := w.makeLogDir()
 	if err != nil {
 		return err
 	}
 
-	w.archive(w.logFileNameFullPath)
+	w.archive(newName)
 
-	// Open logfile for append.
-	w.logFile, err = os.OpenFile(w.logFileNameFullPath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, logFilePermissions)
+	_, err = w.enforceRetention()
 	if err != nil {
-		return err
+		fmt.Printf("error enforcing log retention: %v\n", err)
 	}
 
+	// Open logfile for append.
 	w.byteCounter = 0
-
-	return nil
+	return w.openLogFile()
 }
 
-// archive renames and potentially postprocesses log files
-func (w *FileWriter) archive(fn string) error {
-	newName := archiveName(w.logFileNameFullPath)
-	err := os.Rename(w.logFileNameFullPath, newName)
+// archive renames the log file to newName and potentially postprocesses it
+func (w *FileWriter) archive(newName string) error {
+	err := w.fs().Rename(w.logFileNameFullPath, newName)
 	if err != nil {
 		return err
 	}
 
 	if w.config.Compress {
+		if w.config.OnRotate != nil {
+			w.compressingMu.Lock()
+			w.rotated[newName] = true
+			w.compressingMu.Unlock()
+		}
+		w.startCompress(newName)
+		return nil
+	}
+
+	if w.config.OnArchived != nil || w.config.OnRotate != nil {
 		w.compressorWG.Add(1)
-		go w.compress(newName)
+		go func() {
+			defer w.compressorWG.Done()
+			w.archived(newName, true)
+		}()
 	}
 
 	return nil
 }
 
+// archived runs the OnRotate hook, if the archive was made by a rotation,
+// and the OnArchived hook for a finished archive.
+func (w *FileWriter) archived(fn string, rotated bool) {
+	if rotated && w.config.OnRotate != nil {
+		w.config.OnRotate(fn, w.logFileNameFullPath)
+	}
+
+	if w.config.OnArchived == nil {
+		return
+	}
+
+	err := w.config.OnArchived(fn)
+	if err != nil {
+		lg.Errorw("archive hook failed", "file", fn, "err", err)
+		return
+	}
+
+	if w.config.RemoveAfterOnArchived {
+		err = w.fs().Remove(fn)
+		if err != nil {
+			lg.Errorw("failed to remove archive", "file", fn, "err", err)
+		}
+	}
+}
+
 // compress the named file.  Note that before you call this function you MUST
-// call w.compressorWG.Add(1)
+// call w.compressorWG.Add(1), use startCompress.  It waits for one of the
+// compressSlots so no more than CompressionConcurrency files are compressed
+// at the same time.
 func (w *FileWriter) compress(fn string) {
 	defer w.compressorWG.Done()
+	defer func() {
+		w.compressingMu.Lock()
+		delete(w.compressing, fn)
+		delete(w.rotated, fn)
+		w.stats.compressing.Add(-1)
+		w.compressingMu.Unlock()
+	}()
+
+	w.compressSlots <- struct{}{}
+	defer func() { <-w.compressSlots }()
 
-	in, err := os.Open(fn)
+	in, err := w.fs().OpenFile(fn, os.O_RDONLY, 0)
 	if err != nil {
 		fmt.Printf("failed to open input file for compression file = %s: %v", fn, err)
 		return
 	}
 	defer in.Close()
 
-	compressedFilename := fn + "." + compressedExtension
+	compressedFilename := fn + "." + w.compressedExtension()
 	tempFilename := compressedFilename + "." + processingExtenstion
 
-	out, err := os.OpenFile(tempFilename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, logFilePermissions)
+	out, err := w.fs().OpenFile(tempFilename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, w.filePermissions())
 	if err != nil {
 		lg.Errorw("failed to open output file for compression", "file", tempFilename, "err", err)
+		w.recordError(err)
 		return
 	}
+	w.fixOwnership(tempFilename)
 	defer out.Close()
 
-	zipper := gzip.NewWriter(out)
-	defer zipper.Close()
+	// the compressed stream is encrypted on its way to the file if
+	// encryption is enabled
+	var encrypter io.WriteCloser
+	compressed := io.Writer(out)
+	if w.config.Encryption.enabled() {
+		encrypter, err = w.config.Encryption.newWriter(out)
+		if err != nil {
+			lg.Errorw("failed to create encrypter", "file", tempFilename, "err", err)
+			w.recordError(err)
+			w.fs().Remove(tempFilename)
+			return
+		}
+		compressed = encrypter
+	}
+
+	zipper, err := w.config.CompressionCodec.newWriter(compressed)
+	if err != nil {
+		lg.Errorw("failed to create compressor", "file", tempFilename, "err", err)
+		w.recordError(err)
+		w.fs().Remove(tempFilename)
+		return
+	}
 
 	n, err := io.Copy(zipper, in)
+	if err == nil {
+		// flush everything before we give the file its final name
+		err = zipper.Close()
+	}
+	if err == nil && encrypter != nil {
+		err = encrypter.Close()
+	}
 	if err != nil {
 		lg.Errorf("failed to compress %s: %v", tempFilename, err)
-		os.Remove(tempFilename)
+		w.recordError(err)
+		w.fs().Remove(tempFilename)
 		return
 	}
 
-	err = os.Rename(tempFilename, compressedFilename)
+	err = w.fs().Rename(tempFilename, compressedFilename)
 	if err != nil {
 		lg.Errorw("failed to rename processed file", "fromName", tempFilename, "toName", compressedFilename, "err", err)
+		w.recordError(err)
+		return
 	}
 
-	err = os.Remove(fn)
+	err = w.fs().Remove(fn)
 	if err != nil {
 		lg.Errorw("failed to remove processed log file", "filename", fn, "err", err)
 	}
 
 	lg.Infow("compressed", "file", compressedFilename, "originalSize", n)
-}
-
-// archiveName borrows the formatting from https://github.com/natefinch/lumberjack/
-// for compatibility
-func archiveName(current string) string {
-	dir := filepath.Dir(current)
-	filename := filepath.Base(current)
-	ext := filepath.Ext(current)
-	prefix := filename[:len(filename)-len(ext)]
-
-	timestamp := time.Now().Format(archiveNameFormat)
 
-	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", prefix, timestamp, ext))
+	w.compressingMu.Lock()
+	rotated := w.rotated[fn]
+	w.compressingMu.Unlock()
+	w.archived(compressedFilename, rotated)
 }
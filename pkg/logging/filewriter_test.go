@@ -2,12 +2,19 @@ package logging
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestFileWriter(t *testing.T) {
@@ -16,13 +23,14 @@ func TestFileWriter(t *testing.T) {
 	assert.NotEmpty(t, dir)
 	defer os.RemoveAll(dir)
 
-	fw := NewFileWriter(FileWriterConfig{
+	fw, err := NewFileWriter(FileWriterConfig{
 		LogDirName:          dir,
 		LogFileName:         "logfile.log",
 		Compress:            true,
 		MaxTimeTimeToKeep:   1,
 		MaxLogFileSizeBytes: 1000,
-	})
+	}, withMinSize(1))
+	assert.NoError(t, err)
 
 	// generate some log files
 	for i := 0; i < 120; i++ {
@@ -53,3 +61,674 @@ func randomString(n int) string {
 	}
 	return string(b)
 }
+
+func TestFileWriterReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:  dir,
+		LogFileName: "logfile.log",
+	})
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	_, err = fw.Write([]byte("before\n"))
+	assert.NoError(t, err)
+
+	// simulate logrotate moving the file out of the way
+	logFile := filepath.Join(dir, "logfile.log")
+	assert.NoError(t, os.Rename(logFile, logFile+".1"))
+	assert.NoError(t, fw.Reopen())
+
+	_, err = fw.Write([]byte("after\n"))
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(logFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "after\n", string(data))
+
+	data, err = os.ReadFile(logFile + ".1")
+	assert.NoError(t, err)
+	assert.Equal(t, "before\n", string(data))
+
+	assert.NoError(t, fw.Close())
+	assert.ErrorIs(t, fw.Reopen(), os.ErrClosed)
+}
+
+func TestFileWriterRotateInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:     dir,
+		LogFileName:    "logfile.log",
+		RotateInterval: 50 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	_, err = fw.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+
+	// the file should be rotated even though it is far below the size limit
+	assert.Eventually(t, func() bool {
+		files, err := os.ReadDir(dir)
+		return err == nil && len(files) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// empty files are not rotated
+	time.Sleep(150 * time.Millisecond)
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+}
+
+func TestFileWriterRotateSchedule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:     dir,
+		LogFileName:    "logfile.log",
+		RotateSchedule: EveryUTC(100 * time.Millisecond),
+	})
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	_, err = fw.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+
+	// the archive is named after the period
+	assert.Eventually(t, func() bool {
+		matches, err := filepath.Glob(filepath.Join(dir, "logfile-????-??-??T??-??.log"))
+		return err == nil && len(matches) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestFileWriterMaxTotalSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// create some old archives
+	for i := 0; i < 5; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("logfile-2020-01-0%dT00-00-00.00000.log.gz", i+1))
+		assert.NoError(t, os.WriteFile(name, make([]byte, 1000), 0644))
+		modTime := time.Now().Add(time.Duration(i-10) * time.Hour)
+		assert.NoError(t, os.Chtimes(name, modTime, modTime))
+	}
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:        dir,
+		LogFileName:       "logfile.log",
+		MaxTotalSizeBytes: 2500,
+	})
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	// only the two newest archives fit
+	files, err := filepath.Glob(filepath.Join(dir, "logfile-*"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "logfile-2020-01-04T00-00-00.00000.log.gz"),
+		filepath.Join(dir, "logfile-2020-01-05T00-00-00.00000.log.gz"),
+	}, files)
+}
+
+func TestFileWriterMaxArchivedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		MaxLogFileSizeBytes: 100,
+		MaxArchivedFiles:    2,
+	}, withMinSize(1))
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	for i := 0; i < 10; i++ {
+		_, err := fw.Write([]byte(randomString(60)))
+		assert.NoError(t, err)
+		// make sure the archives get distinct names and modification times
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "logfile-*"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+}
+
+func TestFileWriterPurge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:  dir,
+		LogFileName: "logfile.log",
+	})
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	// archives that are 1 to 5 days old
+	for i := 0; i < 5; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("logfile-2020-01-0%dT00-00-00.00000.log.gz", i+1))
+		assert.NoError(t, os.WriteFile(name, make([]byte, 1000), 0644))
+		modTime := time.Now().Add(time.Duration(i-5) * 24 * time.Hour)
+		assert.NoError(t, os.Chtimes(name, modTime, modTime))
+	}
+
+	removed, err := fw.Purge(60 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, removed)
+
+	// the limits of another config apply too
+	removed, err = PurgeArchives(FileWriterConfig{
+		LogDirName:       dir,
+		LogFileName:      "logfile.log",
+		MaxArchivedFiles: 1,
+	}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	files, err := filepath.Glob(filepath.Join(dir, "logfile-*"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "logfile-2020-01-05T00-00-00.00000.log.gz")}, files)
+}
+
+// undeletableFS is a stalledFS where removing the file named undeletable
+// fails.
+type undeletableFS struct {
+	*stalledFS
+	undeletable string
+}
+
+func (f *undeletableFS) Remove(name string) error {
+	if filepath.Base(name) == f.undeletable {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrPermission}
+	}
+	return f.stalledFS.Remove(name)
+}
+
+func TestFileWriterPurgeCount(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "filewriter-purge-does-not-exist")
+	fs := &undeletableFS{
+		stalledFS: &stalledFS{
+			memFS:   newMemFS(),
+			logFile: filepath.Join(dir, "logfile.log"),
+			release: make(chan struct{}),
+		},
+		undeletable: "logfile-2020-01-02T00-00-00.00000.log.gz",
+	}
+	clock := NewFakeClock(time.Now())
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		Compress:            true,
+		MaxLogFileSizeBytes: 10,
+		Clock:               clock,
+		FS:                  fs,
+	}, withMinSize(1))
+	assert.NoError(t, err)
+
+	for _, name := range []string{"logfile-2020-01-01T00-00-00.00000.log.gz", fs.undeletable} {
+		f, err := fs.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY, 0644)
+		assert.NoError(t, err)
+		f.Close()
+	}
+
+	// an archive which is still being compressed
+	_, err = fw.Write([]byte("rotated right away\n"))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return fw.Stats().CompressionBacklog == 1 }, 2*time.Second, time.Millisecond)
+
+	clock.Advance(2 * time.Hour)
+	removed, err := fw.Purge(time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	close(fs.release)
+	assert.NoError(t, fw.Close())
+
+	names := fs.names()
+	assert.Len(t, names, 3)
+	assert.Contains(t, names, filepath.Join(dir, fs.undeletable))
+}
+
+func TestFileWriterCleanupInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:        dir,
+		LogFileName:       "logfile.log",
+		MaxTimeTimeToKeep: time.Hour,
+		CleanupInterval:   10 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	// an archive that expires while we are running
+	name := filepath.Join(dir, "logfile-2020-01-01T00-00-00.00000.log.gz")
+	assert.NoError(t, os.WriteFile(name, make([]byte, 1000), 0644))
+	modTime := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(name, modTime, modTime))
+
+	// the active log file is left alone however old it is
+	logFile := filepath.Join(dir, "logfile.log")
+	assert.NoError(t, os.Chtimes(logFile, modTime, modTime))
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(name)
+		return os.IsNotExist(err)
+	}, 2*time.Second, 10*time.Millisecond)
+
+	_, err = os.Stat(logFile)
+	assert.NoError(t, err)
+}
+
+func TestFileWriterZstd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		Compress:            true,
+		CompressionCodec:    CodecZstd,
+		MaxLogFileSizeBytes: 100,
+	}, withMinSize(1))
+	assert.NoError(t, err)
+
+	line := randomString(150)
+	_, err = fw.Write([]byte(line))
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "logfile-*.log.zst"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	f, err := os.Open(files[0])
+	assert.NoError(t, err)
+	defer f.Close()
+
+	dec, err := zstd.NewReader(f)
+	assert.NoError(t, err)
+	defer dec.Close()
+
+	data, err := io.ReadAll(dec)
+	assert.NoError(t, err)
+	assert.Equal(t, line, string(data))
+}
+
+func TestFileWriterOnArchived(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		mu       sync.Mutex
+		uploaded []string
+	)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		Compress:            true,
+		MaxLogFileSizeBytes: 100,
+		OnArchived: func(path string) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			_, err := os.Stat(path)
+			assert.NoError(t, err)
+			uploaded = append(uploaded, filepath.Base(path))
+			return nil
+		},
+		RemoveAfterOnArchived: true,
+	}, withMinSize(1))
+	assert.NoError(t, err)
+
+	_, err = fw.Write([]byte(randomString(150)))
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Close())
+
+	assert.Len(t, uploaded, 1)
+	assert.Regexp(t, `^logfile-.*\.log\.gz$`, uploaded[0])
+
+	// the archive has been removed after the upload
+	files, err := filepath.Glob(filepath.Join(dir, "logfile-*"))
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestFileWriterOnRotate(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "filewriter-*")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		// leftover archives compressed at startup are not rotations
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "logfile-2020-01-01T00-00-00.00000.log"), []byte("old"), 0644))
+
+		var (
+			mu      sync.Mutex
+			rotated [][2]string
+		)
+
+		fw, err := NewFileWriter(FileWriterConfig{
+			LogDirName:          dir,
+			LogFileName:         "logfile.log",
+			Compress:            compress,
+			MaxLogFileSizeBytes: 100,
+			OnRotate: func(old, new string) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				_, err := os.Stat(old)
+				assert.NoError(t, err)
+				rotated = append(rotated, [2]string{filepath.Base(old), filepath.Base(new)})
+			},
+		}, withMinSize(1))
+		assert.NoError(t, err)
+
+		_, err = fw.Write([]byte(randomString(150)))
+		assert.NoError(t, err)
+		assert.NoError(t, fw.Close())
+
+		pattern := `^logfile-.*\.log$`
+		if compress {
+			pattern = `^logfile-.*\.log\.gz$`
+		}
+		assert.Len(t, rotated, 1)
+		assert.Regexp(t, pattern, rotated[0][0])
+		assert.NotContains(t, rotated[0][0], "2020")
+		assert.Equal(t, "logfile.log", rotated[0][1])
+	}
+}
+
+func TestFileWriterCompressionConcurrency(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// a backlog of uncompressed archives
+	for i := 0; i < 8; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("logfile-2020-01-0%dT00-00-00.00000.log", i+1))
+		assert.NoError(t, os.WriteFile(name, []byte(randomString(1000)), 0644))
+	}
+
+	var (
+		mu               sync.Mutex
+		running, maxSeen int
+	)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:             dir,
+		LogFileName:            "logfile.log",
+		Compress:               true,
+		CompressionConcurrency: 2,
+		OnArchived: func(path string) error {
+			mu.Lock()
+			running++
+			if running > maxSeen {
+				maxSeen = running
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "logfile-*.log.gz"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 8)
+	assert.LessOrEqual(t, maxSeen, 2)
+}
+
+func TestFileWriterRecoverCompression(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// a compression interrupted by a crash
+	source := filepath.Join(dir, "logfile-2020-01-01T00-00-00.00000.log")
+	line := randomString(1000)
+	assert.NoError(t, os.WriteFile(source, []byte(line), 0644))
+	assert.NoError(t, os.WriteFile(source+".gz.processing", []byte("partial"), 0644))
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:  dir,
+		LogFileName: "logfile.log",
+		Compress:    true,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "logfile-*"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{source + ".gz"}, files)
+
+	r, err := OpenArchive(source + ".gz")
+	assert.NoError(t, err)
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, line, string(data))
+}
+
+func TestFileWriterSync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:  dir,
+		LogFileName: "logfile.log",
+		FsyncEvery:  10 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	_, err = fw.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Sync())
+
+	// give the periodic sync a chance to run
+	time.Sleep(30 * time.Millisecond)
+
+	assert.NoError(t, fw.Close())
+	assert.ErrorIs(t, fw.Sync(), os.ErrClosed)
+}
+
+func TestFileWriterAsync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:           dir,
+		LogFileName:          "logfile.log",
+		Async:                true,
+		AsyncBufferSizeBytes: 64,
+	})
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				n, err := fw.Write([]byte("0123456789\n"))
+				assert.NoError(t, err)
+				assert.Equal(t, 11, n)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Close must write everything that is still buffered
+	assert.NoError(t, fw.Close())
+
+	data, err := os.ReadFile(filepath.Join(dir, "logfile.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, 400*11, len(data))
+
+	_, err = fw.Write([]byte("closed\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestFileWriterDropOnOverload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:           dir,
+		LogFileName:          "logfile.log",
+		DropOnOverload:       true,
+		AsyncBufferSizeBytes: 100,
+	})
+	assert.NoError(t, err)
+
+	// hold the file lock so the flusher can't empty the buffer
+	fw.mu.Lock()
+	l := zap.New(newFileWriterCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), fw, zapcore.DebugLevel))
+	for i := 0; i < 10; i++ {
+		l.Warn("this entry is long enough to fill up the buffer quickly")
+	}
+	_, err = fw.Write([]byte(randomString(100)))
+	assert.NoError(t, err)
+	fw.mu.Unlock()
+
+	dropped := fw.Dropped()
+	assert.GreaterOrEqual(t, dropped.Total, uint64(9))
+	assert.Equal(t, dropped.Total-1, dropped.ByLevel[zapcore.WarnLevel])
+
+	assert.NoError(t, fw.Close())
+}
+
+func TestFileWriterCurrentSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		MaxLogFileSizeBytes: 100,
+		Compress:            true,
+		CurrentSymlink:      "current.log",
+	}, withMinSize(1))
+	assert.NoError(t, err)
+
+	link := filepath.Join(dir, "current.log")
+	target, err := os.Readlink(link)
+	assert.NoError(t, err)
+	assert.Equal(t, "logfile.log", target)
+
+	// rotate and make sure the link still points to the active file
+	_, err = fw.Write([]byte(randomString(150)))
+	assert.NoError(t, err)
+	_, err = fw.Write([]byte("after rotation\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Close())
+
+	data, err := os.ReadFile(link)
+	assert.NoError(t, err)
+	assert.Equal(t, "after rotation\n", string(data))
+}
+
+func TestFileWriterLockLogDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := FileWriterConfig{
+		LogDirName:  dir,
+		LogFileName: "logfile.log",
+		LockLogDir:  true,
+	}
+
+	fw, err := newFileWriter(c)
+	assert.NoError(t, err)
+
+	// flock locks are per open file so a second writer in the same process
+	// is refused just like another process would be
+	_, err = newFileWriter(c)
+	var lockedErr *LockedError
+	assert.ErrorAs(t, err, &lockedErr)
+	assert.Equal(t, os.Getpid(), lockedErr.PID)
+
+	assert.NoError(t, fw.Close())
+
+	// the lock is released on close
+	fw, err = newFileWriter(c)
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Close())
+}
+
+func TestNewFileWriterOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{LogDirName: dir},
+		WithMaxSize(1000),
+		WithCompression(CodecZstd),
+		WithRetention(time.Hour, 3),
+		WithMaxTotalSize(1<<20),
+		withMinSize(1))
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	assert.Equal(t, int64(1000), fw.config.MaxLogFileSizeBytes)
+	assert.True(t, fw.config.Compress)
+	assert.Equal(t, CodecZstd, fw.config.CompressionCodec)
+	assert.Equal(t, time.Hour, fw.config.MaxTimeTimeToKeep)
+	assert.Equal(t, 3, fw.config.MaxArchivedFiles)
+	assert.Equal(t, int64(1<<20), fw.config.MaxTotalSizeBytes)
+
+	// initialization errors are returned
+	notDir := filepath.Join(dir, "log.log")
+	_, err = NewFileWriter(FileWriterConfig{LogDirName: notDir})
+	assert.Error(t, err)
+}
+
+func TestFileWriterConfigValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, FileWriterConfig{}.Validate())
+	assert.NoError(t, FileWriterConfig{LogDirName: dir, MaxLogFileSizeBytes: 100000}.Validate())
+	assert.ErrorIs(t, FileWriterConfig{MaxLogFileSizeBytes: -1}.Validate(), ErrInvalidMaxSize)
+	assert.ErrorIs(t, FileWriterConfig{MaxLogFileSizeBytes: 1000}.Validate(), ErrInvalidMaxSize)
+
+	file := filepath.Join(dir, "file")
+	assert.NoError(t, ioutil.WriteFile(file, nil, 0644))
+	assert.ErrorIs(t, FileWriterConfig{LogDirName: file}.Validate(), ErrBadDirectory)
+
+	_, err = NewFileWriter(FileWriterConfig{LogDirName: dir, MaxLogFileSizeBytes: 1000})
+	assert.ErrorIs(t, err, ErrInvalidMaxSize)
+	_, err = NewFileWriter(FileWriterConfig{LogDirName: filepath.Join(file, "log")})
+	assert.ErrorIs(t, err, ErrBadDirectory)
+}
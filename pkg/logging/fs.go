@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"io"
+	"os"
+)
+
+// FS is the filesystem a FileWriter writes its log files and archives to.
+// Implement it to back a FileWriter with custom storage.
+type FS interface {
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	ReadDir(name string) ([]os.DirEntry, error)
+	Stat(name string) (os.FileInfo, error)
+	MkdirAll(path string, perm os.FileMode) error
+}
+
+// File is a file opened by an FS.
+type File interface {
+	io.ReadWriteCloser
+	Stat() (os.FileInfo, error)
+	Sync() error
+}
+
+// OSFS is the filesystem of the operating system.
+var OSFS FS = osFS{}
+
+type osFS struct{}
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		// don't return a nil *os.File as a non-nil File
+		return nil, err
+	}
+	return f, nil
+}
+
+func (osFS) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFS) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// fs returns the filesystem of the writer.
+func (w *FileWriter) fs() FS {
+	if w.config.FS == nil {
+		return OSFS
+	}
+	return w.config.FS
+}
+
+// onOSFS returns true if the writer uses the filesystem of the operating
+// system, which symlinks, ownership and locking require.
+func (w *FileWriter) onOSFS() bool {
+	return w.config.FS == nil || w.config.FS == OSFS
+}
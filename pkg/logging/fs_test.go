@@ -0,0 +1,239 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memFS is an in-memory FS.
+type memFS struct {
+	mu    sync.Mutex
+	files map[string]*memData
+	dirs  map[string]bool
+}
+
+type memData struct {
+	data    []byte
+	modTime time.Time
+}
+
+func newMemFS() *memFS {
+	return &memFS{files: map[string]*memData{}, dirs: map[string]bool{}}
+}
+
+func (m *memFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	d, ok := m.files[name]
+	if !ok {
+		if flag&os.O_CREATE == 0 {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		d = &memData{modTime: time.Now()}
+		m.files[name] = d
+	}
+	if flag&os.O_TRUNC != 0 {
+		d.data = nil
+	}
+	return &memFile{fs: m, name: name, d: d, append: flag&os.O_APPEND != 0}, nil
+}
+
+func (m *memFS) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.files[filepath.Clean(oldpath)]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	delete(m.files, filepath.Clean(oldpath))
+	m.files[filepath.Clean(newpath)] = d
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.files[filepath.Clean(name)]; !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	delete(m.files, filepath.Clean(name))
+	return nil
+}
+
+func (m *memFS) ReadDir(name string) ([]os.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	if !m.dirs[name] {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: os.ErrNotExist}
+	}
+	var entries []os.DirEntry
+	for fn, d := range m.files {
+		if filepath.Dir(fn) == name {
+			entries = append(entries, iofs.FileInfoToDirEntry(memInfo{name: filepath.Base(fn), d: d}))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m *memFS) Stat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	if m.dirs[name] {
+		return memInfo{name: filepath.Base(name), dir: true}, nil
+	}
+	d, ok := m.files[name]
+	if !ok {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+	return memInfo{name: filepath.Base(name), d: d}, nil
+}
+
+func (m *memFS) MkdirAll(path string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for path = filepath.Clean(path); !m.dirs[path]; path = filepath.Dir(path) {
+		m.dirs[path] = true
+	}
+	return nil
+}
+
+// names returns the names of the files in the filesystem.
+func (m *memFS) names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var names []string
+	for fn := range m.files {
+		names = append(names, fn)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type memFile struct {
+	fs     *memFS
+	name   string
+	d      *memData
+	pos    int
+	append bool
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.pos >= len(f.d.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.d.data[f.pos:])
+	f.pos += n
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.append {
+		f.pos = len(f.d.data)
+	}
+	if end := f.pos + len(p); end > len(f.d.data) {
+		f.d.data = append(f.d.data, make([]byte, end-len(f.d.data))...)
+	}
+	copy(f.d.data[f.pos:], p)
+	f.pos += len(p)
+	f.d.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	return memInfo{name: filepath.Base(f.name), d: f.d}, nil
+}
+
+func (f *memFile) Sync() error  { return nil }
+func (f *memFile) Close() error { return nil }
+
+type memInfo struct {
+	name string
+	d    *memData
+	dir  bool
+}
+
+func (i memInfo) Name() string { return i.name }
+func (i memInfo) Size() int64 {
+	if i.d == nil {
+		return 0
+	}
+	return int64(len(i.d.data))
+}
+func (i memInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+func (i memInfo) ModTime() time.Time {
+	if i.d == nil {
+		return time.Time{}
+	}
+	return i.d.modTime
+}
+func (i memInfo) IsDir() bool      { return i.dir }
+func (i memInfo) Sys() interface{} { return nil }
+
+func TestFileWriterFS(t *testing.T) {
+	fs := newMemFS()
+	dir := filepath.Join(os.TempDir(), "filewriter-memfs-does-not-exist")
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		Compress:            true,
+		MaxLogFileSizeBytes: 100,
+		FS:                  fs,
+	}, withMinSize(1))
+	assert.NoError(t, err)
+	line := randomString(150)
+	_, err = fw.Write([]byte(line))
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Close())
+
+	// nothing was written to disk
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+
+	names := fs.names()
+	assert.Len(t, names, 2)
+	assert.True(t, strings.HasSuffix(names[0], ".log.gz"), names[0])
+	assert.Equal(t, filepath.Join(dir, "logfile.log"), names[1])
+
+	f, err := fs.OpenFile(names[0], os.O_RDONLY, 0)
+	assert.NoError(t, err)
+	zr, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Equal(t, line, string(data))
+}
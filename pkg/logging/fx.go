@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Module provides a *Logger to fx applications, along with its *zap.Logger
+// and *zap.SugaredLogger.  The logger is configured by a Config if the
+// application provides one and by ConfigFromEnv otherwise.  Its sinks are
+// closed when the application stops.
+var Module = fx.Module("logging",
+	fx.Provide(
+		provideLogger,
+		func(l *Logger) *zap.Logger { return l.Logger },
+		func(l *Logger) *zap.SugaredLogger { return l.Sugar() },
+	),
+)
+
+type loggerParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Config    *Config `optional:"true"`
+}
+
+func provideLogger(p loggerParams) (*Logger, error) {
+	c := ConfigFromEnv()
+	if p.Config != nil {
+		c = *p.Config
+	}
+
+	l, _, err := New(c)
+	if err != nil {
+		return nil, err
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return l.Close()
+		},
+	})
+	return l, nil
+}
@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Special field names recognized by Google Cloud Logging.  See
+// https://cloud.google.com/logging/docs/structured-logging
+const (
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	gcpTraceKey          = "logging.googleapis.com/trace"
+	gcpSpanIDKey         = "logging.googleapis.com/spanId"
+	gcpTraceSampledKey   = "logging.googleapis.com/trace_sampled"
+)
+
+// gcpEncoderConfig returns an encoder config producing the JSON format Cloud
+// Logging parses natively.  The source location is added by gcpCore.
+func gcpEncoderConfig() zapcore.EncoderConfig {
+	ec := zap.NewProductionEncoderConfig()
+	ec.TimeKey = "time"
+	ec.LevelKey = "severity"
+	ec.MessageKey = "message"
+	ec.CallerKey = ""
+	ec.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	ec.EncodeLevel = gcpLevelEncoder
+	return ec
+}
+
+// gcpLevelEncoder maps zap levels to Cloud Logging severities.
+func gcpLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch l {
+	case zapcore.DebugLevel:
+		enc.AppendString("DEBUG")
+	case zapcore.InfoLevel:
+		enc.AppendString("INFO")
+	case zapcore.WarnLevel:
+		enc.AppendString("WARNING")
+	case zapcore.ErrorLevel:
+		enc.AppendString("ERROR")
+	case zapcore.DPanicLevel:
+		enc.AppendString("CRITICAL")
+	case zapcore.PanicLevel:
+		enc.AppendString("ALERT")
+	case zapcore.FatalLevel:
+		enc.AppendString("EMERGENCY")
+	default:
+		enc.AppendString("DEFAULT")
+	}
+}
+
+// gcpCore adds the source location of the caller to each entry in the format
+// expected by Cloud Logging.
+type gcpCore struct {
+	zapcore.Core
+}
+
+// NewGCPCore returns a core that writes JSON formatted for Google Cloud
+// Logging to ws.
+func NewGCPCore(ws zapcore.WriteSyncer, enab zapcore.LevelEnabler) zapcore.Core {
+	return newGCPCore(gcpEncoderConfig(), ws, enab)
+}
+
+func newGCPCore(ec zapcore.EncoderConfig, ws zapcore.WriteSyncer, enab zapcore.LevelEnabler) zapcore.Core {
+	return gcpCore{zapcore.NewCore(zapcore.NewJSONEncoder(ec), ws, enab)}
+}
+
+func (c gcpCore) With(fields []zapcore.Field) zapcore.Core {
+	return gcpCore{c.Core.With(fields)}
+}
+
+func (c gcpCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c gcpCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Caller.Defined {
+		// make sure we don't append to the caller's slice
+		fields = append(fields[:len(fields):len(fields)], zap.Object(gcpSourceLocationKey, gcpSourceLocation(ent.Caller)))
+	}
+	return c.Core.Write(ent, fields)
+}
+
+type gcpSourceLocation zapcore.EntryCaller
+
+func (s gcpSourceLocation) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("file", s.File)
+	enc.AddString("line", fmt.Sprint(s.Line))
+	if s.Function != "" {
+		enc.AddString("function", s.Function)
+	}
+	return nil
+}
+
+// GCPTrace returns the fields Cloud Logging uses to correlate log entries
+// with traces.  Add them to request scoped loggers, eg.
+//
+//	lg := lg.With(logging.GCPTrace(projectID, traceID, spanID, sampled)...)
+func GCPTrace(projectID string, traceID string, spanID string, sampled bool) []zap.Field {
+	fields := []zap.Field{
+		zap.String(gcpTraceKey, fmt.Sprintf("projects/%s/traces/%s", projectID, traceID)),
+		zap.Bool(gcpTraceSampledKey, sampled),
+	}
+	if spanID != "" {
+		fields = append(fields, zap.String(gcpSpanIDKey, spanID))
+	}
+	return fields
+}
@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestGCPCore(t *testing.T) {
+	var buf bytes.Buffer
+	l := zap.New(NewGCPCore(zapcore.AddSync(&buf), zapcore.InfoLevel), zap.AddCaller())
+
+	l.With(GCPTrace("my-project", "abc123", "def456", true)...).Warn("hello", zap.Int("count", 1))
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+
+	assert.Equal(t, "WARNING", entry["severity"])
+	assert.Equal(t, "hello", entry["message"])
+	assert.Contains(t, entry, "time")
+	assert.NotContains(t, entry, "caller")
+	assert.Equal(t, "projects/my-project/traces/abc123", entry[gcpTraceKey])
+	assert.Equal(t, "def456", entry[gcpSpanIDKey])
+	assert.Equal(t, true, entry[gcpTraceSampledKey])
+	assert.Equal(t, float64(1), entry["count"])
+
+	sourceLocation, ok := entry[gcpSourceLocationKey].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Contains(t, sourceLocation["file"], "gcp_test.go")
+	assert.NotEmpty(t, sourceLocation["line"])
+	assert.Contains(t, sourceLocation["function"], "TestGCPCore")
+}
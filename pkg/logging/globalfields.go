@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// globalFields are the fields set with SetGlobalFields.
+	globalFields []zapcore.Field
+
+	// configuredCore is the core built by Configure, before the global
+	// fields are applied.
+	configuredCore zapcore.Core
+)
+
+// SetGlobalFields attaches fields, eg. the service name and environment, to
+// every entry logged by the package logger, in all sinks.  Loggers that have
+// already been obtained from Get() are affected as well.  The fields replace
+// those given to earlier calls, so SetGlobalFields() removes them.
+func SetGlobalFields(fields ...zap.Field) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	globalFields = append([]zapcore.Field(nil), fields...)
+	rootCore.swap(finishCore(configuredCore))
+}
+
+// fieldsFromEnv returns the fields given by LogFieldsEnvVar.
+func fieldsFromEnv() []zap.Field {
+	var fields []zap.Field
+	for _, kv := range strings.Split(getenv(LogFieldsEnvVar), ",") {
+		key, value, ok := strings.Cut(kv, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		fields = append(fields, zap.String(key, strings.TrimSpace(value)))
+	}
+	return fields
+}
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestGlobalFields(t *testing.T) {
+	defer Configure(Config{})
+	defer SetGlobalFields()
+
+	assert.NoError(t, Configure(Config{
+		RecentEntries: 10,
+		Fields:        []zap.Field{zap.String("service", "billing")},
+	}))
+
+	// loggers obtained before the fields are set get them too
+	log := Get().Named("test")
+	SetGlobalFields(zap.String("environment", "prod"))
+	log.Info("with fields")
+
+	SetGlobalFields()
+	log.Info("without global fields")
+
+	entries := Recent(2)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, map[string]interface{}{"service": "billing", "environment": "prod"}, entries[0].ContextMap())
+	assert.Equal(t, map[string]interface{}{"service": "billing"}, entries[1].ContextMap())
+}
+
+func TestFieldsFromEnv(t *testing.T) {
+	t.Setenv(LogFieldsEnvVar, "service=billing, environment=prod,invalid")
+	assert.Equal(t, []zap.Field{
+		zap.String("service", "billing"),
+		zap.String("environment", "prod"),
+	}, fieldsFromEnv())
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// restoreGlobals undoes ReplaceGlobals.  It is nil if the globals have
+	// not been replaced.
+	restoreGlobals func()
+	globalsMu      sync.Mutex
+)
+
+// ReplaceGlobals makes the package logger zap's global logger, see zap.L and
+// zap.S, and redirects the output of the standard library's log package to
+// it.  This is done when the package is initialized unless
+// LogNoGlobalsEnvVar is set.
+func ReplaceGlobals() {
+	globalsMu.Lock()
+	defer globalsMu.Unlock()
+
+	if restoreGlobals != nil {
+		return
+	}
+	restoreStdLog := zap.RedirectStdLog(logger)
+	restoreZap := zap.ReplaceGlobals(logger)
+	restoreGlobals = func() {
+		restoreZap()
+		restoreStdLog()
+	}
+}
+
+// RestoreGlobals undoes ReplaceGlobals, so libraries using this package
+// leave the global zap logger and the standard library's logger of the
+// application alone.  See also Config.NoGlobals.
+func RestoreGlobals() {
+	globalsMu.Lock()
+	defer globalsMu.Unlock()
+
+	if restoreGlobals != nil {
+		restoreGlobals()
+		restoreGlobals = nil
+	}
+}
+
+func noGlobalsFromEnv() bool {
+	noGlobals, _ := strconv.ParseBool(getenv(LogNoGlobalsEnvVar))
+	return noGlobals
+}
@@ -0,0 +1,25 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestRestoreGlobals(t *testing.T) {
+	defer ReplaceGlobals()
+	defer Configure(Config{})
+
+	assert.Same(t, Get(), zap.L())
+
+	assert.NoError(t, Configure(Config{NoGlobals: true}))
+	assert.NotSame(t, Get(), zap.L())
+
+	// Configure does not replace the globals again
+	assert.NoError(t, Configure(Config{}))
+	assert.NotSame(t, Get(), zap.L())
+
+	ReplaceGlobals()
+	assert.Same(t, Get(), zap.L())
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const fileHeaderMessage = "log file opened"
+
+// processStart approximates the start time of the process.
+var processStart = time.Now()
+
+// fileHeader returns a FileWriterConfig.Header writing a record encoded
+// with enc which describes the process and the logging configuration.
+func fileHeader(c Config, fw FileWriterConfig, enc zapcore.Encoder) func() []byte {
+	fields := append(enrichFields(),
+		zap.Time("start_time", processStart),
+		zap.Object("config", zapcore.ObjectMarshalerFunc(func(oe zapcore.ObjectEncoder) error {
+			oe.AddString("mode", c.Mode)
+			oe.AddString("encoding", c.Encoding)
+			oe.AddString("log_level", atomicLogLevel.Level().String())
+			oe.AddString("file", fw.LogFileName)
+			oe.AddInt64("max_file_size_bytes", fw.MaxLogFileSizeBytes)
+			oe.AddBool("compress", fw.Compress)
+			return nil
+		})),
+	)
+
+	return func() []byte {
+		ent := zapcore.Entry{
+			Level:   zapcore.InfoLevel,
+			Time:    time.Now(),
+			Message: fileHeaderMessage,
+		}
+		buf, err := enc.EncodeEntry(ent, fields)
+		if err != nil {
+			return nil
+		}
+		defer buf.Free()
+		return append([]byte(nil), buf.Bytes()...)
+	}
+}
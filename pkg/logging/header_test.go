@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileWriterHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// appending to an existing log file doesn't add a header
+	logFile := filepath.Join(dir, "logfile.log")
+	assert.NoError(t, os.WriteFile(logFile, []byte("old line\n"), 0644))
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		MaxLogFileSizeBytes: 100,
+		Header:              func() []byte { return []byte("header\n") },
+	}, withMinSize(1))
+	assert.NoError(t, err)
+	_, err = fw.Write([]byte(randomString(150) + "\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Close())
+
+	archives, err := filepath.Glob(filepath.Join(dir, "logfile-*"))
+	assert.NoError(t, err)
+	assert.Len(t, archives, 1)
+	data, err := os.ReadFile(archives[0])
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "old line\n"))
+
+	// the new file starts with the header
+	data, err = os.ReadFile(logFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "header\n", string(data))
+}
+
+func TestConfigureFileHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer Configure(Config{})
+	assert.NoError(t, Configure(Config{
+		Mode:       "file",
+		FileHeader: true,
+		FileWriter: FileWriterConfig{LogDirName: dir, LogFileName: "test.log"},
+	}))
+	Get().Info("first entry")
+	assert.NoError(t, Configure(Config{}))
+
+	f, err := os.Open(filepath.Join(dir, "test.log"))
+	assert.NoError(t, err)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	assert.True(t, scanner.Scan())
+	var header map[string]interface{}
+	assert.NoError(t, json.Unmarshal(scanner.Bytes(), &header))
+	assert.Equal(t, fileHeaderMessage, header["msg"])
+	assert.Equal(t, float64(os.Getpid()), header["pid"])
+	assert.Contains(t, header, "host")
+	assert.Contains(t, header, "start_time")
+	assert.Equal(t, "test.log", header["config"].(map[string]interface{})["file"])
+
+	assert.True(t, scanner.Scan())
+	assert.Contains(t, scanner.Text(), "first entry")
+}
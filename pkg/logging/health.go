@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// maxCompressionBacklog is the number of archives waiting to be compressed
+// before we consider a FileWriter unhealthy.
+const maxCompressionBacklog = 10
+
+// ErrCompressionBacklog is returned by Health if archives are compressed
+// slower than they are produced.
+var ErrCompressionBacklog = errors.New("log compression is falling behind")
+
+// Health returns nil if the FileWriter is healthy.  It returns an error if
+// the FileWriter is closed, if the last write or rotation failed or if too
+// many archives are waiting to be compressed.
+func (w *FileWriter) Health() error {
+	if w.closed.Load() {
+		return os.ErrClosed
+	}
+
+	if lastError := w.stats.lastError.Load(); lastError != nil {
+		lastWrite := time.Unix(0, w.stats.lastWrite.Load())
+		if !lastError.time.Before(lastWrite) {
+			return fmt.Errorf("writing %s failed: %w", w.logFileNameFullPath, lastError.err)
+		}
+	}
+
+	if backlog := w.stats.compressing.Load(); backlog > maxCompressionBacklog {
+		return fmt.Errorf("%w: %d archives waiting", ErrCompressionBacklog, backlog)
+	}
+	return nil
+}
+
+// Health returns nil if the sinks of the package logger are healthy.  Use it
+// in readiness checks to surface logging failures.
+func Health() error {
+	var errs []error
+	for _, fw := range currentFileWriters() {
+		err := fw.Health()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileWriterHealth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:  dir,
+		LogFileName: "logfile.log",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Health())
+
+	fw.recordError(errors.New("disk full"))
+	assert.ErrorContains(t, fw.Health(), "disk full")
+
+	// a successful write makes us healthy again
+	time.Sleep(time.Millisecond)
+	_, err = fw.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Health())
+
+	assert.NoError(t, fw.Close())
+	assert.ErrorIs(t, fw.Health(), os.ErrClosed)
+}
+
+// stalledFS is a memFS where reading archives blocks until release is
+// closed, like a disk too slow to keep up with compression.
+type stalledFS struct {
+	*memFS
+	logFile string
+	release chan struct{}
+}
+
+func (s *stalledFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if flag == os.O_RDONLY && filepath.Clean(name) != s.logFile {
+		<-s.release
+	}
+	return s.memFS.OpenFile(name, flag, perm)
+}
+
+func TestFileWriterCompressionBacklog(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "filewriter-backlog-does-not-exist")
+	fs := &stalledFS{
+		memFS:   newMemFS(),
+		logFile: filepath.Join(dir, "logfile.log"),
+		release: make(chan struct{}),
+	}
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		Compress:            true,
+		MaxLogFileSizeBytes: minLogFileSizeBytes,
+		FS:                  fs,
+	})
+	assert.NoError(t, err)
+
+	// every write rotates the log file, the archives pile up waiting to be
+	// compressed
+	line := []byte(randomString(int(minLogFileSizeBytes) + 1))
+	for i := 0; i <= maxCompressionBacklog; i++ {
+		_, err = fw.Write(line)
+		assert.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		return fw.Stats().CompressionBacklog == maxCompressionBacklog+1
+	}, 2*time.Second, time.Millisecond)
+	assert.ErrorIs(t, fw.Health(), ErrCompressionBacklog)
+
+	close(fs.release)
+	assert.Eventually(t, func() bool {
+		return fw.Stats().CompressionBacklog == 0
+	}, 5*time.Second, time.Millisecond)
+	assert.NoError(t, fw.Health())
+	assert.NoError(t, fw.Close())
+}
+
+func TestFileWriterOnError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var errs []error
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:  dir,
+		LogFileName: "logfile.log",
+		OnError:     func(err error) { errs = append(errs, err) },
+	})
+	assert.NoError(t, err)
+
+	// close the file behind the FileWriter's back to make writes fail
+	fw.logFile.Close()
+	_, err = fw.Write([]byte("hello\n"))
+	assert.Error(t, err)
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], os.ErrClosed)
+
+	fw.Close()
+}
@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger is a logger with its own level and sinks, so one binary can run
+// several independently configured loggers, eg. for the application log, the
+// access log and the audit log.  Unlike the package logger it is not
+// installed anywhere, it is handed to the code using it, eg. by a dependency
+// injection framework.
+type Logger struct {
+	*zap.Logger
+
+	level     zap.AtomicLevel
+	closers   []io.Closer
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// New creates a Logger writing to the sinks given by c at Info level.  The
+// package logger, its level and the level spec are left alone.  The returned
+// function flushes and closes the sinks, call it
+// when the logger is no longer used.  Mask rules and the audit log are only
+// set up by Configure.  Logging at Fatal level runs the exit hooks and closes
+// the sinks of both the package logger and the returned logger.
+func New(c Config) (*Logger, func(), error) {
+	stackLevel := noStacktraceLevel
+	if c.StacktraceLevel != "" {
+		err := stackLevel.UnmarshalText([]byte(c.StacktraceLevel))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	level := zap.NewAtomicLevel()
+	c.level = level
+
+	core, closers, err := buildFilteredCore(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(c.Fields) > 0 {
+		core = core.With(c.Fields)
+	}
+	if c.Enrich {
+		core = NewEnrichCore(core)
+	}
+
+	l := &Logger{
+		level:   level,
+		closers: closers,
+	}
+	l.Logger = zap.New(exitCore{core, l.exit}, zap.AddCaller(), zap.AddStacktrace(stackLevel))
+	return l, func() { l.Close() }, nil
+}
+
+// Close flushes and closes the sinks of the logger.  The logger must not be
+// used afterwards.
+func (l *Logger) Close() error {
+	l.closeOnce.Do(func() {
+		l.Logger.Sync()
+
+		var errs []error
+		for _, closer := range l.closers {
+			err := closer.Close()
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		l.closeErr = errors.Join(errs...)
+	})
+	return l.closeErr
+}
+
+// exit is run when something is logged at Fatal level, just before zap exits
+// the process.  Since the whole process exits the exit hooks and the package
+// logger are taken care of as well, then the sinks of the logger are closed.
+func (l *Logger) exit() {
+	runExitHooks()
+	l.Close()
+}
+
+// SetLevel sets the level of the logger.
+func (l *Logger) SetLevel(level zapcore.Level) {
+	l.level.SetLevel(level)
+}
+
+// GetLevel returns the level of the logger.
+func (l *Logger) GetLevel() zapcore.Level {
+	return l.level.Level()
+}
+
+// FileWriters returns the file writers of the logger, eg. to rotate them.
+func (l *Logger) FileWriters() []*FileWriter {
+	var fileWriters []*FileWriter
+	for _, closer := range l.closers {
+		if fw, ok := closer.(*FileWriter); ok {
+			fileWriters = append(fileWriters, fw)
+		}
+	}
+	return fileWriters
+}
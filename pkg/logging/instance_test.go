@@ -0,0 +1,142 @@
+package logging
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNew(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer Configure(Config{})
+	assert.NoError(t, Configure(Config{RecentEntries: 10}))
+
+	l, cleanup, err := New(Config{
+		Mode:       "file",
+		FileWriter: FileWriterConfig{LogDirName: dir, LogFileName: "instance.log"},
+	})
+	assert.NoError(t, err)
+	l.Info("instance entry")
+	cleanup()
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "instance.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "instance entry")
+
+	// the package logger is left alone
+	assert.Empty(t, Recent(10))
+	assert.NoError(t, l.Close())
+}
+
+func TestNewFatal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer Configure(Config{})
+
+	l, cleanup, err := New(Config{
+		Mode: "file",
+		FileWriter: FileWriterConfig{
+			LogDirName:      dir,
+			LogFileName:     "instance.log",
+			BufferSizeBytes: 4096,
+		},
+	})
+	assert.NoError(t, err)
+	defer cleanup()
+
+	hookRan := false
+	RegisterExitHook(func() { hookRan = true })
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.WithOptions(zap.OnFatal(zapcore.WriteThenGoexit)).Fatal("instance fatal")
+	}()
+	<-done
+
+	// the exit hooks ran and the buffered entry was flushed when the sinks
+	// of the logger were closed
+	assert.True(t, hookRan)
+	assert.ErrorIs(t, l.FileWriters()[0].Health(), os.ErrClosed)
+	data, err := ioutil.ReadFile(filepath.Join(dir, "instance.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "instance fatal")
+}
+
+func TestNewLevel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer SetLevel(defaultLogLevel)
+	SetLevel(zapcore.ErrorLevel)
+
+	app, cleanupApp, err := New(Config{
+		Mode:       "file",
+		FileWriter: FileWriterConfig{LogDirName: dir, LogFileName: "app.log"},
+	})
+	assert.NoError(t, err)
+	access, cleanupAccess, err := New(Config{
+		Mode:       "file",
+		FileWriter: FileWriterConfig{LogDirName: dir, LogFileName: "access.log"},
+	})
+	assert.NoError(t, err)
+
+	app.SetLevel(zapcore.DebugLevel)
+	assert.Equal(t, zapcore.DebugLevel, app.GetLevel())
+	assert.Equal(t, zapcore.InfoLevel, access.GetLevel())
+	assert.Len(t, app.FileWriters(), 1)
+
+	app.Debug("app debug")
+	access.Debug("access debug")
+	access.Info("access info")
+	cleanupApp()
+	cleanupAccess()
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "app debug")
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "access.log"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "access debug")
+	assert.Contains(t, string(data), "access info")
+}
+
+func TestModule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := &Config{
+		Mode:       "file",
+		FileWriter: FileWriterConfig{LogDirName: dir, LogFileName: "fx.log"},
+	}
+
+	var log *zap.SugaredLogger
+	app := fx.New(
+		fx.NopLogger,
+		fx.Supply(c),
+		Module,
+		fx.Populate(&log),
+	)
+	assert.NoError(t, app.Start(context.Background()))
+	log.Infow("fx entry")
+	assert.NoError(t, app.Stop(context.Background()))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "fx.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "fx entry")
+}
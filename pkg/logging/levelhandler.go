@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// levelPayload is the JSON payload used by the level handler.  It follows the
+// format used by the REST API described in doc/logging.md.
+type levelPayload struct {
+	LogLevel        string   `json:"logLevel,omitempty"`
+	DurationSeconds int64    `json:"durationSeconds,omitempty"`
+	ValidLogLevels  []string `json:"validLoglevels,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+var validLogLevels = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// LevelHandler returns an http.Handler for querying and changing the log level
+// at runtime.
+//
+// GET returns the current log level along with the valid log levels.
+//
+// PUT (or POST) sets the log level temporarily using the same semantics as
+// SetLevelTemporarily.  The level and duration can be given as a JSON payload
+// ({"logLevel":"DEBUG","durationSeconds":600}) or as the query parameters
+// "level" and "duration" (eg. ?level=debug&duration=15m).  The response
+// contains the duration the level will actually be in effect.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(serveLevel)
+}
+
+func serveLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeLevelPayload(w, http.StatusOK, levelPayload{
+			LogLevel:       GetLevel().CapitalString(),
+			ValidLogLevels: validLogLevels,
+		})
+
+	case http.MethodPut, http.MethodPost:
+		level, d, err := parseLevelRequest(r)
+		if err != nil {
+			writeLevelPayload(w, http.StatusBadRequest, levelPayload{Error: err.Error()})
+			return
+		}
+
+		d, err = SetLevelTemporarily(level, d)
+		if err != nil {
+			writeLevelPayload(w, http.StatusInternalServerError, levelPayload{Error: err.Error()})
+			return
+		}
+
+		lg.Infow("log level changed", "level", level, "duration", d)
+		writeLevelPayload(w, http.StatusOK, levelPayload{
+			LogLevel:        level.CapitalString(),
+			DurationSeconds: int64(d / time.Second),
+		})
+
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		writeLevelPayload(w, http.StatusMethodNotAllowed, levelPayload{Error: "method not allowed"})
+	}
+}
+
+// parseLevelRequest extracts level and duration from the request.  Query
+// parameters take precedence over the JSON payload.
+func parseLevelRequest(r *http.Request) (zapcore.Level, time.Duration, error) {
+	var (
+		payload levelPayload
+		level   zapcore.Level
+	)
+
+	if r.Body != nil && r.ContentLength != 0 {
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil {
+			return level, 0, fmt.Errorf("invalid payload: %w", err)
+		}
+	}
+
+	levelString := payload.LogLevel
+	if q := r.URL.Query().Get("level"); q != "" {
+		levelString = q
+	}
+	if levelString == "" {
+		return level, 0, fmt.Errorf("missing log level, valid levels are %s", strings.Join(validLogLevels, ", "))
+	}
+
+	err := level.UnmarshalText([]byte(levelString))
+	if err != nil {
+		return level, 0, fmt.Errorf("invalid log level %q, valid levels are %s", levelString, strings.Join(validLogLevels, ", "))
+	}
+
+	d := time.Duration(payload.DurationSeconds) * time.Second
+	if q := r.URL.Query().Get("duration"); q != "" {
+		d, err = time.ParseDuration(q)
+		if err != nil {
+			return level, 0, fmt.Errorf("invalid duration %q: %w", q, err)
+		}
+	}
+	if d < 0 {
+		return level, 0, fmt.Errorf("negative duration %s", d)
+	}
+
+	return level, d, nil
+}
+
+func writeLevelPayload(w http.ResponseWriter, status int, payload levelPayload) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelHandler(t *testing.T) {
+	defer SetLevel(defaultLogLevel)
+
+	server := httptest.NewServer(LevelHandler())
+	defer server.Close()
+
+	// get the current level
+	res, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var payload levelPayload
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&payload))
+	res.Body.Close()
+	assert.Equal(t, "INFO", payload.LogLevel)
+	assert.Equal(t, validLogLevels, payload.ValidLogLevels)
+
+	// set the level using query parameters
+	req, err := http.NewRequest(http.MethodPut, server.URL+"?level=debug&duration=15m", nil)
+	assert.NoError(t, err)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&payload))
+	res.Body.Close()
+	assert.Equal(t, "DEBUG", payload.LogLevel)
+	assert.Equal(t, int64(900), payload.DurationSeconds)
+	assert.Equal(t, zapcore.DebugLevel, GetLevel())
+
+	// set the level using a JSON payload, the duration is capped
+	res, err = http.Post(server.URL, "application/json", strings.NewReader(`{"logLevel":"WARN","durationSeconds":100000}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&payload))
+	res.Body.Close()
+	assert.Equal(t, int64(maxDurationForTemporaryLogLevelChange.Seconds()), payload.DurationSeconds)
+	assert.Equal(t, zapcore.WarnLevel, GetLevel())
+
+	// invalid level
+	res, err = http.Post(server.URL+"?level=loud", "application/json", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res.Body.Close()
+	assert.Equal(t, zapcore.WarnLevel, GetLevel())
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"os"
+	"os/signal"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// HandleLevelSignals lets operators change the log level of a running process
+// with signals.  SIGUSR1 switches to debug level for the default temporary
+// duration, see SetLevelTemporarily, and SIGUSR2 reverts to the default level
+// right away.  Call the returned function to remove the signal handler.  On
+// platforms without SIGUSR1 and SIGUSR2 this does nothing.
+func HandleLevelSignals() (stop func()) {
+	debugSignal, revertSignal, ok := levelSignals()
+	if !ok {
+		return func() {}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, debugSignal, revertSignal)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case sig := <-sigCh:
+				level := defaultLogLevel
+				if sig == debugSignal {
+					level = zapcore.DebugLevel
+				}
+				d, err := SetLevelTemporarily(level, 0)
+				if err != nil {
+					lg.Errorw("error changing log level", "signal", sig, "err", err)
+					continue
+				}
+				lg.Infow("log level changed by signal", "signal", sig, "level", level, "duration", d)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+		<-stopped
+	}
+}
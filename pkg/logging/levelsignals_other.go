@@ -0,0 +1,9 @@
+//go:build !unix
+
+package logging
+
+import "os"
+
+func levelSignals() (debug os.Signal, revert os.Signal, ok bool) {
+	return nil, nil, false
+}
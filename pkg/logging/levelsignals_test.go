@@ -0,0 +1,25 @@
+//go:build unix
+
+package logging
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestHandleLevelSignals(t *testing.T) {
+	defer SetLevel(defaultLogLevel)
+
+	stop := HandleLevelSignals()
+	defer stop()
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, func() bool { return GetLevel() == zapcore.DebugLevel }, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+	assert.Eventually(t, func() bool { return GetLevel() == defaultLogLevel }, time.Second, 10*time.Millisecond)
+}
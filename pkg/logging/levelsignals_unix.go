@@ -0,0 +1,12 @@
+//go:build unix
+
+package logging
+
+import (
+	"os"
+	"syscall"
+)
+
+func levelSignals() (debug os.Signal, revert os.Signal, ok bool) {
+	return syscall.SIGUSR1, syscall.SIGUSR2, true
+}
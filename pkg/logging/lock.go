@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const lockExtension = "lock"
+
+// ErrLockUnsupported is returned if LockLogDir is set on a platform where we
+// can't lock files.
+var ErrLockUnsupported = errors.New("log file locking is not supported on this platform")
+
+// LockedError is returned when the log directory is locked by another
+// FileWriter, usually another instance of the same binary.
+type LockedError struct {
+	// Path is the path of the lock file.
+	Path string
+	// PID is the process id of the lock holder if known, otherwise 0.
+	PID int
+}
+
+func (e *LockedError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("log directory is locked by process %d (%s)", e.PID, e.Path)
+	}
+	return fmt.Sprintf("log directory is locked by another process (%s)", e.Path)
+}
+
+// lockPath returns the path of the lock file guarding the log file.  We can't
+// lock the log file itself since it is renamed on rotation.
+func (w *FileWriter) lockPath() string {
+	return filepath.Join(w.config.LogDirName, w.config.LogFileName+"."+lockExtension)
+}
+
+// acquireLock takes an exclusive lock on the lock file and records our pid in
+// it.  The lock is held until the FileWriter is closed.
+func (w *FileWriter) acquireLock() error {
+	name := w.lockPath()
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, w.filePermissions())
+	if err != nil {
+		return err
+	}
+
+	err = lockFile(f)
+	if err != nil {
+		f.Close()
+		if errors.Is(err, errLockHeld) {
+			return &LockedError{Path: name, PID: lockHolder(name)}
+		}
+		return err
+	}
+
+	// the pid is informational only so we don't care if this fails
+	if f.Truncate(0) == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+
+	w.lockFile = f
+	return nil
+}
+
+// releaseLock releases the lock taken by acquireLock.  Closing the file
+// releases the lock.
+func (w *FileWriter) releaseLock() error {
+	if w.lockFile == nil {
+		return nil
+	}
+	err := w.lockFile.Close()
+	w.lockFile = nil
+	return err
+}
+
+// lockHolder returns the pid recorded in the lock file or 0.
+func lockHolder(name string) int {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
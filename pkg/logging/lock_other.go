@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package logging
+
+import (
+	"errors"
+	"os"
+)
+
+var errLockHeld = errors.New("lock held")
+
+// lockFile always fails with ErrLockUnsupported on platforms without flock.
+func lockFile(f *os.File) error {
+	return ErrLockUnsupported
+}
@@ -0,0 +1,400 @@
+package logging
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtPool = buffer.NewPool()
+
+func init() {
+	err := zap.RegisterEncoder("logfmt", func(ec zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return NewLogfmtEncoder(ec), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// logfmtEncoder encodes entries as logfmt, ie. space separated key=value
+// pairs.  Nested objects are flattened using dotted keys and arrays are
+// encoded as comma separated lists in brackets.
+type logfmtEncoder struct {
+	*zapcore.EncoderConfig
+	buf    *buffer.Buffer
+	prefix string
+}
+
+// NewLogfmtEncoder creates an encoder producing logfmt lines.  The encoder is
+// also registered with zap under the name "logfmt".
+func NewLogfmtEncoder(ec zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{
+		EncoderConfig: &ec,
+		buf:           logfmtPool.Get(),
+	}
+}
+
+// logfmtEncoderConfig is the production encoder config adjusted to produce
+// human readable timestamps.
+func logfmtEncoderConfig() zapcore.EncoderConfig {
+	ec := zap.NewProductionEncoderConfig()
+	ec.EncodeTime = zapcore.ISO8601TimeEncoder
+	return ec
+}
+
+func (enc *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := &logfmtEncoder{
+		EncoderConfig: enc.EncoderConfig,
+		buf:           logfmtPool.Get(),
+		prefix:        enc.prefix,
+	}
+	clone.buf.Write(enc.buf.Bytes())
+	return clone
+}
+
+func (enc *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := &logfmtEncoder{
+		EncoderConfig: enc.EncoderConfig,
+		buf:           logfmtPool.Get(),
+	}
+
+	if final.TimeKey != "" && final.EncodeTime != nil {
+		final.addEncoded(final.TimeKey, func(ae zapcore.PrimitiveArrayEncoder) {
+			final.EncodeTime(ent.Time, ae)
+		})
+	}
+	if final.LevelKey != "" && final.EncodeLevel != nil {
+		final.addEncoded(final.LevelKey, func(ae zapcore.PrimitiveArrayEncoder) {
+			final.EncodeLevel(ent.Level, ae)
+		})
+	}
+	if ent.LoggerName != "" && final.NameKey != "" {
+		final.addEncoded(final.NameKey, func(ae zapcore.PrimitiveArrayEncoder) {
+			if final.EncodeName == nil {
+				ae.AppendString(ent.LoggerName)
+				return
+			}
+			final.EncodeName(ent.LoggerName, ae)
+		})
+	}
+	if ent.Caller.Defined {
+		if final.CallerKey != "" && final.EncodeCaller != nil {
+			final.addEncoded(final.CallerKey, func(ae zapcore.PrimitiveArrayEncoder) {
+				final.EncodeCaller(ent.Caller, ae)
+			})
+		}
+		if final.FunctionKey != "" {
+			final.AddString(final.FunctionKey, ent.Caller.Function)
+		}
+	}
+	if final.MessageKey != "" {
+		final.AddString(final.MessageKey, ent.Message)
+	}
+
+	// fields added with With() are already encoded
+	if enc.buf.Len() > 0 {
+		final.separate()
+		final.buf.Write(enc.buf.Bytes())
+	}
+	final.prefix = enc.prefix
+	for _, f := range fields {
+		f.AddTo(final)
+	}
+	final.prefix = ""
+
+	if ent.Stack != "" && final.StacktraceKey != "" {
+		final.AddString(final.StacktraceKey, ent.Stack)
+	}
+
+	if final.LineEnding != "" {
+		final.buf.AppendString(final.LineEnding)
+	} else {
+		final.buf.AppendString(zapcore.DefaultLineEnding)
+	}
+
+	return final.buf, nil
+}
+
+// separate adds a space between key/value pairs.
+func (enc *logfmtEncoder) separate() {
+	if enc.buf.Len() > 0 {
+		enc.buf.AppendByte(' ')
+	}
+}
+
+func (enc *logfmtEncoder) addKey(key string) {
+	enc.separate()
+	if enc.prefix != "" {
+		writeLogfmtKey(enc.buf, enc.prefix)
+		enc.buf.AppendByte('.')
+	}
+	writeLogfmtKey(enc.buf, key)
+	enc.buf.AppendByte('=')
+}
+
+// addEncoded adds a value produced by one of the EncoderConfig encoders.
+func (enc *logfmtEncoder) addEncoded(key string, encode func(zapcore.PrimitiveArrayEncoder)) {
+	values := &logfmtArrayEncoder{}
+	encode(values)
+	enc.addKey(key)
+	writeLogfmtValue(enc.buf, strings.Join(values.elems, ","))
+}
+
+func (enc *logfmtEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	values := &logfmtArrayEncoder{config: enc.EncoderConfig}
+	err := arr.MarshalLogArray(values)
+	enc.addKey(key)
+	writeLogfmtValue(enc.buf, "["+strings.Join(values.elems, ",")+"]")
+	return err
+}
+
+func (enc *logfmtEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	old := enc.prefix
+	if enc.prefix == "" {
+		enc.prefix = key
+	} else {
+		enc.prefix = enc.prefix + "." + key
+	}
+	err := obj.MarshalLogObject(enc)
+	enc.prefix = old
+	return err
+}
+
+func (enc *logfmtEncoder) AddBinary(key string, value []byte) {
+	enc.AddString(key, base64.StdEncoding.EncodeToString(value))
+}
+
+func (enc *logfmtEncoder) AddByteString(key string, value []byte) {
+	enc.AddString(key, string(value))
+}
+
+func (enc *logfmtEncoder) AddBool(key string, value bool) {
+	enc.addKey(key)
+	enc.buf.AppendBool(value)
+}
+
+func (enc *logfmtEncoder) AddComplex128(key string, value complex128) {
+	enc.addKey(key)
+	enc.buf.AppendString(formatComplex(value))
+}
+
+func (enc *logfmtEncoder) AddComplex64(key string, value complex64) {
+	enc.AddComplex128(key, complex128(value))
+}
+
+func (enc *logfmtEncoder) AddDuration(key string, value time.Duration) {
+	if enc.EncodeDuration == nil {
+		enc.AddString(key, value.String())
+		return
+	}
+	enc.addEncoded(key, func(ae zapcore.PrimitiveArrayEncoder) {
+		enc.EncodeDuration(value, ae)
+	})
+}
+
+func (enc *logfmtEncoder) AddFloat64(key string, value float64) {
+	enc.addKey(key)
+	enc.buf.AppendString(formatFloat(value, 64))
+}
+
+func (enc *logfmtEncoder) AddFloat32(key string, value float32) {
+	enc.addKey(key)
+	enc.buf.AppendString(formatFloat(float64(value), 32))
+}
+
+func (enc *logfmtEncoder) AddInt(key string, value int)     { enc.AddInt64(key, int64(value)) }
+func (enc *logfmtEncoder) AddInt32(key string, value int32) { enc.AddInt64(key, int64(value)) }
+func (enc *logfmtEncoder) AddInt16(key string, value int16) { enc.AddInt64(key, int64(value)) }
+func (enc *logfmtEncoder) AddInt8(key string, value int8)   { enc.AddInt64(key, int64(value)) }
+
+func (enc *logfmtEncoder) AddInt64(key string, value int64) {
+	enc.addKey(key)
+	enc.buf.AppendInt(value)
+}
+
+func (enc *logfmtEncoder) AddString(key, value string) {
+	enc.addKey(key)
+	writeLogfmtValue(enc.buf, value)
+}
+
+func (enc *logfmtEncoder) AddTime(key string, value time.Time) {
+	if enc.EncodeTime == nil {
+		enc.AddString(key, value.Format(time.RFC3339Nano))
+		return
+	}
+	enc.addEncoded(key, func(ae zapcore.PrimitiveArrayEncoder) {
+		enc.EncodeTime(value, ae)
+	})
+}
+
+func (enc *logfmtEncoder) AddUint(key string, value uint)       { enc.AddUint64(key, uint64(value)) }
+func (enc *logfmtEncoder) AddUint32(key string, value uint32)   { enc.AddUint64(key, uint64(value)) }
+func (enc *logfmtEncoder) AddUint16(key string, value uint16)   { enc.AddUint64(key, uint64(value)) }
+func (enc *logfmtEncoder) AddUint8(key string, value uint8)     { enc.AddUint64(key, uint64(value)) }
+func (enc *logfmtEncoder) AddUintptr(key string, value uintptr) { enc.AddUint64(key, uint64(value)) }
+
+func (enc *logfmtEncoder) AddUint64(key string, value uint64) {
+	enc.addKey(key)
+	enc.buf.AppendUint(value)
+}
+
+func (enc *logfmtEncoder) AddReflected(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	enc.AddString(key, string(data))
+	return nil
+}
+
+func (enc *logfmtEncoder) OpenNamespace(key string) {
+	if enc.prefix == "" {
+		enc.prefix = key
+		return
+	}
+	enc.prefix = enc.prefix + "." + key
+}
+
+// logfmtArrayEncoder collects array elements as strings.
+type logfmtArrayEncoder struct {
+	config *zapcore.EncoderConfig
+	elems  []string
+}
+
+func (a *logfmtArrayEncoder) AppendArray(arr zapcore.ArrayMarshaler) error {
+	inner := &logfmtArrayEncoder{config: a.config}
+	err := arr.MarshalLogArray(inner)
+	a.elems = append(a.elems, "["+strings.Join(inner.elems, ",")+"]")
+	return err
+}
+
+func (a *logfmtArrayEncoder) AppendObject(obj zapcore.ObjectMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	err := obj.MarshalLogObject(m)
+	data, jsonErr := json.Marshal(m.Fields)
+	if jsonErr != nil {
+		return jsonErr
+	}
+	a.elems = append(a.elems, string(data))
+	return err
+}
+
+func (a *logfmtArrayEncoder) AppendReflected(value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	a.elems = append(a.elems, string(data))
+	return nil
+}
+
+func (a *logfmtArrayEncoder) AppendBool(v bool)         { a.elems = append(a.elems, strconv.FormatBool(v)) }
+func (a *logfmtArrayEncoder) AppendByteString(v []byte) { a.elems = append(a.elems, string(v)) }
+func (a *logfmtArrayEncoder) AppendComplex128(v complex128) {
+	a.elems = append(a.elems, formatComplex(v))
+}
+func (a *logfmtArrayEncoder) AppendComplex64(v complex64) { a.AppendComplex128(complex128(v)) }
+func (a *logfmtArrayEncoder) AppendFloat64(v float64)     { a.elems = append(a.elems, formatFloat(v, 64)) }
+func (a *logfmtArrayEncoder) AppendFloat32(v float32) {
+	a.elems = append(a.elems, formatFloat(float64(v), 32))
+}
+func (a *logfmtArrayEncoder) AppendInt(v int) { a.AppendInt64(int64(v)) }
+func (a *logfmtArrayEncoder) AppendInt64(v int64) {
+	a.elems = append(a.elems, strconv.FormatInt(v, 10))
+}
+func (a *logfmtArrayEncoder) AppendInt32(v int32)   { a.AppendInt64(int64(v)) }
+func (a *logfmtArrayEncoder) AppendInt16(v int16)   { a.AppendInt64(int64(v)) }
+func (a *logfmtArrayEncoder) AppendInt8(v int8)     { a.AppendInt64(int64(v)) }
+func (a *logfmtArrayEncoder) AppendString(v string) { a.elems = append(a.elems, v) }
+func (a *logfmtArrayEncoder) AppendUint(v uint)     { a.AppendUint64(uint64(v)) }
+func (a *logfmtArrayEncoder) AppendUint64(v uint64) {
+	a.elems = append(a.elems, strconv.FormatUint(v, 10))
+}
+func (a *logfmtArrayEncoder) AppendUint32(v uint32)          { a.AppendUint64(uint64(v)) }
+func (a *logfmtArrayEncoder) AppendUint16(v uint16)          { a.AppendUint64(uint64(v)) }
+func (a *logfmtArrayEncoder) AppendUint8(v uint8)            { a.AppendUint64(uint64(v)) }
+func (a *logfmtArrayEncoder) AppendUintptr(v uintptr)        { a.AppendUint64(uint64(v)) }
+func (a *logfmtArrayEncoder) AppendTime(v time.Time)         { a.appendTime(v) }
+func (a *logfmtArrayEncoder) AppendDuration(v time.Duration) { a.appendDuration(v) }
+
+func (a *logfmtArrayEncoder) appendTime(v time.Time) {
+	if a.config == nil || a.config.EncodeTime == nil {
+		a.elems = append(a.elems, v.Format(time.RFC3339Nano))
+		return
+	}
+	a.config.EncodeTime(v, a)
+}
+
+func (a *logfmtArrayEncoder) appendDuration(v time.Duration) {
+	if a.config == nil || a.config.EncodeDuration == nil {
+		a.elems = append(a.elems, v.String())
+		return
+	}
+	a.config.EncodeDuration(v, a)
+}
+
+// writeLogfmtKey writes key replacing characters that are not allowed in
+// logfmt keys with underscores.
+func writeLogfmtKey(buf *buffer.Buffer, key string) {
+	if key == "" {
+		buf.AppendByte('_')
+		return
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			buf.AppendByte('_')
+			continue
+		}
+		buf.AppendString(string(r))
+	}
+}
+
+// writeLogfmtValue writes value, quoting it if necessary.
+func writeLogfmtValue(buf *buffer.Buffer, value string) {
+	if !logfmtNeedsQuoting(value) {
+		buf.AppendString(value)
+		return
+	}
+	buf.AppendString(strconv.Quote(value))
+}
+
+func logfmtNeedsQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !strconv.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'f', -1, bitSize)
+}
+
+func formatComplex(c complex128) string {
+	i := formatFloat(imag(c), 64)
+	if !strings.HasPrefix(i, "-") && !strings.HasPrefix(i, "+") {
+		i = "+" + i
+	}
+	return formatFloat(real(c), 64) + i + "i"
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	var buf bytes.Buffer
+
+	ec := logfmtEncoderConfig()
+	ec.TimeKey = ""
+	l := zap.New(zapcore.NewCore(NewLogfmtEncoder(ec), zapcore.AddSync(&buf), zapcore.DebugLevel))
+
+	l.Named("db").With(zap.String("component", "store")).Info("query done",
+		zap.Int("rows", 3),
+		zap.Duration("elapsed", 1500*time.Millisecond),
+		zap.String("query", `select * from "users"`),
+		zap.Strings("tags", []string{"a", "b"}),
+		zap.Object("user", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("name", "bob")
+			enc.AddBool("admin", false)
+			return nil
+		})),
+		zap.String("empty", ""),
+		zap.String("bad key", "x=y"),
+	)
+
+	assert.Equal(t,
+		`level=info logger=db msg="query done" component=store rows=3 elapsed=1.5 query="select * from \"users\"" tags=[a,b] user.name=bob user.admin=false empty="" bad_key="x=y"`+"\n",
+		buf.String())
+}
+
+func TestLogfmtEncoderNamespace(t *testing.T) {
+	var buf bytes.Buffer
+
+	ec := logfmtEncoderConfig()
+	ec.TimeKey = ""
+	ec.LevelKey = ""
+	l := zap.New(zapcore.NewCore(NewLogfmtEncoder(ec), zapcore.AddSync(&buf), zapcore.DebugLevel))
+
+	l.With(zap.Namespace("req"), zap.String("id", "42")).Info("hi", zap.Float64("ratio", 0.5), zap.Complex128("c", complex(1, -2)))
+
+	assert.Equal(t, "msg=hi req.id=42 req.ratio=0.5 req.c=1-2i\n", buf.String())
+}
+
+func TestLogfmtRegistered(t *testing.T) {
+	cfg := zap.NewProductionConfig()
+	cfg.Encoding = "logfmt"
+	cfg.OutputPaths = []string{}
+	_, err := cfg.Build()
+	assert.NoError(t, err)
+}
@@ -1,8 +1,12 @@
 package logging
 
 import (
+	"io"
 	"os"
+	"path"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -13,18 +17,201 @@ const (
 	// LoggerSpecEnvVar is the environment variable that controls what kind of logger we
 	// want.  If the value is "file" we will only log to file.  If the value is "both", we
 	// log to console and file.  If the value is "console" we log to console only.
-	// "container"
+	// "container" logs JSON to stderr and "syslog" logs to syslog.  "eventlog" logs to
+	// file and writes warnings and errors to the Windows Event Log.  "gcp" logs JSON in
+	// the format expected by Google Cloud Logging to stdout.  "console-split" logs to
+	// console with Info and below on stdout and Warn and above on stderr.  "off"
+	// or "discard" drops all entries.
 	LoggerSpecEnvVar = "TEST_LOGGER"
 
+	// LogEncodingEnvVar selects the encoding of the structured (non-console) output.  Valid
+	// values are "json", "logfmt", "ecs" and "cef".  The default is "json".
+	LogEncodingEnvVar = "TEST_LOG_ENCODING"
+
 	// LogDirEnvVar is the environment variable that controls which directory logging
 	// will take place in.  If this is unset we do not log to file.make
 	LogDirEnvVar = "TEST_LOG_DIR"
 
-	// LogFileSizeEnvVar specifies max log file size in megabytes
-	LogFileSizeEnvVar = "TEST_LOG_FILE_SIZE_MB"
+	// LogFileSizeEnvVar specifies max log file size, eg. "500MB" or "2GiB".
+	LogFileSizeEnvVar = "TEST_LOG_FILE_SIZE"
+
+	// LogFileSizeMBEnvVar specifies max log file size in megabytes.
+	//
+	// Deprecated: Use LogFileSizeEnvVar.
+	LogFileSizeMBEnvVar = "TEST_LOG_FILE_SIZE_MB"
+
+	// LogErrorFileEnvVar is the name of a file in the log directory which
+	// Error level entries and above are written to in addition to the log
+	// file, eg. "errors.log".
+	LogErrorFileEnvVar = "TEST_LOG_ERROR_FILE"
+
+	// LogDirMaxSizeEnvVar specifies the max total size of the log file and its archives in
+	// megabytes.  The oldest archives are deleted when the limit is exceeded.
+	LogDirMaxSizeEnvVar = "TEST_LOG_DIR_MAX_SIZE_MB"
+
+	// LogMaxArchivesEnvVar specifies how many rotated log files we keep.  If this is unset
+	// we keep all of them (subject to the age and size limits).
+	LogMaxArchivesEnvVar = "TEST_LOG_MAX_ARCHIVES"
+
+	// LogFileMaxAgeEnvVar is how long we will keep log files around, eg. "72h" or "7d".
+	LogFileMaxAgeEnvVar = "TEST_LOG_FILE_MAX_AGE"
+
+	// LogFileMaxAgeDaysEnvVar is the maximum number of days we will keep log files around.
+	//
+	// Deprecated: Use LogFileMaxAgeEnvVar.
+	LogFileMaxAgeDaysEnvVar = "TEST_LOG_FILE_MAX_AGE_DAYS"
+
+	// LogCompressionEnvVar selects the codec used to compress archived log files.  Valid
+	// values are "gzip" and "zstd".  The default is "gzip".
+	LogCompressionEnvVar = "TEST_LOG_COMPRESSION"
+
+	// LogAgeRecipientsEnvVar is a comma separated list of age recipients
+	// ("age1...") that archived log files are encrypted for.
+	LogAgeRecipientsEnvVar = "TEST_LOG_AGE_RECIPIENTS"
+
+	// LogFsyncEnvVar controls when the log file is synced to disk.  If the value is "error"
+	// we sync after every entry at Error level or above.  If it is a duration, eg. "1s", we
+	// sync at that interval.  If it is unset we leave it to the operating system.
+	LogFsyncEnvVar = "TEST_LOG_FSYNC"
+
+	// LogAsyncEnvVar controls whether log files are written asynchronously.  Set it to
+	// "true" to buffer entries in memory and write them to disk in batches.  Set it to
+	// "drop" to also drop entries instead of blocking when the buffer is full.
+	LogAsyncEnvVar = "TEST_LOG_ASYNC"
+
+	// LogReopenOnSIGHUPEnvVar controls whether the log file is reopened when the process
+	// receives SIGHUP.  Set it to "true" when log files are rotated by logrotate.
+	LogReopenOnSIGHUPEnvVar = "TEST_LOG_REOPEN_ON_SIGHUP"
+
+	// LogLockEnvVar makes the file logger lock the log directory so a second
+	// instance writing to the same log file fails at startup.  Set it to "true".
+	LogLockEnvVar = "TEST_LOG_LOCK"
+
+	// LogSamplingInitialEnvVar is the number of entries with the same level and
+	// message logged per second before sampling kicks in.  Sampling is disabled
+	// unless this is set.
+	LogSamplingInitialEnvVar = "TEST_LOG_SAMPLING_INITIAL"
+
+	// LogSamplingThereafterEnvVar is the sampling rate once the initial entries
+	// have been logged, eg. 100 logs every 100th entry.
+	LogSamplingThereafterEnvVar = "TEST_LOG_SAMPLING_THEREAFTER"
+
+	// LogMaskRulesEnvVar is the path of a YAML file with rules for masking
+	// personal data such as email addresses in log entries.
+	LogMaskRulesEnvVar = "TEST_LOG_MASK_RULES"
+
+	// LogConfigFileEnvVar is the path of a YAML or JSON logging configuration
+	// file.  Settings in the file override the environment variables.
+	LogConfigFileEnvVar = "TEST_LOG_CONFIG"
+
+	// LogLevelEnvVar sets the log level and optionally levels for individual
+	// packages, eg. "info,storage=debug,net/http=warn".  See SetLevelSpec.
+	LogLevelEnvVar = "TEST_LOG_LEVEL"
+
+	// LogLevelSignalsEnvVar installs a signal handler which switches to debug level
+	// on SIGUSR1 and back to the default level on SIGUSR2.  Set it to "true".
+	LogLevelSignalsEnvVar = "TEST_LOG_LEVEL_SIGNALS"
+
+	// LogNoGlobalsEnvVar keeps the package from replacing zap's global
+	// logger and redirecting the standard library's logger.  Set it to
+	// "true".
+	LogNoGlobalsEnvVar = "TEST_LOG_NO_GLOBALS"
+
+	// LogColorEnvVar enables ANSI colors in the console output.  Set it to "true".
+	LogColorEnvVar = "TEST_LOG_COLOR"
+
+	// LogStacktraceLevelEnvVar is the lowest level at which stack traces are
+	// attached to log entries, eg. "error".  If it is unset no stack traces are
+	// logged.
+	LogStacktraceLevelEnvVar = "TEST_LOG_STACKTRACE_LEVEL"
+
+	// LogAuditEnvVar enables the audit log, which is written to the "audit"
+	// directory in the log directory.  Set it to "true".
+	LogAuditEnvVar = "TEST_LOG_AUDIT"
+
+	// LogFieldsEnvVar is a comma separated list of fields attached to every
+	// entry, eg. "service=billing,environment=prod".
+	LogFieldsEnvVar = "TEST_LOG_FIELDS"
+
+	// LogEnrichEnvVar adds the host name, pid, Go version and version of the
+	// binary to every entry.  Set it to "true".
+	LogEnrichEnvVar = "TEST_LOG_ENRICH"
+
+	// LogEscapeControlEnvVar escapes newlines, ANSI escape sequences and
+	// other control characters in messages and string fields.  Set it to
+	// "true".
+	LogEscapeControlEnvVar = "TEST_LOG_ESCAPE_CONTROL"
+
+	// LogFileHeaderEnvVar makes each new log file start with a record
+	// describing the process.  Set it to "true".
+	LogFileHeaderEnvVar = "TEST_LOG_FILE_HEADER"
+
+	// LogSentryDSNEnvVar is the DSN of the Sentry project which entries at
+	// Error level and above are sent to.
+	LogSentryDSNEnvVar = "TEST_LOG_SENTRY_DSN"
+
+	// LogSentrySampleRateEnvVar is the fraction of the entries sent to
+	// Sentry, eg. "0.25".  All entries are sent if it is not set.
+	LogSentrySampleRateEnvVar = "TEST_LOG_SENTRY_SAMPLE_RATE"
+
+	// LogAlertWebhookEnvVar is the URL entries at Panic and Fatal level are
+	// posted to.
+	LogAlertWebhookEnvVar = "TEST_LOG_ALERT_WEBHOOK"
+
+	// LogChatWebhookEnvVar is the incoming webhook of a Slack or Teams
+	// channel which summaries of entries at Warn level and above are posted
+	// to.
+	LogChatWebhookEnvVar = "TEST_LOG_CHAT_WEBHOOK"
+
+	// LogChatKindEnvVar is the chat service of LogChatWebhookEnvVar,
+	// "slack" (the default) or "teams".
+	LogChatKindEnvVar = "TEST_LOG_CHAT_KIND"
+
+	// LogMQTTBrokerEnvVar is the URL of an MQTT broker entries are published
+	// to as JSON, eg. "tcp://broker:1883".
+	LogMQTTBrokerEnvVar = "TEST_LOG_MQTT_BROKER"
+
+	// LogMQTTTopicEnvVar is the topic of LogMQTTBrokerEnvVar the entries
+	// are published to.  Defaults to "logs/<host name>".
+	LogMQTTTopicEnvVar = "TEST_LOG_MQTT_TOPIC"
+
+	// LogSQLitePathEnvVar is a SQLite database entries are written to.
+	LogSQLitePathEnvVar = "TEST_LOG_SQLITE_PATH"
+
+	// LogSQLiteMaxSizeMBEnvVar is the size in megabytes the entries may take
+	// up in the database given by LogSQLitePathEnvVar.  Defaults to 100.
+	LogSQLiteMaxSizeMBEnvVar = "TEST_LOG_SQLITE_MAX_SIZE_MB"
+
+	// LogRecentEntriesEnvVar is the number of recent entries kept in memory
+	// at all levels, see Recent.
+	LogRecentEntriesEnvVar = "TEST_LOG_RECENT_ENTRIES"
+
+	// LogTimeFormatEnvVar is the format of timestamps, eg. "rfc3339",
+	// "epochmillis" or a Go time layout.  See TimeConfig.
+	LogTimeFormatEnvVar = "TEST_LOG_TIME_FORMAT"
+
+	// LogTimeUTCEnvVar makes timestamps UTC instead of local time.  Set it to
+	// "true".
+	LogTimeUTCEnvVar = "TEST_LOG_TIME_UTC"
+
+	// SyslogNetworkEnvVar is the network used to reach the syslog server, eg. "udp",
+	// "tcp" or "tls".
+	SyslogNetworkEnvVar = "TEST_SYSLOG_NETWORK"
+
+	// SyslogAddressEnvVar is the address of the syslog server.  If this is unset we log
+	// to the local syslog daemon.
+	SyslogAddressEnvVar = "TEST_SYSLOG_ADDRESS"
+
+	// SyslogCAFileEnvVar, SyslogCertFileEnvVar and SyslogKeyFileEnvVar are the PEM
+	// files with the CA certificates, the client certificate and the client key used
+	// when SyslogNetworkEnvVar is "tls".
+	SyslogCAFileEnvVar   = "TEST_SYSLOG_CA_FILE"
+	SyslogCertFileEnvVar = "TEST_SYSLOG_CERT_FILE"
+	SyslogKeyFileEnvVar  = "TEST_SYSLOG_KEY_FILE"
 
-	// LogFileMaxAgeEnvVar is the maximum number of days we will keep log files around.
-	LogFileMaxAgeEnvVar = "TEST_LOG_FILE_MAX_AGE_DAYS"
+	// EventLogSourceEnvVar is the Windows Event Log source name used by the "eventlog"
+	// logger.  Defaults to the name of the binary.
+	EventLogSourceEnvVar = "TEST_EVENTLOG_SOURCE"
 
 	// maxDurationForTemporaryLogLevelChange is the maximum amount of time we allow a
 	// temporary log change to last
@@ -35,76 +222,598 @@ const (
 	defaultTemporaryLogLevelChangeDuration = 5 * time.Minute
 
 	logFileName = "test.log"
+
+	// noStacktraceLevel is a level above all others, used to disable stack
+	// traces.
+	noStacktraceLevel = zapcore.FatalLevel + 1
 )
 
+// Config is the configuration of the package logger.
+type Config struct {
+	// Mode selects the kind of logger, see LoggerSpecEnvVar for valid values.
+	// The default is "console".
+	Mode string
+	// Encoding is the encoding used for the structured output of the "file",
+	// "both", "container" and "eventlog" modes.  Valid values are "json",
+	// "logfmt", "ecs" (Elastic Common Schema) and "cef" (Common Event Format).
+	// The default is "json".
+	Encoding string
+	// CEF configures the "cef" encoding.
+	CEF CEFConfig
+	// Console configures the human readable output of the "console",
+	// "console-split" and "both" modes.
+	Console ConsoleConfig
+	// StacktraceLevel is the lowest level at which stack traces are attached
+	// to log entries, eg. "error" in production and "warn" during
+	// development.  If it is empty no stack traces are logged.
+	StacktraceLevel string
+	// If NoGlobals is set the global zap logger and the standard library's
+	// logger are restored to what they were before the package replaced
+	// them, see RestoreGlobals.  Unsetting it again does not replace them.
+	NoGlobals bool
+	// Time configures the timestamps of the console and structured outputs.
+	// The syslog header and the CEF "rt" extension use the formats required
+	// by their protocols.
+	Time TimeConfig
+	// FileWriter configures the file output of the "file" and "both" modes.
+	FileWriter FileWriterConfig
+	// If FileHeader is set each new log file starts with a record giving
+	// the host, pid, version and start time of the process and a summary
+	// of the logging configuration.
+	FileHeader bool
+	// FileRoutes routes entries to several files, eg. everything to all.log
+	// and errors to errors.log, each with its own rotation settings.  If it
+	// is set FileWriter is not used.
+	FileRoutes []FileRoute
+	// Syslog configures the "syslog" mode.
+	Syslog SyslogConfig
+	// EventLog configures the event log output of the "eventlog" mode.
+	EventLog EventLogConfig
+	// Sampling configures sampling of log entries.  It applies to all modes
+	// except "console".
+	Sampling SamplingConfig
+	// RateLimit configures rate limiting of identical messages.  It applies
+	// to all modes except "console".
+	RateLimit RateLimitConfig
+	// Dedup configures collapsing of consecutive identical entries.  It
+	// applies to all modes except "console".
+	Dedup DedupConfig
+	// RecentEntries is the number of recent entries kept in memory for
+	// Recent, at all levels.  If it is 0 no entries are kept.
+	RecentEntries int
+	// Audit configures the audit log written by Audit.
+	Audit AuditConfig
+	// Sentry configures sending entries at Error level and above to Sentry.
+	Sentry SentryConfig
+	// AlertWebhook configures posting entries at Panic and Fatal level to
+	// a webhook.
+	AlertWebhook WebhookConfig
+	// Chat configures posting summaries of entries at Warn level and above
+	// to a Slack or Teams channel.
+	Chat ChatConfig
+	// MQTT configures publishing entries to an MQTT broker.
+	MQTT MQTTConfig
+	// SQLite configures writing entries to a SQLite database.
+	SQLite SQLiteConfig
+	// Fields are attached to every entry, eg. the service name and the
+	// environment.  See also SetGlobalFields.
+	Fields []zap.Field
+	// If Enrich is set the host name, pid, Go version and version of the
+	// binary are added to every entry, see NewEnrichCore.
+	Enrich bool
+	// If EscapeControl is set control characters in messages and string
+	// fields are escaped so they can't forge log lines, see NewEscapeCore.
+	EscapeControl bool
+	// MaskRulesFile is a YAML file with mask rules, see LoadMaskRules.  The
+	// rules replace those given to SetMaskRules.
+	MaskRulesFile string
+
+	// level is the level enabler of the cores of a Logger.  The cores of
+	// the package logger use coreLevel.
+	level zapcore.LevelEnabler
+}
+
+// levelEnabler returns the level enabler of the cores built for c.
+func (c Config) levelEnabler() zapcore.LevelEnabler {
+	if c.level != nil {
+		return c.level
+	}
+	return coreLevel
+}
+
 var (
 	logger          *zap.Logger
 	atomicLogLevel  = zap.NewAtomicLevel() // defaults to info
+	stacktraceLevel = zap.NewAtomicLevelAt(noStacktraceLevel)
 	defaultLogLevel = zapcore.InfoLevel
 	lg              *zap.SugaredLogger
+
+	// rootCore is the core of logger.  Configure swaps out the core it
+	// delegates to.
+	rootCore *swapCore
+
+	// closers holds the sinks of the current configuration which must be
+	// closed when the configuration is replaced.
+	closers  []io.Closer
+	configMu sync.Mutex
 )
 
 func init() {
-	var core zapcore.Core
+	configuredCore = newPackageLevelCore(consoleCore(Config{}))
+	rootCore = newSwapCore(exitCore{Core: configuredCore})
+	logger = zap.New(rootCore, zap.AddCaller(), zap.AddStacktrace(stacktraceLevel))
+	lg = logger.Sugar()
+
+	// the sinks are only created once something is logged, see Start
+	levelFromEnv()
+	timeConfigFromEnv().registerLayout()
+	rootCore.deferStart(startFromEnv)
+
+	if !noGlobalsFromEnv() {
+		ReplaceGlobals()
+	}
+}
+
+// ConfigFromEnv returns the logger configuration given by the environment variables.
+func ConfigFromEnv() Config {
+	fileWriter := fileWriterConfigFromEnv()
+	return Config{
+		Mode:       modeFromEnv(),
+		Encoding:   getenv(LogEncodingEnvVar),
+		FileWriter: fileWriter,
+		FileRoutes: fileRoutesFromEnv(fileWriter),
+		FileHeader: fileHeaderFromEnv(),
+		Syslog: SyslogConfig{
+			Network:  getenv(SyslogNetworkEnvVar),
+			Address:  getenv(SyslogAddressEnvVar),
+			CAFile:   getenv(SyslogCAFileEnvVar),
+			CertFile: getenv(SyslogCertFileEnvVar),
+			KeyFile:  getenv(SyslogKeyFileEnvVar),
+		},
+		EventLog: EventLogConfig{
+			Source: getenv(EventLogSourceEnvVar),
+		},
+		Console:         consoleConfigFromEnv(),
+		StacktraceLevel: getenv(LogStacktraceLevelEnvVar),
+		NoGlobals:       noGlobalsFromEnv(),
+		Time:            timeConfigFromEnv(),
+		Sampling:        samplingConfigFromEnv(),
+		RecentEntries:   recentEntriesFromEnv(),
+		Audit:           auditConfigFromEnv(),
+		Sentry:          sentryConfigFromEnv(),
+		AlertWebhook:    WebhookConfig{URL: getenv(LogAlertWebhookEnvVar)},
+		Chat:            chatConfigFromEnv(),
+		MQTT:            mqttConfigFromEnv(),
+		SQLite:          sqliteConfigFromEnv(),
+		Fields:          fieldsFromEnv(),
+		Enrich:          enrichFromEnv(),
+		EscapeControl:   escapeControlFromEnv(),
+		MaskRulesFile:   getenv(LogMaskRulesEnvVar),
+	}
+}
+
+// Configure replaces the configuration of the package logger.  Loggers that
+// have already been obtained from Get() are affected as well.  The sinks of the
+// previous configuration are closed.
+func Configure(c Config) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	// the configuration from the environment is not wanted anymore
+	rootCore.claimStart()
+
+	stackLevel := noStacktraceLevel
+	if c.StacktraceLevel != "" {
+		err := stackLevel.UnmarshalText([]byte(c.StacktraceLevel))
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.MaskRulesFile != "" {
+		rules, err := LoadMaskRules(c.MaskRulesFile)
+		if err != nil {
+			return err
+		}
+		err = SetMaskRules(rules...)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := configureAudit(c.Audit)
+	if err != nil {
+		return err
+	}
+
+	core, newClosers, err := buildFilteredCore(c)
+	if err != nil {
+		return err
+	}
+	core = newPackageLevelCore(core)
+	if ring := configureRecent(c.RecentEntries); ring != nil {
+		// recent entries are kept regardless of the log level
+		core = newLevelTee(core, NewRedactCore(&recentCore{ring: ring}))
+	}
+	if len(c.Fields) > 0 {
+		core = core.With(c.Fields)
+	}
+	if c.Enrich {
+		core = NewEnrichCore(core)
+	}
+	configuredCore = core
+
+	stacktraceLevel.SetLevel(stackLevel)
+
+	// flush whatever the old core holds back before closing its sinks
+	rootCore.swap(finishCore(core)).Sync()
+
+	for _, closer := range closers {
+		closer.Close()
+	}
+	closers = newClosers
+
+	if c.NoGlobals {
+		RestoreGlobals()
+	}
+	return nil
+}
+
+// currentFileWriters returns the file writers of the current configuration.
+func currentFileWriters() []*FileWriter {
+	Start()
+
+	configMu.Lock()
+	defer configMu.Unlock()
 
+	var fileWriters []*FileWriter
+	for _, closer := range closers {
+		if fw, ok := closer.(*FileWriter); ok {
+			fileWriters = append(fileWriters, fw)
+		}
+	}
+	return fileWriters
+}
+
+// buildFilteredCore creates the core for the configuration like buildCore
+// and adds deduplication, rate limiting, sampling, the Sentry, webhook, chat,
+// MQTT and SQLite sinks, redaction and escaping to it.  The "off" and
+// "discard" modes drop all entries.
+func buildFilteredCore(c Config) (zapcore.Core, []io.Closer, error) {
+	if c.Mode == "off" || c.Mode == "discard" {
+		return zapcore.NewNopCore(), nil, nil
+	}
+
+	core, closers, err := buildCore(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.Mode != "console" && c.Mode != "" {
+		core = NewDedupCore(core, c.Dedup)
+		core = NewRateLimitCore(core, c.RateLimit)
+		core = sampledCore(c.Sampling, core)
+	}
+	if c.Sentry.DSN != "" {
+		sentryCore, err := NewSentryCore(c.Sentry)
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, err
+		}
+		core = newLevelTee(core, sentryCore)
+	}
+	if c.AlertWebhook.URL != "" {
+		core = newLevelTee(core, NewWebhookCore(c.AlertWebhook))
+	}
+	if c.Chat.URL != "" {
+		chatCore, chat, err := NewChatCore(c.Chat)
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, err
+		}
+		core = newLevelTee(core, chatCore)
+		closers = append(closers, chat)
+	}
+	if c.MQTT.Broker != "" {
+		mqttWriter := DialMQTT(c.MQTT)
+		core = newLevelTee(core, NewMQTTCore(mqttWriter, c.levelEnabler()))
+		closers = append(closers, mqttWriter)
+	}
+	if c.SQLite.Path != "" {
+		sqliteCore, db, err := NewSQLiteCore(c.SQLite, c.levelEnabler())
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, err
+		}
+		core = newLevelTee(core, sqliteCore)
+		closers = append(closers, db)
+	}
+	core = NewRedactCore(core)
+	if c.EscapeControl {
+		core = NewEscapeCore(core)
+	}
+	return core, closers, nil
+}
+
+// closeAll closes the sinks of a core which could not be built.
+func closeAll(closers []io.Closer) {
+	for _, closer := range closers {
+		closer.Close()
+	}
+}
+
+// buildCore creates the core for the configuration along with the sinks that
+// have to be closed when the core is no longer in use.
+func buildCore(c Config) (zapcore.Core, []io.Closer, error) {
 	// Choose between different logging configurations
-	switch os.Getenv(LoggerSpecEnvVar) {
+	switch c.Mode {
 	// the "file" configuration means the logger will only log to files
 	case "file":
-		core = zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), getLogFileWriter(), atomicLogLevel)
+		return fileCores(c)
 
 	// the "both" configuration means the logger will log to console and files,
 	// however, it will use a more human readable format for the console.
 	case "both":
-		core = zapcore.NewTee(
-			zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), getLogFileWriter(), atomicLogLevel),
-			zapcore.NewCore(zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), zapcore.AddSync(os.Stderr), atomicLogLevel),
-		)
+		core, closers, err := fileCores(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		return newLevelTee(
+			core,
+			consoleCore(c),
+		), closers, nil
 
 	// "console" means the logger logs to console only.
 	case "console":
-		core = zapcore.NewCore(zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), zapcore.AddSync(os.Stderr), atomicLogLevel)
+		return consoleCore(c), nil, nil
+
+	// "console-split" logs to console with Info and below on stdout and Warn
+	// and above on stderr, which some orchestrators and CI systems use to
+	// classify output
+	case "console-split":
+		return splitConsoleCore(c, zapcore.AddSync(os.Stdout), zapcore.AddSync(os.Stderr)), nil, nil
 
 	// "container" is a setting that logs JSON on stderr
 	case "container":
-		core = zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(os.Stderr), atomicLogLevel)
+		return zapcore.NewCore(structuredEncoder(c), zapcore.AddSync(os.Stderr), c.levelEnabler()), nil, nil
+
+	// "gcp" logs JSON formatted for Google Cloud Logging on stdout, which is
+	// where GKE and Cloud Run pick it up
+	case "gcp":
+		return newGCPCore(c.Time.apply(gcpEncoderConfig()), zapcore.AddSync(os.Stdout), c.levelEnabler()), nil, nil
+
+	// "syslog" logs RFC5424 messages to a local or remote syslog server
+	case "syslog":
+		sw, err := NewSyslogWriter(c.Syslog)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewSyslogCore(sw, c.levelEnabler()), []io.Closer{sw}, nil
+
+	// "eventlog" logs everything to files and warnings and errors to the
+	// Windows Event Log
+	case "eventlog":
+		eventLogCore, eventLog, err := NewEventLogCore(c.EventLog)
+		if err != nil {
+			return nil, nil, err
+		}
+		core, closers, err := fileCores(c)
+		if err != nil {
+			eventLog.Close()
+			return nil, nil, err
+		}
+		return newLevelTee(
+			core,
+			eventLogCore,
+		), append(closers, eventLog), nil
 
 	// console logging with human readable format is default
 	default:
-		core = zapcore.NewCore(zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), zapcore.AddSync(os.Stderr), atomicLogLevel)
+		return consoleCore(c), nil, nil
 	}
+}
 
-	logger = zap.New(core, zap.AddCaller())
+// syncOnErrorCore syncs the underlying core after writing entries at Error
+// level or above.  zap only does this for levels above Error.
+type syncOnErrorCore struct {
+	zapcore.Core
+}
 
-	zap.RedirectStdLog(logger)
-	zap.ReplaceGlobals(logger)
+func (c syncOnErrorCore) With(fields []zapcore.Field) zapcore.Core {
+	return syncOnErrorCore{c.Core.With(fields)}
+}
 
-	lg = logger.Sugar()
+func (c syncOnErrorCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
 }
 
-func getLogFileWriter() zapcore.WriteSyncer {
-	logFileSizeMB := int64(0)
-	if os.Getenv(LogFileSizeEnvVar) != "" {
-		size, err := strconv.ParseInt(os.Getenv(LogFileSizeEnvVar), 10, 64)
+func (c syncOnErrorCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	err := c.Core.Write(ent, fields)
+	if ent.Level >= zapcore.ErrorLevel {
+		syncErr := c.Core.Sync()
 		if err == nil {
-			logFileSizeMB = size
+			err = syncErr
+		}
+	}
+	return err
+}
+
+// structuredEncoder returns the encoder for the structured outputs.
+func structuredEncoder(c Config) zapcore.Encoder {
+	switch c.Encoding {
+	case "logfmt":
+		return NewLogfmtEncoder(c.Time.apply(logfmtEncoderConfig()))
+	case "ecs":
+		return newECSEncoder(c.Time.apply(ecsEncoderConfig()))
+	case "cef":
+		return NewCEFEncoder(c.CEF)
+	default:
+		return zapcore.NewJSONEncoder(c.Time.apply(zap.NewProductionEncoderConfig()))
+	}
+}
+
+func consoleCore(c Config) zapcore.Core {
+	return c.Console.wrap(zapcore.NewCore(c.Console.encoder(c.Time), zapcore.AddSync(os.Stderr), c.levelEnabler()))
+}
+
+// splitConsoleCore logs to console with Warn and above on errOut and the rest
+// on out.
+func splitConsoleCore(c Config, out, errOut zapcore.WriteSyncer) zapcore.Core {
+	enc := c.Console.encoder(c.Time)
+	enab := c.levelEnabler()
+	return c.Console.wrap(newLevelTee(
+		zapcore.NewCore(enc, out, zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return l < zapcore.WarnLevel && enab.Enabled(l)
+		})),
+		zapcore.NewCore(enc.Clone(), errOut, zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return l >= zapcore.WarnLevel && enab.Enabled(l)
+		})),
+	))
+}
+
+func fileWriterConfigFromEnv() FileWriterConfig {
+	logFileSize := sizeFromEnv(LogFileSizeEnvVar, LogFileSizeMBEnvVar)
+
+	maxTotalSizeMB := int64(0)
+	if getenv(LogDirMaxSizeEnvVar) != "" {
+		size, err := strconv.ParseInt(getenv(LogDirMaxSizeEnvVar), 10, 64)
+		if err == nil {
+			maxTotalSizeMB = size
+		}
+	}
+
+	maxArchives := 0
+	if getenv(LogMaxArchivesEnvVar) != "" {
+		n, err := strconv.Atoi(getenv(LogMaxArchivesEnvVar))
+		if err == nil {
+			maxArchives = n
 		}
 	}
 
 	// Figure out how long to keep log files
-	maxAge := time.Duration(0)
-	if os.Getenv(LogFileMaxAgeEnvVar) != "" {
-		days, err := strconv.ParseInt(os.Getenv(LogFileMaxAgeEnvVar), 10, 32)
+	maxAge := durationFromEnv(LogFileMaxAgeEnvVar, LogFileMaxAgeDaysEnvVar)
+
+	reopenOnSIGHUP, _ := strconv.ParseBool(getenv(LogReopenOnSIGHUPEnvVar))
+	async, _ := strconv.ParseBool(getenv(LogAsyncEnvVar))
+	lockLogDir, _ := strconv.ParseBool(getenv(LogLockEnvVar))
+	dropOnOverload := getenv(LogAsyncEnvVar) == "drop"
+
+	fsyncOnError := false
+	fsyncEvery := time.Duration(0)
+	if fsync := getenv(LogFsyncEnvVar); fsync == "error" {
+		fsyncOnError = true
+	} else if fsync != "" {
+		d, err := time.ParseDuration(fsync)
 		if err == nil {
-			maxAge = time.Duration(days) * time.Hour * 24
+			fsyncEvery = d
+		}
+	}
+
+	var encryption EncryptionConfig
+	if recipients := getenv(LogAgeRecipientsEnvVar); recipients != "" {
+		for _, r := range strings.Split(recipients, ",") {
+			encryption.AgeRecipients = append(encryption.AgeRecipients, strings.TrimSpace(r))
 		}
 	}
 
-	return zapcore.AddSync(NewFileWriter(FileWriterConfig{
+	return FileWriterConfig{
 		LogDirName:          GetLogDir(),
 		LogFileName:         logFileName,
 		Compress:            true,
 		MaxTimeTimeToKeep:   maxAge,
-		MaxLogFileSizeBytes: logFileSizeMB * 1024 * 1024,
-	}))
+		MaxLogFileSizeBytes: logFileSize,
+		ReopenOnSIGHUP:      reopenOnSIGHUP,
+		MaxTotalSizeBytes:   maxTotalSizeMB * 1024 * 1024,
+		MaxArchivedFiles:    maxArchives,
+		CompressionCodec:    Codec(getenv(LogCompressionEnvVar)),
+		Encryption:          encryption,
+		FsyncOnError:        fsyncOnError,
+		FsyncEvery:          fsyncEvery,
+		Async:               async,
+		DropOnOverload:      dropOnOverload,
+		LockLogDir:          lockLogDir,
+	}
+}
+
+// samplingConfigFromEnv returns the sampling configuration given by the
+// environment variables.
+func samplingConfigFromEnv() SamplingConfig {
+	initial, _ := strconv.Atoi(getenv(LogSamplingInitialEnvVar))
+	thereafter, _ := strconv.Atoi(getenv(LogSamplingThereafterEnvVar))
+	return SamplingConfig{
+		Initial:    initial,
+		Thereafter: thereafter,
+	}
+}
+
+// consoleConfigFromEnv returns the console configuration given by the
+// environment variables.
+func consoleConfigFromEnv() ConsoleConfig {
+	color, _ := strconv.ParseBool(getenv(LogColorEnvVar))
+	return ConsoleConfig{Color: color}
+}
+
+// timeConfigFromEnv returns the timestamp configuration given by the
+// environment variables.
+func timeConfigFromEnv() TimeConfig {
+	utc, _ := strconv.ParseBool(getenv(LogTimeUTCEnvVar))
+	return TimeConfig{
+		Format: getenv(LogTimeFormatEnvVar),
+		UTC:    utc,
+	}
+}
+
+// auditConfigFromEnv returns the audit log configuration given by the
+// environment variables.  The audit log uses the same rotation settings as
+// the log file.
+func auditConfigFromEnv() AuditConfig {
+	enabled, _ := strconv.ParseBool(getenv(LogAuditEnvVar))
+	fw := fileWriterConfigFromEnv()
+	fw.LogDirName = path.Join(fw.LogDirName, auditDirName)
+	fw.LogFileName = auditFileName
+	return AuditConfig{
+		Enabled:    enabled,
+		FileWriter: fw,
+	}
+}
+
+// fileRoutesFromEnv returns the file routes given by the environment
+// variables, which is nil unless there is an error file.  The error file
+// uses the settings of fw except for the name.
+func fileRoutesFromEnv(fw FileWriterConfig) []FileRoute {
+	errorFile := getenv(LogErrorFileEnvVar)
+	if errorFile == "" {
+		return nil
+	}
+
+	errorFileWriter := fw
+	errorFileWriter.LogFileName = errorFile
+	return []FileRoute{
+		{FileWriter: fw},
+		{Level: zapcore.ErrorLevel, FileWriter: errorFileWriter},
+	}
+}
+
+func enrichFromEnv() bool {
+	enrich, _ := strconv.ParseBool(getenv(LogEnrichEnvVar))
+	return enrich
+}
+
+func escapeControlFromEnv() bool {
+	escape, _ := strconv.ParseBool(getenv(LogEscapeControlEnvVar))
+	return escape
+}
+
+func fileHeaderFromEnv() bool {
+	header, _ := strconv.ParseBool(getenv(LogFileHeaderEnvVar))
+	return header
+}
+
+// recentEntriesFromEnv returns the number of recent entries to keep given by
+// the environment variables.
+func recentEntriesFromEnv() int {
+	n, _ := strconv.Atoi(getenv(LogRecentEntriesEnvVar))
+	return n
 }
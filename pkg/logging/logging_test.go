@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// countingSyncer counts writes and syncs.
+type countingSyncer struct {
+	writes int
+	syncs  int
+}
+
+func (s *countingSyncer) Write(p []byte) (int, error) {
+	s.writes++
+	return len(p), nil
+}
+
+func (s *countingSyncer) Sync() error {
+	s.syncs++
+	return nil
+}
+
+func TestSyncOnErrorCore(t *testing.T) {
+	ws := &countingSyncer{}
+	core := syncOnErrorCore{zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), ws, zapcore.DebugLevel)}
+	l := zap.New(core).With(zap.String("k", "v"))
+
+	l.Info("info")
+	l.Warn("warn")
+	assert.Equal(t, 0, ws.syncs)
+
+	l.Error("error")
+	assert.Equal(t, 3, ws.writes)
+	assert.Equal(t, 1, ws.syncs)
+}
+
+func TestSplitConsoleCore(t *testing.T) {
+	var out, errOut bytes.Buffer
+	l := zap.New(splitConsoleCore(Config{}, zapcore.AddSync(&out), zapcore.AddSync(&errOut)))
+
+	l.Debug("not logged")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+
+	assert.Contains(t, out.String(), "info")
+	assert.NotContains(t, out.String(), "warn")
+	assert.Contains(t, errOut.String(), "warn")
+	assert.Contains(t, errOut.String(), "error")
+	assert.NotContains(t, errOut.String(), "info")
+	assert.NotContains(t, out.String()+errOut.String(), "not logged")
+}
+
+func TestStacktraceLevel(t *testing.T) {
+	// stack traces are disabled by default
+	tl := NewTestLogger(t)
+	Get().Error("no stack")
+	assert.Empty(t, tl.Entries()[0].Stack)
+
+	defer Configure(Config{})
+
+	assert.NoError(t, Configure(Config{StacktraceLevel: "warn"}))
+	tl = NewTestLogger(t)
+
+	Get().Info("no stack")
+	Get().Warn("stack")
+
+	entries := tl.Entries()
+	assert.Len(t, entries, 2)
+	assert.Empty(t, entries[0].Stack)
+	assert.Contains(t, entries[1].Stack, "TestStacktraceLevel")
+
+	assert.Error(t, Configure(Config{StacktraceLevel: "loud"}))
+}
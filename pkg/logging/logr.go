@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// logrSink implements logr.LogSink on top of a zap logger.  logr verbosity
+// levels are mapped to negative zap levels so V(0) is Info, V(1) is Debug and
+// anything beyond that is only logged if the level is set below Debug.
+type logrSink struct {
+	l *zap.Logger
+}
+
+// Logr returns a logr.Logger that writes to the package logger.  This is
+// useful for libraries such as client-go and controller-runtime which expect
+// a logr.Logger.
+func Logr() logr.Logger {
+	return newLogr(logger)
+}
+
+func newLogr(l *zap.Logger) logr.Logger {
+	return logr.New(&logrSink{l: l})
+}
+
+// Init receives runtime info about the logr library.  We skip one extra
+// frame since we call zap.Logger.Check from within the sink.
+func (s *logrSink) Init(info logr.RuntimeInfo) {
+	s.l = s.l.WithOptions(zap.AddCallerSkip(info.CallDepth + 1))
+}
+
+// Enabled tests whether this sink is enabled at the specified V-level.
+func (s *logrSink) Enabled(level int) bool {
+	return s.l.Core().Enabled(logrToZapLevel(level))
+}
+
+// Info logs a non-error message at the given V-level.
+func (s *logrSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if ce := s.l.Check(logrToZapLevel(level), msg); ce != nil {
+		ce.Write(logrFields(keysAndValues)...)
+	}
+}
+
+// Error logs an error message.
+func (s *logrSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if ce := s.l.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(append(logrFields(keysAndValues), zap.Error(err))...)
+	}
+}
+
+// WithValues returns a sink with the key/value pairs added to every entry.
+func (s *logrSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &logrSink{l: s.l.With(logrFields(keysAndValues)...)}
+}
+
+// WithName returns a sink with the name appended to the logger name.
+func (s *logrSink) WithName(name string) logr.LogSink {
+	return &logrSink{l: s.l.Named(name)}
+}
+
+// WithCallDepth returns a sink that skips depth additional stack frames when
+// determining the caller.
+func (s *logrSink) WithCallDepth(depth int) logr.LogSink {
+	return &logrSink{l: s.l.WithOptions(zap.AddCallerSkip(depth))}
+}
+
+func logrToZapLevel(level int) zapcore.Level {
+	if level < 0 {
+		level = 0
+	}
+	return zapcore.Level(-level)
+}
+
+// logrFields turns logr key/value pairs into zap fields.  Non-string keys are
+// formatted and a dangling value without a key is kept under a special key
+// rather than being silently dropped.
+func logrFields(keysAndValues []interface{}) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, len(keysAndValues)/2+1)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i == len(keysAndValues)-1 {
+			fields = append(fields, zap.Any("EXTRA_VALUE_AT_END", keysAndValues[i]))
+			break
+		}
+
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields = append(fields, zap.Any(key, keysAndValues[i+1]))
+	}
+	return fields
+}
@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogr(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	core, logs := observer.New(level)
+	lr := newLogr(zap.New(core, zap.AddCaller()))
+
+	lr.V(1).Info("not logged")
+	lr.WithName("controller").WithValues("kind", "Pod").Info("reconciling", "name", "foo")
+	lr.Error(errors.New("boom"), "failed", "odd")
+
+	level.SetLevel(zapcore.DebugLevel)
+	lr.V(1).Info("debug now")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 3)
+
+	assert.Equal(t, "controller", entries[0].LoggerName)
+	assert.Equal(t, map[string]interface{}{"kind": "Pod", "name": "foo"}, entries[0].ContextMap())
+	assert.Contains(t, entries[0].Caller.File, "logr_test.go")
+
+	assert.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+	assert.Equal(t, map[string]interface{}{"EXTRA_VALUE_AT_END": "odd", "error": "boom"}, entries[1].ContextMap())
+
+	assert.Equal(t, zapcore.DebugLevel, entries[2].Level)
+}
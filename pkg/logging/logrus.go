@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// logrusHook implements logrus.Hook on top of a zapcore.Core.
+type logrusHook struct {
+	core zapcore.Core
+	name string
+}
+
+// NewLogrusHook returns a logrus hook which forwards entries to the package
+// logger under the name name, so code bases migrating from logrus get the
+// same sinks, rotation and level control while logrus call sites remain.
+// Set the output of the logrus logger to io.Discard to avoid logging twice.
+// logrus still handles Fatal and Panic entries itself, ie. it exits or
+// panics once the hooks have run.
+func NewLogrusHook(name string) logrus.Hook {
+	return &logrusHook{core: logger.Core(), name: name}
+}
+
+// Levels returns the levels the hook fires for, which is all of them.
+func (h *logrusHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire converts the logrus entry to a zap entry and writes it to the core.
+func (h *logrusHook) Fire(e *logrus.Entry) error {
+	ent := zapcore.Entry{
+		LoggerName: h.name,
+		Level:      logrusToZapLevel(e.Level),
+		Time:       e.Time,
+		Message:    e.Message,
+	}
+	if e.Caller != nil {
+		ent.Caller = zapcore.NewEntryCaller(e.Caller.PC, e.Caller.File, e.Caller.Line, true)
+	}
+
+	ce := h.core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(e.Data))
+	for key := range e.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]zapcore.Field, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, zap.Any(key, e.Data[key]))
+	}
+
+	ce.Write(fields...)
+	return nil
+}
+
+// logrusToZapLevel maps logrus levels to zap levels.  zap has no trace
+// level so Trace is mapped to Debug.
+func logrusToZapLevel(level logrus.Level) zapcore.Level {
+	switch level {
+	case logrus.PanicLevel:
+		return zapcore.PanicLevel
+	case logrus.FatalLevel:
+		return zapcore.FatalLevel
+	case logrus.ErrorLevel:
+		return zapcore.ErrorLevel
+	case logrus.WarnLevel:
+		return zapcore.WarnLevel
+	case logrus.InfoLevel:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
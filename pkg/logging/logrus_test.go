@@ -0,0 +1,31 @@
+package logging
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogrusHook(t *testing.T) {
+	tl := NewTestLogger(t)
+
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetLevel(logrus.TraceLevel)
+	l.AddHook(NewLogrusHook("legacy"))
+
+	l.WithField("user", "alice").WithError(errors.New("boom")).Error("request failed")
+	l.Trace("tracing")
+
+	entries := tl.Logged(zapcore.ErrorLevel, "request failed", zap.String("user", "alice"))
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "legacy", entries[0].LoggerName)
+	assert.Equal(t, "boom", entries[0].ContextMap()["error"])
+
+	tl.AssertLogged(zapcore.DebugLevel, "tracing")
+}
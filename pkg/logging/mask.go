@@ -0,0 +1,254 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// MaskRule masks values matching a regular expression, eg. email addresses.
+// Mask rules are applied to the message and to string and error fields.
+type MaskRule struct {
+	// Name identifies the rule.
+	Name string `yaml:"name"`
+	// Pattern is the regular expression matching values to mask.
+	Pattern string `yaml:"pattern"`
+	// Replacement replaces matches.  Defaults to RedactedValue.
+	Replacement string `yaml:"replacement"`
+	// Builtin refers to one of the builtin rules instead of giving a
+	// pattern: "email", "creditcard", "nationalid" (Norwegian national
+	// identity numbers) or "ssn" (US social security numbers).
+	Builtin string `yaml:"builtin"`
+
+	re       *regexp.Regexp
+	validate func(string) bool
+}
+
+// maskRulesFile is the format of mask rule files.
+type maskRulesFile struct {
+	Rules []MaskRule `yaml:"rules"`
+}
+
+// builtinMaskRules are the rules available through MaskRule.Builtin.
+var builtinMaskRules = map[string]MaskRule{
+	"email": {
+		Pattern:     `[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`,
+		Replacement: "[EMAIL]",
+	},
+	"creditcard": {
+		Pattern:     `\b(?:\d[ -]?){12,18}\d\b`,
+		Replacement: "[CARD]",
+		validate:    luhnValid,
+	},
+	"nationalid": {
+		Pattern:     `\b\d{6} ?\d{5}\b`,
+		Replacement: "[NATIONALID]",
+		validate:    nationalIDValid,
+	},
+	"ssn": {
+		Pattern:     `\b\d{3}-\d{2}-\d{4}\b`,
+		Replacement: "[SSN]",
+	},
+}
+
+// compile resolves builtin rules and compiles the pattern.
+func (r MaskRule) compile() (MaskRule, error) {
+	if r.Builtin != "" {
+		builtin, ok := builtinMaskRules[r.Builtin]
+		if !ok {
+			return r, fmt.Errorf("unknown builtin mask rule %q", r.Builtin)
+		}
+		if r.Pattern == "" {
+			r.Pattern = builtin.Pattern
+		}
+		if r.Replacement == "" {
+			r.Replacement = builtin.Replacement
+		}
+		r.validate = builtin.validate
+	}
+	if r.Name == "" {
+		r.Name = r.Builtin
+	}
+	if r.Pattern == "" {
+		return r, fmt.Errorf("mask rule %q has no pattern", r.Name)
+	}
+	if r.Replacement == "" {
+		r.Replacement = RedactedValue
+	}
+
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return r, fmt.Errorf("mask rule %q: %w", r.Name, err)
+	}
+	r.re = re
+	return r, nil
+}
+
+// mask applies the rule to s.
+func (r MaskRule) mask(s string) string {
+	return r.re.ReplaceAllStringFunc(s, func(match string) string {
+		if r.validate != nil && !r.validate(match) {
+			return match
+		}
+		return r.Replacement
+	})
+}
+
+// LoadMaskRules reads mask rules from a YAML file on the form
+//
+//	rules:
+//	  - builtin: email
+//	  - name: customer-id
+//	    pattern: 'CUST-\d{6}'
+//	    replacement: '[CUSTOMER]'
+func LoadMaskRules(path string) ([]MaskRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f maskRulesFile
+	err = yaml.Unmarshal(data, &f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mask rules file %s: %w", path, err)
+	}
+
+	for i, rule := range f.Rules {
+		f.Rules[i], err = rule.compile()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f.Rules, nil
+}
+
+// SetMaskRules replaces the mask rules applied to all log entries.
+func SetMaskRules(rules ...MaskRule) error {
+	compiled := make([]MaskRule, len(rules))
+	for i, rule := range rules {
+		var err error
+		compiled[i], err = rule.compile()
+		if err != nil {
+			return err
+		}
+	}
+
+	redaction.mu.Lock()
+	defer redaction.mu.Unlock()
+
+	redaction.masks = compiled
+	return nil
+}
+
+// maskString applies all mask rules to s.  This assumes r.mu is read locked.
+func (r *redactRules) maskString(s string) string {
+	for _, rule := range r.masks {
+		s = rule.mask(s)
+	}
+	return s
+}
+
+// maskMessage applies the mask rules to a message.
+func (r *redactRules) maskMessage(msg string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.maskString(msg)
+}
+
+// safeString returns the text of an error or fmt.Stringer, or false if
+// getting it panics, eg. for nil pointers.
+func safeString(v interface{}) (s string, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	switch v := v.(type) {
+	case error:
+		return v.Error(), true
+	case fmt.Stringer:
+		return v.String(), true
+	}
+	return "", false
+}
+
+// maskField applies the mask rules to string like fields.  It returns false
+// if the field is unchanged.  This assumes r.mu is read locked.
+func (r *redactRules) maskField(f zapcore.Field) (zapcore.Field, bool) {
+	var s string
+	switch f.Type {
+	case zapcore.StringType:
+		s = f.String
+	case zapcore.ByteStringType:
+		s = string(f.Interface.([]byte))
+	case zapcore.ErrorType, zapcore.StringerType:
+		var ok bool
+		if s, ok = safeString(f.Interface); !ok {
+			// leave it to the encoder, which handles nil pointers
+			return f, false
+		}
+	default:
+		return f, false
+	}
+
+	masked := r.maskString(s)
+	if masked == s {
+		return f, false
+	}
+	return zap.String(f.Key, masked), true
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum used by
+// credit card numbers.
+func luhnValid(s string) bool {
+	sum := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// nationalIDValid reports whether s is a Norwegian national identity number
+// with valid check digits.
+func nationalIDValid(s string) bool {
+	var d []int
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			d = append(d, int(c-'0'))
+		}
+	}
+	if len(d) != 11 {
+		return false
+	}
+
+	checkDigit := func(weights []int) int {
+		sum := 0
+		for i, w := range weights {
+			sum += w * d[i]
+		}
+		k := 11 - sum%11
+		if k == 11 {
+			k = 0
+		}
+		return k
+	}
+	return checkDigit([]int{3, 7, 6, 1, 8, 9, 4, 5, 2}) == d[9] &&
+		checkDigit([]int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2}) == d[10]
+}
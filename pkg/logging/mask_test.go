@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMaskRules(t *testing.T) {
+	defer ResetRedaction()
+
+	dir, err := os.MkdirTemp("", "mask-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rules.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`rules:
+  - builtin: email
+  - builtin: creditcard
+  - builtin: nationalid
+  - name: customer
+    pattern: 'CUST-\d{6}'
+    replacement: '[CUSTOMER]'
+`), 0644))
+
+	rules, err := LoadMaskRules(path)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 4)
+	assert.NoError(t, SetMaskRules(rules...))
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := zap.New(NewRedactCore(core))
+
+	l.Info("mail from bob@example.com",
+		zap.String("card", "4111 1111 1111 1111"),
+		zap.String("order", "1234567890123"),
+		zap.String("fnr", "01010112377"),
+		zap.Error(errors.New("unknown customer CUST-123456")),
+	)
+
+	ent := logs.All()[0]
+	assert.Equal(t, "mail from [EMAIL]", ent.Message)
+	assert.Equal(t, map[string]interface{}{
+		"card":  "[CARD]",
+		"order": "1234567890123",
+		"fnr":   "[NATIONALID]",
+		"error": "unknown customer [CUSTOMER]",
+	}, ent.ContextMap())
+
+	assert.Error(t, SetMaskRules(MaskRule{Builtin: "nope"}))
+	assert.Error(t, SetMaskRules(MaskRule{Name: "empty"}))
+}
+
+type maskStringer struct{ s string }
+
+func (m *maskStringer) String() string { return m.s }
+
+func (m *maskStringer) Error() string { return m.s }
+
+func TestMaskStringer(t *testing.T) {
+	defer ResetRedaction()
+	assert.NoError(t, SetMaskRules(MaskRule{Builtin: "email"}))
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := zap.New(NewRedactCore(core))
+
+	assert.NotPanics(t, func() {
+		l.Info("stringers",
+			zap.Stringer("user", &maskStringer{"bob@example.com"}),
+			zap.Stringer("nil", (*maskStringer)(nil)),
+			zap.NamedError("err", (*maskStringer)(nil)),
+		)
+	})
+
+	ent := logs.All()[0]
+	assert.Equal(t, "[EMAIL]", ent.ContextMap()["user"])
+	assert.Equal(t, "<nil>", ent.ContextMap()["nil"])
+	assert.Equal(t, "<nil>", ent.ContextMap()["err"])
+}
@@ -0,0 +1,147 @@
+package logging
+
+import (
+	"bufio"
+	"container/heap"
+	"io"
+	"strings"
+	"time"
+)
+
+// MergeOptions configures MergeLogs.
+type MergeOptions struct {
+	// Since and Until limit the entries to a period.  A zero Since or
+	// Until leaves that end open.
+	Since time.Time
+	Until time.Time
+	// If Label is set every line is prefixed with the path of the file it
+	// was read from, eg. "api/test.log: ".
+	Label bool
+}
+
+// MergeLogs writes the entries of the log files and archives at paths to w
+// ordered by time, eg. to follow a request through the logs of several
+// services during an incident.  The files may be in any of the formats of
+// this package, use different time formats and be compressed.  Each file
+// must be in time order, as log files are.  Entries logged at the same time
+// are written in the order of paths.
+func MergeLogs(w io.Writer, paths []string, opts MergeOptions) error {
+	out := bufio.NewWriter(w)
+	err := mergeEntries(paths, opts.Since, opts.Until, func(path string, e Entry) error {
+		line := e.Line
+		if opts.Label {
+			prefix := path + ": "
+			line = prefix + strings.ReplaceAll(line, "\n", "\n"+prefix)
+		}
+		_, err := out.WriteString(line + "\n")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// mergeEntries calls fn with the entries of the files at paths logged
+// between since and until, ordered by time.  It stops at the first error
+// returned by fn.
+func mergeEntries(paths []string, since, until time.Time, fn func(path string, e Entry) error) error {
+	sources := make(mergeHeap, 0, len(paths))
+	defer func() {
+		for _, s := range sources {
+			s.r.Close()
+		}
+	}()
+
+	for i, path := range paths {
+		r, err := openEntries(path)
+		if err != nil {
+			return err
+		}
+		s := &mergeSource{r: r, index: i}
+		ok, err := s.advance(since)
+		if err != nil {
+			r.Close()
+			return err
+		}
+		if ok {
+			sources = append(sources, s)
+		} else {
+			r.Close()
+		}
+	}
+	heap.Init(&sources)
+
+	for len(sources) > 0 {
+		s := sources[0]
+		if !until.IsZero() && s.entry.Time.After(until) {
+			// the other sources are past until as well
+			break
+		}
+
+		err := fn(s.r.path, s.entry)
+		if err != nil {
+			return err
+		}
+
+		ok, err := s.advance(since)
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&sources, 0)
+		} else {
+			s.r.Close()
+			heap.Pop(&sources)
+		}
+	}
+	return nil
+}
+
+// mergeSource is a file merged by MergeLogs along with its next entry.
+type mergeSource struct {
+	r     *entryReader
+	index int
+	entry Entry
+}
+
+// advance reads the next entry logged at or after since.  It returns false
+// at the end of the file.
+func (s *mergeSource) advance(since time.Time) (bool, error) {
+	for {
+		e, err := s.r.Next()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if since.IsZero() || !e.Time.Before(since) {
+			s.entry = e
+			return true, nil
+		}
+	}
+}
+
+// mergeHeap orders the sources by the time of their next entry.
+type mergeHeap []*mergeSource
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].entry.Time.Equal(h[j].entry.Time) {
+		return h[i].index < h[j].index
+	}
+	return h[i].entry.Time.Before(h[j].entry.Time)
+}
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(*mergeSource)) }
+
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the same period logged by services with different encoders
+	api := filepath.Join(dir, "api.log")
+	assert.NoError(t, os.WriteFile(api, []byte(
+		`{"level":"info","ts":1714557600,"msg":"api 1"}`+"\n"+
+			`{"level":"info","ts":1714557602.5,"msg":"api 2"}`+"\n"+
+			`{"level":"info","ts":1714557604,"msg":"api 3"}`+"\n"), 0644))
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(
+		"2024-05-01T10:00:01.000Z\tERROR\tworker.go:1\tworker 1\n" +
+			"main.main()\n" +
+			"2024-05-01T12:00:03.000+0200\tINFO\tworker.go:2\tworker 2\n"))
+	assert.NoError(t, zw.Close())
+	worker := filepath.Join(dir, "worker-2024-05-01T10-00-00.00000.log.gz")
+	assert.NoError(t, os.WriteFile(worker, compressed.Bytes(), 0644))
+
+	var out bytes.Buffer
+	assert.NoError(t, MergeLogs(&out, []string{api, worker}, MergeOptions{}))
+	assert.Equal(t, ""+
+		`{"level":"info","ts":1714557600,"msg":"api 1"}`+"\n"+
+		"2024-05-01T10:00:01.000Z\tERROR\tworker.go:1\tworker 1\n"+
+		"main.main()\n"+
+		`{"level":"info","ts":1714557602.5,"msg":"api 2"}`+"\n"+
+		"2024-05-01T12:00:03.000+0200\tINFO\tworker.go:2\tworker 2\n"+
+		`{"level":"info","ts":1714557604,"msg":"api 3"}`+"\n", out.String())
+
+	out.Reset()
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, MergeLogs(&out, []string{api, worker}, MergeOptions{
+		Since: start.Add(time.Second),
+		Until: start.Add(3 * time.Second),
+		Label: true,
+	}))
+	assert.Equal(t, ""+
+		worker+": 2024-05-01T10:00:01.000Z\tERROR\tworker.go:1\tworker 1\n"+
+		worker+": main.main()\n"+
+		api+`: {"level":"info","ts":1714557602.5,"msg":"api 2"}`+"\n"+
+		worker+": 2024-05-01T12:00:03.000+0200\tINFO\tworker.go:2\tworker 2\n", out.String())
+
+	assert.Error(t, MergeLogs(&out, []string{filepath.Join(dir, "missing.log")}, MergeOptions{}))
+}
@@ -0,0 +1,148 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultRequestIDHeader = "X-Request-Id"
+	requestIDKey           = "requestID"
+)
+
+// HTTPMiddlewareConfig contains the configuration for the HTTP middleware.
+type HTTPMiddlewareConfig struct {
+	// StatusLevels maps status classes (2 for 2xx, 4 for 4xx and so on) to
+	// the level requests are logged at.  By default 5xx responses are logged
+	// at Error level, 4xx at Warn level and everything else at Info level.
+	StatusLevels map[int]zapcore.Level
+	// SkipPaths are paths which aren't logged, eg. "/healthz".
+	SkipPaths []string
+	// RequestIDHeader is the header used to pass request ids.  If the
+	// request has this header we use its value, otherwise we generate a new
+	// request id.  The request id is returned in the same header.  Defaults
+	// to X-Request-Id.
+	RequestIDHeader string
+}
+
+// HTTPMiddleware logs every request handled by next using the default
+// HTTPMiddlewareConfig.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return NewHTTPMiddleware(HTTPMiddlewareConfig{})(next)
+}
+
+// NewHTTPMiddleware returns a middleware which logs the method, path, status,
+// latency and response size of every request along with a request id.  The
+// request id is added to the request context with NewContext so handlers can
+// use FromContext to log with the same request id.
+func NewHTTPMiddleware(c HTTPMiddlewareConfig) func(http.Handler) http.Handler {
+	if c.RequestIDHeader == "" {
+		c.RequestIDHeader = defaultRequestIDHeader
+	}
+
+	skip := make(map[string]bool, len(c.SkipPaths))
+	for _, p := range c.SkipPaths {
+		skip[p] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestID := r.Header.Get(c.RequestIDHeader)
+			if requestID == "" {
+				requestID = newRequestID()
+			}
+			w.Header().Set(c.RequestIDHeader, requestID)
+
+			ctx := NewContext(r.Context(), zap.String(requestIDKey, requestID))
+			rw := &responseWriter{ResponseWriter: w}
+
+			start := time.Now()
+			next.ServeHTTP(rw, r.WithContext(ctx))
+			latency := time.Since(start)
+
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+
+			if ce := FromContext(ctx).Check(c.statusLevel(rw.status), "http request"); ce != nil {
+				ce.Write(
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Int("status", rw.status),
+					zap.Duration("latency", latency),
+					zap.Int64("bytes", rw.bytes),
+				)
+			}
+		})
+	}
+}
+
+// statusLevel returns the level used for requests with the given status.
+func (c HTTPMiddlewareConfig) statusLevel(status int) zapcore.Level {
+	if level, ok := c.StatusLevels[status/100]; ok {
+		return level
+	}
+	switch {
+	case status >= 500:
+		return zapcore.ErrorLevel
+	case status >= 400:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// newRequestID returns a random 16 character hex string.
+func newRequestID() string {
+	var b [8]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return "-"
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// responseWriter records the status and size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher if the underlying writer does.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer for use with http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	old := rootCore.swap(core)
+	defer rootCore.swap(old)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("in handler")
+		w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	handler := NewHTTPMiddleware(HTTPMiddlewareConfig{
+		StatusLevels: map[int]zapcore.Level{4: zapcore.DebugLevel},
+		SkipPaths:    []string{"/healthz"},
+	})(mux)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set("X-Request-Id", "abc")
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "abc", rec.Header().Get("X-Request-Id"))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Len(t, rec.Header().Get("X-Request-Id"), 16)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 3)
+
+	assert.Equal(t, "in handler", entries[0].Message)
+	assert.Equal(t, "abc", entries[0].ContextMap()["requestID"])
+
+	fields := entries[1].ContextMap()
+	assert.Equal(t, zapcore.InfoLevel, entries[1].Level)
+	assert.Equal(t, "http request", entries[1].Message)
+	assert.Equal(t, "abc", fields["requestID"])
+	assert.Equal(t, "GET", fields["method"])
+	assert.Equal(t, "/hello", fields["path"])
+	assert.Equal(t, int64(200), fields["status"])
+	assert.Equal(t, int64(5), fields["bytes"])
+
+	assert.Equal(t, zapcore.DebugLevel, entries[2].Level)
+	assert.Equal(t, int64(404), entries[2].ContextMap()["status"])
+}
@@ -0,0 +1,158 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"go.uber.org/zap/zapcore"
+)
+
+// MQTTConfig configures publishing entries to an MQTT broker.
+type MQTTConfig struct {
+	// Broker is the URL of the broker, eg. "tcp://broker:1883", used by
+	// DialMQTT.  Configure only publishes entries if it is set.
+	Broker string
+	// ClientID is the client ID used by DialMQTT.  Defaults to
+	// "logging-<host name>-<pid>".
+	ClientID string
+	// Username and Password authenticate DialMQTT with the broker.
+	Username string
+	Password string
+	// Topic is the topic the entries are published to.  Defaults to
+	// "logs/<host name>".
+	Topic string
+	// QoS is the quality of service of the published entries, 0, 1 or 2.
+	QoS byte
+	// BufferSize is the number of entries kept while the client is not
+	// connected.  The oldest entries are dropped first.  Defaults to 1000.
+	BufferSize int
+}
+
+const defaultMQTTBufferSize = 1000
+
+// MQTTWriter publishes the entries written to it to an MQTT topic.  While
+// the client is not connected entries are buffered and published once it is
+// connected again.
+type MQTTWriter struct {
+	client mqtt.Client
+	config MQTTConfig
+	owned  bool
+
+	mu      sync.Mutex
+	buffer  [][]byte
+	dropped int
+}
+
+// NewMQTTWriter returns a writer publishing entries with client, eg. the
+// client the application already uses to talk to the backend.  Closing the
+// writer does not disconnect the client.
+func NewMQTTWriter(client mqtt.Client, c MQTTConfig) *MQTTWriter {
+	if c.Topic == "" {
+		host, _ := os.Hostname()
+		c.Topic = "logs/" + host
+	}
+	if c.BufferSize <= 0 {
+		c.BufferSize = defaultMQTTBufferSize
+	}
+	return &MQTTWriter{client: client, config: c}
+}
+
+// DialMQTT returns a writer publishing entries with a client of its own
+// connected to c.Broker.  The client keeps trying to connect and reconnects
+// when the connection is lost.  Closing the writer disconnects the client.
+func DialMQTT(c MQTTConfig) *MQTTWriter {
+	if c.ClientID == "" {
+		host, _ := os.Hostname()
+		c.ClientID = fmt.Sprintf("logging-%s-%d", host, os.Getpid())
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(c.Broker).
+		SetClientID(c.ClientID).
+		SetUsername(c.Username).
+		SetPassword(c.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true)
+	client := mqtt.NewClient(opts)
+	client.Connect()
+
+	w := NewMQTTWriter(client, c)
+	w.owned = true
+	return w
+}
+
+// NewMQTTCore returns a zapcore.Core publishing entries enabled by enab to w
+// as JSON.
+func NewMQTTCore(w *MQTTWriter, enab zapcore.LevelEnabler) zapcore.Core {
+	return zapcore.NewCore(structuredEncoder(Config{}), w, enab)
+}
+
+// Write publishes p, or buffers it if the client is not connected.
+func (w *MQTTWriter) Write(p []byte) (int, error) {
+	// zap reuses the buffer once Write returns
+	payload := append([]byte(nil), bytes.TrimSuffix(p, []byte("\n"))...)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.client.IsConnectionOpen() {
+		if len(w.buffer) >= w.config.BufferSize {
+			w.buffer = w.buffer[1:]
+			w.dropped++
+		}
+		w.buffer = append(w.buffer, payload)
+		return len(p), nil
+	}
+
+	w.flush()
+	w.client.Publish(w.config.Topic, w.config.QoS, false, payload)
+	return len(p), nil
+}
+
+// flush publishes the buffered entries.  This assumes w.mu is locked and the
+// client is connected.
+func (w *MQTTWriter) flush() {
+	if w.dropped > 0 {
+		note := fmt.Sprintf(`{"level":"warn","msg":"dropped log entries while disconnected","dropped":%d}`, w.dropped)
+		w.client.Publish(w.config.Topic, w.config.QoS, false, []byte(note))
+		w.dropped = 0
+	}
+	for _, payload := range w.buffer {
+		w.client.Publish(w.config.Topic, w.config.QoS, false, payload)
+	}
+	w.buffer = nil
+}
+
+// Sync publishes the buffered entries if the client is connected.
+func (w *MQTTWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.client.IsConnectionOpen() {
+		w.flush()
+	}
+	return nil
+}
+
+// Close publishes the buffered entries if the client is connected and
+// disconnects the client if it was connected by DialMQTT.
+func (w *MQTTWriter) Close() error {
+	err := w.Sync()
+	if w.owned {
+		// give the client a moment to send what is in flight
+		w.client.Disconnect(250)
+	}
+	return err
+}
+
+// mqttConfigFromEnv returns the MQTT configuration given by the environment
+// variables.
+func mqttConfigFromEnv() MQTTConfig {
+	return MQTTConfig{
+		Broker: getenv(LogMQTTBrokerEnvVar),
+		Topic:  getenv(LogMQTTTopicEnvVar),
+	}
+}
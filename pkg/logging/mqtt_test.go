@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"encoding/json"
+	"testing"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// testMQTTClient records the published messages.  The methods which are not
+// used by MQTTWriter panic.
+type testMQTTClient struct {
+	mqtt.Client
+	connected bool
+	topics    []string
+	messages  []string
+}
+
+func (c *testMQTTClient) IsConnectionOpen() bool { return c.connected }
+
+func (c *testMQTTClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	c.topics = append(c.topics, topic)
+	c.messages = append(c.messages, string(payload.([]byte)))
+	return &mqtt.DummyToken{}
+}
+
+func TestMQTTWriter(t *testing.T) {
+	client := &testMQTTClient{}
+	w := NewMQTTWriter(client, MQTTConfig{Topic: "device/42/logs", BufferSize: 2})
+	log := zap.New(NewMQTTCore(w, zapcore.InfoLevel))
+
+	// entries are buffered while disconnected, dropping the oldest
+	log.Info("first")
+	log.Info("second")
+	log.Info("third")
+	assert.Empty(t, client.messages)
+
+	client.connected = true
+	log.Info("fourth")
+	assert.NoError(t, w.Close())
+
+	assert.Len(t, client.messages, 4)
+	assert.Equal(t, []string{"device/42/logs"}, client.topics[:1])
+	assert.Contains(t, client.messages[0], `"dropped":1`)
+
+	var messages []string
+	for _, m := range client.messages[1:] {
+		var entry map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(m), &entry))
+		messages = append(messages, entry["msg"].(string))
+	}
+	assert.Equal(t, []string{"second", "third", "fourth"}, messages)
+}
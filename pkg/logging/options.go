@@ -0,0 +1,61 @@
+package logging
+
+import "time"
+
+// FileWriterOption changes the FileWriterConfig passed to NewFileWriter.
+type FileWriterOption func(*FileWriterConfig)
+
+// WithMaxSize rotates the log file when it grows beyond bytes.
+func WithMaxSize(bytes int64) FileWriterOption {
+	return func(c *FileWriterConfig) {
+		c.MaxLogFileSizeBytes = bytes
+	}
+}
+
+// WithCompression compresses archives with codec.
+func WithCompression(codec Codec) FileWriterOption {
+	return func(c *FileWriterConfig) {
+		c.Compress = true
+		c.CompressionCodec = codec
+	}
+}
+
+// WithRetention deletes archives older than maxAge and all but the
+// maxArchivedFiles most recent archives.  Zero values keep the archives.
+func WithRetention(maxAge time.Duration, maxArchivedFiles int) FileWriterOption {
+	return func(c *FileWriterConfig) {
+		c.MaxTimeTimeToKeep = maxAge
+		c.MaxArchivedFiles = maxArchivedFiles
+	}
+}
+
+// WithMaxTotalSize deletes the oldest archives until the log file and its
+// archives take up no more than bytes.
+func WithMaxTotalSize(bytes int64) FileWriterOption {
+	return func(c *FileWriterConfig) {
+		c.MaxTotalSizeBytes = bytes
+	}
+}
+
+// WithRotationPolicy rotates the log file when policy says so.
+func WithRotationPolicy(policy RotationPolicy) FileWriterOption {
+	return func(c *FileWriterConfig) {
+		c.RotationPolicy = policy
+	}
+}
+
+// WithRetentionPolicy deletes the archives expired by policy instead of
+// applying the age, count and size limits.
+func WithRetentionPolicy(policy RetentionPolicy) FileWriterOption {
+	return func(c *FileWriterConfig) {
+		c.RetentionPolicy = policy
+	}
+}
+
+// withMinSize lowers the smallest accepted MaxLogFileSizeBytes, so tests can
+// rotate small log files.
+func withMinSize(bytes int64) FileWriterOption {
+	return func(c *FileWriterConfig) {
+		c.minSizeBytes = bytes
+	}
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+)
+
+// filePermissions returns the permissions of the files we create.
+func (w *FileWriter) filePermissions() os.FileMode {
+	if w.config.FilePermissions != 0 {
+		return w.config.FilePermissions
+	}
+	return logFilePermissions
+}
+
+// dirPermissions returns the permissions of the log directory if we create
+// it.
+func (w *FileWriter) dirPermissions() os.FileMode {
+	if w.config.DirPermissions != 0 {
+		return w.config.DirPermissions
+	}
+	return logDirPermissions
+}
+
+// makeLogDir ensures the log directory exists.  Permissions and ownership
+// are only applied if we create it, since it may be shared, eg. /var/log.
+func (w *FileWriter) makeLogDir() error {
+	_, err := w.fs().Stat(w.config.LogDirName)
+	if err == nil {
+		return nil
+	}
+
+	err = w.fs().MkdirAll(w.config.LogDirName, w.dirPermissions())
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrBadDirectory, err)
+	}
+	return w.setOwnership(w.config.LogDirName, w.config.DirPermissions)
+}
+
+// setOwnership applies mode, unless it is 0, and the configured owner to fn.
+// The mode is set explicitly since the umask applies when files are created.
+func (w *FileWriter) setOwnership(fn string, mode os.FileMode) error {
+	if !w.onOSFS() {
+		return nil
+	}
+	if mode != 0 {
+		err := os.Chmod(fn, mode)
+		if err != nil {
+			return err
+		}
+	}
+	if w.config.Chown {
+		err := os.Lchown(fn, w.config.UID, w.config.GID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fixOwnership is setOwnership for files we create, reporting errors
+// rather than failing since the file is usable anyway.
+func (w *FileWriter) fixOwnership(fn string) {
+	err := w.setOwnership(fn, w.config.FilePermissions)
+	if err != nil {
+		fmt.Printf("error setting permissions of %s: %v\n", fn, err)
+		w.recordError(err)
+	}
+}
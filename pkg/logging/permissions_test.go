@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileWriterPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not supported on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logDir := filepath.Join(dir, "log")
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          logDir,
+		LogFileName:         "logfile.log",
+		Compress:            true,
+		MaxLogFileSizeBytes: 100,
+		FilePermissions:     0640,
+		DirPermissions:      0750,
+		Chown:               true,
+		UID:                 os.Getuid(),
+		GID:                 os.Getgid(),
+	}, withMinSize(1))
+	assert.NoError(t, err)
+
+	_, err = fw.Write([]byte(randomString(150)))
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Close())
+
+	info, err := os.Stat(logDir)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+
+	files, err := filepath.Glob(filepath.Join(logDir, "logfile*"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+	for _, fn := range files {
+		info, err := os.Stat(fn)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm(), fn)
+	}
+}
@@ -0,0 +1,216 @@
+package logging
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// packageLevel is the level of a package given in a level spec.
+type packageLevel struct {
+	pkg   string
+	level zapcore.Level
+}
+
+// levelRules are the package levels given in a level spec, longest package
+// first so the most specific rule wins.
+type levelRules struct {
+	rules []packageLevel
+	min   zapcore.Level
+}
+
+var (
+	// packageLevels holds the rules of the current level spec, if any.
+	packageLevels atomic.Pointer[levelRules]
+
+	// coreLevel is the level enabler used by the cores.  It lets entries
+	// through if either the log level or a package level allows them.  The
+	// package level core does the actual filtering.
+	coreLevel zapcore.LevelEnabler = zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		if atomicLogLevel.Enabled(l) {
+			return true
+		}
+		rules := packageLevels.Load()
+		return rules != nil && l >= rules.min
+	})
+
+	// ourPackage is the import path of this package.
+	ourPackage = reflect.TypeOf(packageLevel{}).PkgPath()
+)
+
+// SetLevelSpec sets the log level and, optionally, levels for individual
+// packages.  The spec is a comma separated list of a default level and
+// package=level pairs, eg. "info,storage=debug,net/http=warn".  A package
+// matches if its import path is the given path or ends with it, and the rule
+// applies to its subpackages as well.  The level of an entry is decided by
+// the package of the code that logged it.
+func SetLevelSpec(spec string) error {
+	defaultLevel, rules, err := parseLevelSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	if defaultLevel != nil {
+		SetLevel(*defaultLevel)
+	}
+	packageLevels.Store(rules)
+	return nil
+}
+
+// parseLevelSpec parses a level spec.  The default level is nil if the spec
+// doesn't give one and the rules are nil if there are no package levels.
+func parseLevelSpec(spec string) (*zapcore.Level, *levelRules, error) {
+	var (
+		defaultLevel *zapcore.Level
+		rules        []packageLevel
+	)
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		pkg, levelString, isRule := strings.Cut(part, "=")
+		if !isRule {
+			levelString = pkg
+		}
+
+		var level zapcore.Level
+		err := level.UnmarshalText([]byte(strings.TrimSpace(levelString)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid log level %q in level spec", levelString)
+		}
+
+		if !isRule {
+			defaultLevel = &level
+			continue
+		}
+		pkg = strings.Trim(strings.TrimSpace(pkg), "/")
+		if pkg == "" {
+			return nil, nil, fmt.Errorf("missing package in level spec %q", part)
+		}
+		rules = append(rules, packageLevel{pkg: pkg, level: level})
+	}
+
+	if len(rules) == 0 {
+		return defaultLevel, nil, nil
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].pkg) > len(rules[j].pkg)
+	})
+	min := rules[0].level
+	for _, r := range rules {
+		if r.level < min {
+			min = r.level
+		}
+	}
+	return defaultLevel, &levelRules{rules: rules, min: min}, nil
+}
+
+// levelFor returns the level of the given package and whether a rule
+// matched.
+func (r *levelRules) levelFor(pkg string) (zapcore.Level, bool) {
+	for _, rule := range r.rules {
+		if matchPackage(pkg, rule.pkg) {
+			return rule.level, true
+		}
+	}
+	return 0, false
+}
+
+// matchPackage reports whether the import path pkg matches the package given
+// in a rule.
+func matchPackage(pkg, rule string) bool {
+	for {
+		if pkg == rule || strings.HasSuffix(pkg, "/"+rule) {
+			return true
+		}
+		i := strings.LastIndexByte(pkg, '/')
+		if i < 0 {
+			return false
+		}
+		pkg = pkg[:i]
+	}
+}
+
+// callerPackage returns the import path of the package that logged the
+// current entry.  We walk the stack until we leave zap, the standard library
+// logging adapters and this package.
+func callerPackage() string {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		pkg := funcPackage(frame.Function)
+		switch {
+		case strings.HasPrefix(pkg, "go.uber.org/zap"),
+			pkg == "log/slog",
+			pkg == "github.com/go-logr/logr",
+			pkg == ourPackage && !strings.HasSuffix(frame.File, "_test.go"):
+		default:
+			return pkg
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// funcPackage returns the import path of the package of a function name as
+// returned by runtime.Frame.Function, eg. "net/http.(*Server).Serve".  Dots
+// in the last element of the import path are escaped as %2e in function
+// names.
+func funcPackage(name string) string {
+	slash := strings.LastIndexByte(name, '/')
+	dot := strings.IndexByte(name[slash+1:], '.')
+	if dot >= 0 {
+		name = name[:slash+1+dot]
+	}
+	return strings.ReplaceAll(name, "%2e", ".")
+}
+
+// packageLevelCore is a zapcore.Core which filters entries according to the
+// package levels given by SetLevelSpec.
+type packageLevelCore struct {
+	zapcore.Core
+}
+
+func newPackageLevelCore(core zapcore.Core) zapcore.Core {
+	return &packageLevelCore{Core: core}
+}
+
+func (c *packageLevelCore) Enabled(l zapcore.Level) bool {
+	return coreLevel.Enabled(l)
+}
+
+func (c *packageLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &packageLevelCore{Core: c.Core.With(fields)}
+}
+
+func (c *packageLevelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	rules := packageLevels.Load()
+	if rules == nil {
+		if !atomicLogLevel.Enabled(ent.Level) {
+			return ce
+		}
+		return c.Core.Check(ent, ce)
+	}
+
+	level, ok := rules.levelFor(callerPackage())
+	if !ok {
+		level = atomicLogLevel.Level()
+	}
+	if ent.Level < level {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSetLevelSpec(t *testing.T) {
+	defer SetLevelSpec(defaultLogLevel.String())
+
+	core, logs := observer.New(coreLevel)
+	l := zap.New(newPackageLevelCore(core))
+
+	// a more verbose level for this package
+	assert.NoError(t, SetLevelSpec("warn,storage=error,pkg/logging=debug"))
+	assert.Equal(t, zapcore.WarnLevel, GetLevel())
+	l.Debug("debug")
+	l.Sugar().Debugw("sugared debug")
+
+	// a quieter level for this package
+	assert.NoError(t, SetLevelSpec("info,logging=error"))
+	l.Warn("dropped")
+	l.Error("error")
+
+	// rules for other packages leave us at the default level
+	assert.NoError(t, SetLevelSpec("info,net/http=debug"))
+	l.Debug("dropped")
+	l.Info("info")
+
+	assert.Equal(t, []string{"debug", "sugared debug", "error", "info"}, messages(logs))
+
+	assert.Error(t, SetLevelSpec("info,storage=loud"))
+	assert.Error(t, SetLevelSpec("=debug"))
+}
+
+func TestMatchPackage(t *testing.T) {
+	assert.True(t, matchPackage("net/http", "net/http"))
+	assert.True(t, matchPackage("net/http/httputil", "net/http"))
+	assert.True(t, matchPackage("github.com/foo/bar/storage", "storage"))
+	assert.True(t, matchPackage("github.com/foo/bar/storage/sql", "bar/storage"))
+	assert.False(t, matchPackage("github.com/foo/objectstorage", "storage"))
+	assert.False(t, matchPackage("net/http", "http/httputil"))
+
+	assert.Equal(t, "net/http", funcPackage("net/http.(*Server).Serve"))
+	assert.Equal(t, "github.com/foo/bar.v2", funcPackage("github.com/foo/bar%2ev2.Func"))
+}
+
+func messages(logs *observer.ObservedLogs) []string {
+	var msgs []string
+	for _, e := range logs.All() {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
@@ -0,0 +1,150 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// PrettyPrint converts JSON log lines read from r, as written by the "file",
+// "both" and "container" modes, to the human readable console format and
+// writes them to w.  Lines that are not JSON objects are copied as they are.
+func PrettyPrint(w io.Writer, r io.Reader, c ConsoleConfig) error {
+	enc := c.encoder(TimeConfig{})
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		ent, fields, err := parseJSONEntry(line)
+		if err != nil {
+			_, err = w.Write(append(line, '\n'))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		buf, err := enc.EncodeEntry(ent, fields)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf.Bytes())
+		buf.Free()
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+var errNotJSONObject = errors.New("not a JSON object")
+
+// parseJSONEntry parses a line written by the production JSON encoder.  The
+// fields are returned in the order they appear in the line.
+func parseJSONEntry(line []byte) (zapcore.Entry, []zapcore.Field, error) {
+	var (
+		ent    zapcore.Entry
+		fields []zapcore.Field
+	)
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return ent, nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return ent, nil, errNotJSONObject
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return ent, nil, err
+		}
+		key, _ := tok.(string)
+
+		var value interface{}
+		err = dec.Decode(&value)
+		if err != nil {
+			return ent, nil, err
+		}
+		s, isString := value.(string)
+
+		switch {
+		case key == "level" && isString:
+			err = ent.Level.UnmarshalText([]byte(s))
+			if err != nil {
+				return ent, nil, err
+			}
+		case key == "ts":
+			ent.Time = parseJSONTime(value)
+		case key == "logger" && isString:
+			ent.LoggerName = s
+		case key == "caller" && isString:
+			ent.Caller = parseCaller(s)
+		case key == "msg" && isString:
+			ent.Message = s
+		case key == "stacktrace" && isString:
+			ent.Stack = s
+		default:
+			fields = append(fields, jsonField(key, value))
+		}
+	}
+	return ent, fields, nil
+}
+
+// jsonField returns a field for a value decoded with UseNumber.
+func jsonField(key string, value interface{}) zapcore.Field {
+	if n, ok := value.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return zap.Int64(key, i)
+		}
+		if f, err := n.Float64(); err == nil {
+			return zap.Float64(key, f)
+		}
+	}
+	return zap.Any(key, value)
+}
+
+// parseJSONTime parses timestamps written as seconds since the epoch or as
+// strings in the supported time formats.
+func parseJSONTime(value interface{}) time.Time {
+	switch ts := value.(type) {
+	case json.Number:
+		f, err := ts.Float64()
+		if err != nil {
+			return time.Time{}
+		}
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9))
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700"} {
+			t, err := time.Parse(layout, ts)
+			if err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// parseCaller parses a caller of the form "dir/file.go:42".
+func parseCaller(s string) zapcore.EntryCaller {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return zapcore.EntryCaller{Defined: true, File: s}
+	}
+	line, _ := strconv.Atoi(s[i+1:])
+	return zapcore.EntryCaller{Defined: true, File: s[:i], Line: line}
+}
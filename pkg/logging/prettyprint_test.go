@@ -0,0 +1,27 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrettyPrint(t *testing.T) {
+	in := strings.Join([]string{
+		`{"level":"warn","ts":1714557600.5,"logger":"db","caller":"storage/db.go:42","msg":"slow query","duration":2.5,"rows":10,"user":{"id":"42"}}`,
+		`{"level":"error","ts":"2024-05-01T10:00:00.000Z","msg":"failed","stacktrace":"main.main()\n\tmain.go:12"}`,
+		`not json`,
+	}, "\n")
+
+	var out bytes.Buffer
+	assert.NoError(t, PrettyPrint(&out, strings.NewReader(in), ConsoleConfig{}))
+
+	lines := strings.Split(out.String(), "\n")
+	assert.Contains(t, lines[0], "WARN\tdb\tstorage/db.go:42\tslow query\t"+`{"duration": 2.5, "rows": 10, "user": {"id":"42"}}`)
+	assert.Contains(t, lines[1], "2024-05-01T10:00:00.000Z\tERROR\tfailed")
+	assert.Equal(t, "main.main()", lines[2])
+	assert.Equal(t, "\tmain.go:12", lines[3])
+	assert.Equal(t, "not json", lines[4])
+}
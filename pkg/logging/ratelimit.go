@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RateLimitConfig configures rate limiting of identical messages.
+type RateLimitConfig struct {
+	// Limit is the number of entries with the same key logged per Interval.
+	// Rate limiting is disabled if Limit is 0.
+	Limit int
+	// Interval is the rate limiting interval.  Defaults to one minute.
+	Interval time.Duration
+}
+
+const (
+	// rateLimitKeyField is the name of the field added by RateLimitKey.
+	rateLimitKeyField = "rateLimitKey"
+
+	defaultRateLimitInterval = time.Minute
+
+	// maxRateLimitKeys is the number of keys we track before we start
+	// forgetting keys whose interval has passed.
+	maxRateLimitKeys = 10000
+)
+
+// RateLimitKey returns a field which sets the key used for rate limiting.
+// By default entries are rate limited by their message.  The field itself is
+// not logged.
+func RateLimitKey(key string) zap.Field {
+	return zap.String(rateLimitKeyField, key)
+}
+
+// rateBucket counts the entries for a key in the current interval.
+type rateBucket struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// rateLimiter is the state shared between a rate limiting core and the
+// cores derived from it.
+type rateLimiter struct {
+	config  RateLimitConfig
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+}
+
+// allow reports whether an entry with the given key should be logged.  If a
+// new interval has started it also returns the number of entries suppressed
+// during the previous interval.
+func (l *rateLimiter) allow(key string, t time.Time) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	suppressed := 0
+	b := l.buckets[key]
+	if b == nil || t.Sub(b.start) >= l.config.Interval {
+		if b != nil {
+			suppressed = b.suppressed
+		} else if len(l.buckets) >= maxRateLimitKeys {
+			l.prune(t)
+		}
+		b = &rateBucket{start: t}
+		l.buckets[key] = b
+	}
+
+	b.count++
+	if b.count > l.config.Limit {
+		b.suppressed++
+		return false, 0
+	}
+	return true, suppressed
+}
+
+// prune forgets keys whose interval has passed.  This assumes l.mu is locked.
+func (l *rateLimiter) prune(t time.Time) {
+	for key, b := range l.buckets {
+		if t.Sub(b.start) >= l.config.Interval {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimitCore is a zapcore.Core which logs at most Limit entries with the
+// same key per Interval.  When a new interval starts for a key a summary
+// entry with the number of suppressed entries is logged.
+type rateLimitCore struct {
+	zapcore.Core
+	limiter *rateLimiter
+	key     string
+}
+
+// NewRateLimitCore wraps core so identical messages are logged at most
+// c.Limit times per c.Interval.  Entries are identified by their message or
+// by the key given with RateLimitKey.
+func NewRateLimitCore(core zapcore.Core, c RateLimitConfig) zapcore.Core {
+	if c.Limit <= 0 {
+		return core
+	}
+	if c.Interval <= 0 {
+		c.Interval = defaultRateLimitInterval
+	}
+	return &rateLimitCore{
+		Core: core,
+		limiter: &rateLimiter{
+			config:  c,
+			buckets: make(map[string]*rateBucket),
+		},
+	}
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	key, fields := extractRateLimitKey(c.key, fields)
+	return &rateLimitCore{
+		Core:    c.Core.With(fields),
+		limiter: c.limiter,
+		key:     key,
+	}
+}
+
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *rateLimitCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key, fields := extractRateLimitKey(c.key, fields)
+	if key == "" {
+		key = ent.Message
+	}
+
+	allowed, suppressed := c.limiter.allow(key, ent.Time)
+	if suppressed > 0 {
+		summary := zapcore.Entry{
+			Level:      ent.Level,
+			Time:       ent.Time,
+			LoggerName: ent.LoggerName,
+			Message:    "suppressed rate limited log entries",
+		}
+		err := c.Core.Write(summary, []zapcore.Field{
+			zap.String("key", key),
+			zap.Int("suppressed", suppressed),
+			zap.Duration("interval", c.limiter.config.Interval),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if !allowed {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// extractRateLimitKey returns the rate limiting key given in fields, or key
+// if there is none, along with the remaining fields.
+func extractRateLimitKey(key string, fields []zapcore.Field) (string, []zapcore.Field) {
+	for i, f := range fields {
+		if f.Key == rateLimitKeyField && f.Type == zapcore.StringType {
+			rest := make([]zapcore.Field, 0, len(fields)-1)
+			rest = append(rest, fields[:i]...)
+			rest = append(rest, fields[i+1:]...)
+			return f.String, rest
+		}
+	}
+	return key, fields
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRateLimitCore(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	assert.Equal(t, core, NewRateLimitCore(core, RateLimitConfig{}))
+
+	rc := NewRateLimitCore(core, RateLimitConfig{Limit: 2, Interval: time.Hour})
+	l := zap.New(rc)
+
+	for i := 0; i < 5; i++ {
+		l.Error("disk full")
+	}
+	// different messages with the same key share a limit
+	keyed := l.With(RateLimitKey("conn"))
+	keyed.Warn("connection to a failed")
+	keyed.Warn("connection to b failed")
+	keyed.Warn("connection to c failed")
+
+	entries := logs.TakeAll()
+	assert.Len(t, entries, 4)
+	assert.Equal(t, "disk full", entries[1].Message)
+	assert.Equal(t, "connection to b failed", entries[3].Message)
+	assert.Empty(t, entries[3].Context)
+
+	// start a new interval to get the summary
+	ent := zapcore.Entry{Level: zapcore.ErrorLevel, Time: time.Now().Add(2 * time.Hour), Message: "disk full"}
+	assert.NoError(t, rc.Write(ent, nil))
+
+	entries = logs.TakeAll()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "suppressed rate limited log entries", entries[0].Message)
+	assert.Equal(t, int64(3), entries[0].ContextMap()["suppressed"])
+	assert.Equal(t, "disk full", entries[1].Message)
+}
@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RecentEntry is a log entry kept in memory, see Recent.
+type RecentEntry struct {
+	zapcore.Entry
+	Fields []zapcore.Field
+}
+
+// ContextMap returns the fields of the entry as a map.
+func (e RecentEntry) ContextMap() map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range e.Fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// ringBuffer holds the most recent entries.
+type ringBuffer struct {
+	mu      sync.Mutex
+	entries []RecentEntry
+	next    int
+	full    bool
+}
+
+// recentEntries is the ring buffer of the current configuration, or nil if
+// recent entries are not kept.
+var recentEntries atomic.Pointer[ringBuffer]
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{entries: make([]RecentEntry, size)}
+}
+
+func (r *ringBuffer) add(e RecentEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[r.next] = e
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// last returns the n most recent entries, oldest first.  If n is 0 or
+// larger than the number of entries all entries are returned.
+func (r *ringBuffer) last(n int) []RecentEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.entries)
+	}
+	if n <= 0 || n > count {
+		n = count
+	}
+
+	out := make([]RecentEntry, n)
+	start := r.next - n
+	if start < 0 {
+		start += len(r.entries)
+	}
+	for i := range out {
+		out[i] = r.entries[(start+i)%len(r.entries)]
+	}
+	return out
+}
+
+// resize returns a ring buffer of the given size holding the most recent
+// entries of r.
+func (r *ringBuffer) resize(size int) *ringBuffer {
+	resized := newRingBuffer(size)
+	for _, e := range r.last(size) {
+		resized.add(e)
+	}
+	return resized
+}
+
+// Recent returns the n most recent log entries, oldest first, regardless of
+// the log level.  If n is 0 all entries kept are returned.  Entries are only
+// kept if Config.RecentEntries is set.
+func Recent(n int) []RecentEntry {
+	r := recentEntries.Load()
+	if r == nil {
+		return nil
+	}
+	return r.last(n)
+}
+
+// configureRecent sets up the ring buffer for size entries, keeping the
+// entries we already have.  It returns nil if size is 0.
+func configureRecent(size int) *ringBuffer {
+	if size <= 0 {
+		recentEntries.Store(nil)
+		return nil
+	}
+
+	r := recentEntries.Load()
+	switch {
+	case r == nil:
+		r = newRingBuffer(size)
+	case len(r.entries) != size:
+		r = r.resize(size)
+	}
+	recentEntries.Store(r)
+	return r
+}
+
+// recentCore adds all entries to a ring buffer.
+type recentCore struct {
+	ring   *ringBuffer
+	fields []zapcore.Field
+}
+
+func (c *recentCore) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (c *recentCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	return &recentCore{ring: c.ring, fields: all}
+}
+
+func (c *recentCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *recentCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	c.ring.add(RecentEntry{Entry: ent, Fields: all})
+	return nil
+}
+
+func (c *recentCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func recentMessages(entries []RecentEntry) []string {
+	var msgs []string
+	for _, e := range entries {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+func TestRingBuffer(t *testing.T) {
+	r := newRingBuffer(3)
+	assert.Empty(t, r.last(0))
+
+	for i := 0; i < 5; i++ {
+		r.add(RecentEntry{Entry: zapcore.Entry{Message: strconv.Itoa(i)}})
+	}
+	assert.Equal(t, []string{"2", "3", "4"}, recentMessages(r.last(0)))
+	assert.Equal(t, []string{"3", "4"}, recentMessages(r.last(2)))
+	assert.Equal(t, []string{"2", "3", "4"}, recentMessages(r.last(10)))
+
+	assert.Equal(t, []string{"3", "4"}, recentMessages(r.resize(2).last(0)))
+	assert.Equal(t, []string{"2", "3", "4"}, recentMessages(r.resize(10).last(0)))
+}
+
+func TestRecent(t *testing.T) {
+	defer Configure(Config{})
+	assert.NoError(t, Configure(Config{RecentEntries: 3}))
+
+	// debug entries are kept even though they are not logged
+	Get().Debug("one")
+	Get().With(zap.String("user", "alice")).Info("two")
+	Get().Debug("three", zap.Int("n", 3))
+
+	entries := Recent(0)
+	assert.Equal(t, []string{"one", "two", "three"}, recentMessages(entries))
+	assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+	assert.Equal(t, map[string]interface{}{"user": "alice"}, entries[1].ContextMap())
+	assert.Equal(t, []string{"three"}, recentMessages(Recent(1)))
+
+	assert.NoError(t, Configure(Config{}))
+	assert.Nil(t, Recent(0))
+}
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RecentHandler returns an http.Handler which dumps the recent entries kept
+// in memory, see Recent.  It is meant to be mounted on an admin port so
+// operators can look at the recent logs of a process, including debug
+// entries, without access to its log files.
+//
+// The query parameter "n" limits the number of entries, "level" gives the
+// lowest level included (eg. ?level=warn) and "format" selects "json" (the
+// default, one entry per line) or "text" (the console format).
+func RecentHandler() http.Handler {
+	return http.HandlerFunc(serveRecent)
+}
+
+func serveRecent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if recentEntries.Load() == nil {
+		http.Error(w, "recent entries are not kept, see Config.RecentEntries", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+
+	n := 0
+	if q := query.Get("n"); q != "" {
+		var err error
+		n, err = strconv.Atoi(q)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("invalid number of entries %q", q), http.StatusBadRequest)
+			return
+		}
+	}
+
+	minLevel := zapcore.DebugLevel
+	if q := query.Get("level"); q != "" {
+		err := minLevel.UnmarshalText([]byte(q))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid log level %q", q), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var enc zapcore.Encoder
+	switch query.Get("format") {
+	case "", "json":
+		enc = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	case "text":
+		enc = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	default:
+		http.Error(w, fmt.Sprintf("invalid format %q, valid formats are json and text", query.Get("format")), http.StatusBadRequest)
+		return
+	}
+
+	// filter by level before limiting so we return the n most recent
+	// entries at the given level
+	var entries []RecentEntry
+	for _, e := range Recent(0) {
+		if e.Level >= minLevel {
+			entries = append(entries, e)
+		}
+	}
+	if n > 0 && n < len(entries) {
+		entries = entries[len(entries)-n:]
+	}
+
+	for _, e := range entries {
+		buf, err := enc.EncodeEntry(e.Entry, e.Fields)
+		if err != nil {
+			continue
+		}
+		_, err = w.Write(buf.Bytes())
+		buf.Free()
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecentHandler(t *testing.T) {
+	server := httptest.NewServer(RecentHandler())
+	defer server.Close()
+
+	get := func(query string) (int, string) {
+		res, err := http.Get(server.URL + query)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+		return res.StatusCode, string(body)
+	}
+
+	// recent entries are not kept
+	status, _ := get("")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	defer Configure(Config{})
+	assert.NoError(t, Configure(Config{RecentEntries: 10}))
+	Get().Debug("one")
+	Get().Warn("two")
+	Get().Info("three")
+
+	status, body := get("")
+	assert.Equal(t, http.StatusOK, status)
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"msg":"one"`)
+
+	status, body = get("?n=1&level=warn&format=text")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "WARN")
+	assert.Contains(t, body, "two")
+	assert.NotContains(t, body, "three")
+
+	status, _ = get("?n=-1")
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = get("?format=xml")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
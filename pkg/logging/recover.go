@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"go.uber.org/zap"
+)
+
+// RecoverAndLog logs a panic with its stack trace and panics again with the
+// same value.  It must be deferred directly, eg.
+//
+//	defer logging.RecoverAndLog()
+func RecoverAndLog() {
+	if r := recover(); r != nil {
+		logPanic(r)
+		panic(r)
+	}
+}
+
+// Go runs f in a new goroutine.  If f panics the panic is logged with its
+// stack trace and the process exits through Exit, so the sinks are flushed
+// and closed before the process dies.
+func Go(f func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logPanic(r)
+				// 2 is the exit code of an unrecovered panic
+				Exit(2)
+			}
+		}()
+		f()
+	}()
+}
+
+// logPanic logs the panic value r along with the stack of the panicking
+// goroutine and flushes the package logger.
+func logPanic(r interface{}) {
+	logger.Error("panic", zap.Any("panic", r), zap.Stack("stacktrace"))
+	logger.Sync()
+}
@@ -0,0 +1,30 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRecoverAndLog(t *testing.T) {
+	tl := NewTestLogger(t)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		defer RecoverAndLog()
+		panic("boom")
+	})
+
+	tl.AssertLogged(zapcore.ErrorLevel, "panic", zap.Any("panic", "boom"))
+	entries := tl.Logged(zapcore.ErrorLevel, "panic")
+	assert.Len(t, entries, 1)
+	assert.Contains(t, entries[0].ContextMap()["stacktrace"], "TestRecoverAndLog")
+
+	// nothing is logged without a panic
+	tl.Reset()
+	func() {
+		defer RecoverAndLog()
+	}()
+	assert.Empty(t, tl.Entries())
+}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactedValue replaces the value of redacted fields.
+const RedactedValue = "[REDACTED]"
+
+// redactRules holds the registered redaction rules.
+type redactRules struct {
+	mu       sync.RWMutex
+	names    map[string]bool
+	patterns []*regexp.Regexp
+	masks    []MaskRule
+}
+
+var redaction = &redactRules{names: make(map[string]bool)}
+
+// RedactFields registers field names whose values are replaced with
+// RedactedValue before they reach any sink, eg. "password", "token" and
+// "authorization".  Names are matched case insensitively.  Only top level
+// fields are redacted, not keys of nested objects.
+func RedactFields(names ...string) {
+	redaction.mu.Lock()
+	defer redaction.mu.Unlock()
+
+	for _, name := range names {
+		redaction.names[strings.ToLower(name)] = true
+	}
+}
+
+// RedactFieldsMatching registers a regular expression for field names whose
+// values are redacted, eg. "(?i)secret".
+func RedactFieldsMatching(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	redaction.mu.Lock()
+	defer redaction.mu.Unlock()
+
+	redaction.patterns = append(redaction.patterns, re)
+	return nil
+}
+
+// ResetRedaction removes all registered redaction and mask rules.
+func ResetRedaction() {
+	redaction.mu.Lock()
+	defer redaction.mu.Unlock()
+
+	redaction.names = make(map[string]bool)
+	redaction.patterns = nil
+	redaction.masks = nil
+}
+
+// redacts reports whether the value of the field with the given name must be
+// redacted.  This assumes r.mu is read locked.
+func (r *redactRules) redacts(name string) bool {
+	if r.names[strings.ToLower(name)] {
+		return true
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact returns fields with the values of redacted fields replaced and the
+// mask rules applied.  The fields are only copied if something changed.
+func (r *redactRules) redact(fields []zapcore.Field) []zapcore.Field {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.names) == 0 && len(r.patterns) == 0 && len(r.masks) == 0 {
+		return fields
+	}
+
+	var redacted []zapcore.Field
+	for i, f := range fields {
+		replacement := zap.String(f.Key, RedactedValue)
+		if !r.redacts(f.Key) {
+			var masked bool
+			replacement, masked = r.maskField(f)
+			if !masked {
+				continue
+			}
+		}
+		if redacted == nil {
+			redacted = make([]zapcore.Field, len(fields))
+			copy(redacted, fields)
+		}
+		redacted[i] = replacement
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// redactCore is a zapcore.Core which redacts fields according to the
+// registered redaction rules before passing them on.
+type redactCore struct {
+	zapcore.Core
+}
+
+// NewRedactCore wraps core so fields registered with RedactFields and
+// RedactFieldsMatching are redacted and the mask rules given to SetMaskRules
+// are applied.  Configure wraps all cores with it.
+func NewRedactCore(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redaction.redact(fields))}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = redaction.maskMessage(ent.Message)
+	return c.Core.Write(ent, redaction.redact(fields))
+}
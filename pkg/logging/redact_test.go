@@ -0,0 +1,30 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactCore(t *testing.T) {
+	defer ResetRedaction()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := zap.New(NewRedactCore(core)).Sugar()
+
+	RedactFields("password", "Authorization")
+	assert.NoError(t, RedactFieldsMatching("(?i)secret"))
+	assert.Error(t, RedactFieldsMatching("("))
+
+	l.With("authorization", "Bearer abc").Infow("login", "user", "bob", "PASSWORD", "hunter2", "clientSecret", 42)
+
+	assert.Equal(t, map[string]interface{}{
+		"authorization": RedactedValue,
+		"user":          "bob",
+		"PASSWORD":      RedactedValue,
+		"clientSecret":  RedactedValue,
+	}, logs.All()[0].ContextMap())
+}
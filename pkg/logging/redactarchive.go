@@ -0,0 +1,180 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ebobo/logging_lab5e_go/internal/logfile"
+)
+
+// redactedMessage replaces the message of masked entries.
+const redactedMessage = "[redacted]"
+
+// RedactOptions configures RedactArchive.
+type RedactOptions struct {
+	// Match selects the entries to redact.
+	Match func(Entry) bool
+	// If Mask is set matching entries are replaced by an entry with only
+	// their time, level and the message "[redacted]" rather than removed,
+	// so the archive still shows that something was logged.
+	Mask bool
+}
+
+// RedactArchive rewrites the archive at path without the entries matched
+// by opts.Match, eg. to handle a GDPR erasure request against historical
+// logs.  The archive keeps its name, compression, permissions and
+// modification time, so it stays in place for the retention policy and
+// ListArchives.  Encrypted archives can't be redacted.  It returns the
+// number of entries redacted; the archive is not rewritten if there are
+// none.
+//
+// Don't use it on a log file that is being written to.
+func RedactArchive(path string, opts RedactOptions) (int, error) {
+	if opts.Match == nil {
+		return 0, fmt.Errorf("no match function given for redacting %s", path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	r, err := openEntries(path)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	r.keepLeading = true
+
+	// The temporary file is hidden and doesn't use the processing
+	// extension so the file writer leaves it alone.
+	tmpPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".redacting")
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmpPath)
+	defer tmp.Close()
+
+	var out io.WriteCloser = nopWriteCloser{tmp}
+	switch filepath.Ext(path) {
+	case "." + compressedExtension:
+		out, err = CodecGzip.newWriter(tmp)
+	case "." + zstdExtension:
+		out, err = CodecZstd.newWriter(tmp)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	redacted := 0
+	w := bufio.NewWriter(out)
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		line := e.Line
+		if !e.Time.IsZero() && opts.Match(e) {
+			redacted++
+			if !opts.Mask {
+				continue
+			}
+			line = maskedLine(e.Line)
+		}
+		_, err = w.WriteString(line + "\n")
+		if err != nil {
+			return 0, err
+		}
+	}
+	if redacted == 0 {
+		return 0, nil
+	}
+
+	err = w.Flush()
+	if err == nil {
+		err = out.Close()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = tmp.Close()
+	}
+	if err == nil {
+		err = os.Chtimes(tmpPath, info.ModTime(), info.ModTime())
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	r.Close()
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return 0, err
+	}
+	return redacted, nil
+}
+
+// maskedLine returns line with everything but the time and level removed
+// and the message replaced by "[redacted]", in the format of line.  Lines
+// following the first, eg. stack traces, are dropped.
+func maskedLine(line string) string {
+	line, _, _ = strings.Cut(line, "\n")
+
+	fields := logfile.Fields(line)
+	if fields == nil {
+		// console format: time, level, message
+		columns := strings.SplitN(line, "\t", 3)
+		if len(columns) < 2 {
+			return columns[0] + "\t" + redactedMessage
+		}
+		return columns[0] + "\t" + columns[1] + "\t" + redactedMessage
+	}
+
+	msgKey := "msg"
+	if _, ok := fields["message"]; ok {
+		msgKey = "message"
+	}
+
+	if strings.HasPrefix(line, "{") {
+		masked := map[string]interface{}{msgKey: redactedMessage}
+		for _, key := range []string{"ts", "@timestamp", "time", "level", "log.level", "severity"} {
+			if v, ok := fields[key]; ok {
+				masked[key] = v
+			}
+		}
+		data, err := json.Marshal(masked)
+		if err != nil {
+			return redactedMessage
+		}
+		return string(data)
+	}
+
+	var pairs []string
+	for _, key := range []string{"ts", "time", "level"} {
+		if v, ok := fields[key]; ok {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", key, v))
+		}
+	}
+	pairs = append(pairs, fmt.Sprintf("%s=%q", msgKey, redactedMessage))
+	return strings.Join(pairs, " ")
+}
+
+// nopWriteCloser adds a no-op Close to a writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
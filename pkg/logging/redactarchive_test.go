@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	matchUser := RedactOptions{Match: func(e Entry) bool { return e.Fields["user_id"] == "42" }}
+	mtime := time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte("" +
+		"# header\n" +
+		`{"level":"info","ts":1714557600,"msg":"login","user_id":"42"}` + "\n" +
+		`{"level":"info","ts":1714557601,"msg":"login","user_id":"7"}` + "\n" +
+		`{"level":"error","ts":1714557602,"msg":"failed","user_id":"42"}` + "\n"))
+	assert.NoError(t, zw.Close())
+	archive := filepath.Join(dir, "test-2024-05-01T10-00-00.00000.log.gz")
+	assert.NoError(t, os.WriteFile(archive, compressed.Bytes(), 0600))
+	assert.NoError(t, os.Chtimes(archive, mtime, mtime))
+
+	n, err := RedactArchive(archive, matchUser)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	readArchive := func(fn string) string {
+		r, err := OpenArchive(fn)
+		assert.NoError(t, err)
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, ""+
+		"# header\n"+
+		`{"level":"info","ts":1714557601,"msg":"login","user_id":"7"}`+"\n", readArchive(archive))
+
+	// the name, permissions and modification time are kept and the
+	// temporary file is gone
+	info, err := os.Stat(archive)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.True(t, mtime.Equal(info.ModTime()))
+	dirEnts, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, dirEnts, 1)
+
+	// nothing to redact
+	n, err = RedactArchive(archive, matchUser)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	plain := filepath.Join(dir, "test-2024-05-01T11-00-00.00000.log")
+	assert.NoError(t, os.WriteFile(plain, []byte(""+
+		"2024-05-01T10:00:00.000Z\tINFO\tlogin\t{\"user_id\": \"42\"}\n"+
+		"2024-05-01T10:00:01.000Z\tERROR\tfailed\t{\"user_id\": \"42\"}\n"+
+		"main.main()\n"+
+		"ts=2024-05-01T10:00:02Z level=info msg=login user_id=42\n"+
+		"ts=2024-05-01T10:00:03Z level=info msg=login user_id=7\n"), 0644))
+
+	matchUser.Mask = true
+	n, err = RedactArchive(plain, matchUser)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, ""+
+		"2024-05-01T10:00:00.000Z\tINFO\t[redacted]\n"+
+		"2024-05-01T10:00:01.000Z\tERROR\t[redacted]\n"+
+		"ts=2024-05-01T10:00:02Z level=info msg=\"[redacted]\"\n"+
+		"ts=2024-05-01T10:00:03Z level=info msg=login user_id=7\n", readArchive(plain))
+
+	assert.Equal(t, `{"level":"info","msg":"[redacted]","ts":1714557600}`,
+		maskedLine(`{"level":"info","ts":1714557600,"msg":"login","user_id":"42"}`))
+
+	_, err = RedactArchive(archive+".age", matchUser)
+	assert.Error(t, err)
+}
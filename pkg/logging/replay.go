@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ebobo/logging_lab5e_go/internal/logfile"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	// Since and Until limit the entries to a period, eg. the duration of
+	// an outage.  A zero Since or Until leaves that end open.
+	Since time.Time
+	Until time.Time
+	// Speed is how much faster than they were logged the entries are
+	// replayed, eg. 1 for the original pace or 60 to replay an hour in a
+	// minute.  If it is 0 the entries are replayed as fast as the core
+	// accepts them.
+	Speed float64
+	// Clock is used for pacing the entries.  Defaults to SystemClock.
+	Clock Clock
+}
+
+// Replay writes the entries of the log files and archives at paths to core,
+// ordered by time like MergeLogs, eg. to backfill a log aggregator after an
+// outage.  The entries keep their original time, level, logger, caller,
+// message, stack trace and fields, and entries at levels not enabled by
+// core are skipped.  Entries in the console format are replayed with the
+// whole line as the message.  Replay stops at the first error returned by
+// core, so an interrupted replay can be resumed with Since set to the time
+// of the last entry replayed.  It returns the number of entries replayed.
+func Replay(ctx context.Context, core zapcore.Core, paths []string, opts ReplayOptions) (int, error) {
+	clk := opts.Clock
+	if clk == nil {
+		clk = SystemClock
+	}
+
+	var first, start time.Time
+	replayed := 0
+	err := mergeEntries(paths, opts.Since, opts.Until, func(_ string, e Entry) error {
+		err := ctx.Err()
+		if err != nil {
+			return err
+		}
+		if e.Time.IsZero() || !core.Enabled(e.Level) {
+			return nil
+		}
+
+		if opts.Speed > 0 {
+			if first.IsZero() {
+				first, start = e.Time, clk.Now()
+			}
+			due := start.Add(time.Duration(float64(e.Time.Sub(first)) / opts.Speed))
+			err := sleepUntil(ctx, clk, due)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = core.Write(replayEntry(e))
+		if err != nil {
+			return err
+		}
+		replayed++
+		return nil
+	})
+	if err != nil {
+		return replayed, err
+	}
+	return replayed, core.Sync()
+}
+
+// sleepUntil waits until the clock reaches t or ctx is done.
+func sleepUntil(ctx context.Context, clk Clock, t time.Time) error {
+	d := t.Sub(clk.Now())
+	if d <= 0 {
+		return nil
+	}
+	timer := clk.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// replayEntry returns the zap entry and fields for writing e to a core.
+func replayEntry(e Entry) (zapcore.Entry, []zapcore.Field) {
+	ent := zapcore.Entry{
+		Level:      e.Level,
+		Time:       e.Time,
+		LoggerName: e.Logger,
+		Message:    e.Message,
+		Stack:      e.Stacktrace,
+	}
+	if line, _, _ := strings.Cut(e.Line, "\n"); logfile.Fields(line) == nil {
+		// the console format, which we can't take apart reliably
+		ent.Message = e.Line
+		return ent, nil
+	}
+	if i := strings.LastIndex(e.Caller, ":"); i > 0 {
+		line, err := strconv.Atoi(e.Caller[i+1:])
+		if err == nil {
+			ent.Caller = zapcore.NewEntryCaller(0, e.Caller[:i], line, true)
+		}
+	}
+
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := make([]zapcore.Field, len(keys))
+	for i, key := range keys {
+		fields[i] = zap.Any(key, e.Fields[key])
+	}
+	return ent, fields
+}
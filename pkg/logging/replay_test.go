@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	api := filepath.Join(dir, "api.log")
+	assert.NoError(t, os.WriteFile(api, []byte(""+
+		`{"level":"debug","ts":1714557600,"msg":"noise"}`+"\n"+
+		`{"level":"info","ts":1714557600,"logger":"api","caller":"api/server.go:42","msg":"request","status":200}`+"\n"+
+		`{"level":"error","ts":1714557660,"msg":"failed","stacktrace":"main.main()"}`+"\n"), 0644))
+	worker := filepath.Join(dir, "worker.log")
+	assert.NoError(t, os.WriteFile(worker, []byte(
+		"2024-05-01T10:00:30.000Z\tWARN\tworker.go:1\tslow\n"), 0644))
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	n, err := Replay(context.Background(), core, []string{api, worker}, ReplayOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	entries := logs.All()
+	assert.Len(t, entries, 3)
+	start := time.Unix(1714557600, 0)
+	assert.True(t, start.Equal(entries[0].Time))
+	assert.Equal(t, "request", entries[0].Message)
+	assert.Equal(t, "api", entries[0].LoggerName)
+	assert.Equal(t, "api/server.go:42", entries[0].Caller.TrimmedPath())
+	assert.Equal(t, map[string]interface{}{"status": float64(200)}, entries[0].ContextMap())
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	assert.Equal(t, "2024-05-01T10:00:30.000Z\tWARN\tworker.go:1\tslow", entries[1].Message)
+	assert.Equal(t, "failed", entries[2].Message)
+	assert.Equal(t, "main.main()", entries[2].Stack)
+
+	// paced at ten times the original speed
+	clock := NewFakeClock(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	core, logs = observer.New(zapcore.InfoLevel)
+	done := make(chan int)
+	go func() {
+		n, err := Replay(context.Background(), core, []string{api, worker}, ReplayOptions{Speed: 10, Clock: clock})
+		assert.NoError(t, err)
+		done <- n
+	}()
+
+	assert.Eventually(t, func() bool { return clock.Timers() == 1 }, 2*time.Second, time.Millisecond)
+	assert.Equal(t, 1, logs.Len())
+	clock.Advance(3 * time.Second)
+	assert.Eventually(t, func() bool { return clock.Timers() == 1 && logs.Len() == 2 }, 2*time.Second, time.Millisecond)
+	clock.Advance(3 * time.Second)
+	assert.Equal(t, 3, <-done)
+
+	// cancelled while waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		assert.Eventually(t, func() bool { return clock.Timers() == 1 }, 2*time.Second, time.Millisecond)
+		cancel()
+	}()
+	core, _ = observer.New(zapcore.InfoLevel)
+	n, err = Replay(ctx, core, []string{api}, ReplayOptions{Speed: 1, Clock: clock})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,203 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archiveFile describes a rotated log file.
+type archiveFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// archives returns the rotated log files belonging to this writer, oldest
+// first.  Files that are being compressed are not included.
+func (w *FileWriter) archives() ([]archiveFile, error) {
+	dirEnts, err := w.fs().ReadDir(w.config.LogDirName)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := w.archivePrefix()
+
+	var archives []archiveFile
+	for _, dirEnt := range dirEnts {
+		name := dirEnt.Name()
+		if dirEnt.IsDir() || dirEnt.Type()&os.ModeSymlink != 0 || name == w.config.LogFileName || !strings.HasPrefix(name, prefix) || strings.HasSuffix(name, "."+processingExtenstion) {
+			continue
+		}
+
+		info, err := dirEnt.Info()
+		if err != nil {
+			continue
+		}
+
+		archives = append(archives, archiveFile{
+			path:    filepath.Join(w.config.LogDirName, name),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].modTime.Before(archives[j].modTime)
+	})
+	return archives, nil
+}
+
+// archivePrefix returns the prefix of the names of our archives, which tells
+// them apart from the files of other writers in the same directory.
+func (w *FileWriter) archivePrefix() string {
+	ext := filepath.Ext(w.config.LogFileName)
+	return strings.TrimSuffix(w.config.LogFileName, ext) + "-"
+}
+
+// retentionPolicy returns the configured RetentionPolicy, or the policy
+// given by MaxArchivedFiles and MaxTotalSizeBytes.  It returns nil if there
+// is nothing to enforce.  MaxTimeTimeToKeep is handled by cleanup.
+func (w *FileWriter) retentionPolicy() RetentionPolicy {
+	if w.config.RetentionPolicy != nil {
+		return w.config.RetentionPolicy
+	}
+
+	var policies anyRetention
+	if w.config.MaxArchivedFiles > 0 {
+		policies = append(policies, CountRetention(w.config.MaxArchivedFiles))
+	}
+	if w.config.MaxTotalSizeBytes > 0 {
+		policies = append(policies, TotalSizeRetention(w.config.MaxTotalSizeBytes))
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+	return policies
+}
+
+// isCompressing reports whether the archive at path is being compressed.
+func (w *FileWriter) isCompressing(path string) bool {
+	w.compressingMu.Lock()
+	defer w.compressingMu.Unlock()
+	return w.compressing[path]
+}
+
+// enforceRetention deletes the archives expired by the retention policy.
+// Archives that are being compressed are left alone.  It returns the number
+// of archives deleted.
+func (w *FileWriter) enforceRetention() (int, error) {
+	policy := w.retentionPolicy()
+	if policy == nil {
+		return 0, nil
+	}
+
+	archiveFiles, err := w.archives()
+	if err != nil {
+		return 0, err
+	}
+
+	current := FileStats{
+		Path:     w.logFileNameFullPath,
+		OpenedAt: w.openedAt,
+		Now:      w.clock().Now(),
+	}
+	info, err := w.fs().Stat(w.logFileNameFullPath)
+	if err == nil {
+		current.Size = info.Size()
+	}
+
+	archives := make([]Archive, len(archiveFiles))
+	for i, archive := range archiveFiles {
+		archives[i] = Archive{
+			Name:    filepath.Base(archive.path),
+			Size:    archive.size,
+			ModTime: archive.modTime,
+		}
+	}
+
+	removed := 0
+	for _, archive := range policy.Expired(archives, current) {
+		path := filepath.Join(w.config.LogDirName, archive.Name)
+		if w.isCompressing(path) {
+			continue
+		}
+		err := w.fs().Remove(path)
+		if err != nil {
+			fmt.Printf("error removing %s: %v\n", path, err)
+			continue
+		}
+		removed++
+		fmt.Printf("%s removed by retention policy\n", path)
+	}
+
+	return removed, nil
+}
+
+// Purge deletes the archives older than olderThan and then enforces the
+// retention policy.  Use it to reclaim disk space on demand, eg. before a
+// disk space alert fires, rather than waiting for the next rotation.  If
+// olderThan is 0 only the retention policy is enforced.  Archives that are
+// being compressed are left alone.  It returns the number of archives
+// deleted.
+func (w *FileWriter) Purge(olderThan time.Duration) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.purge(olderThan)
+}
+
+// PurgeArchives purges the archives of the log file given by c like
+// FileWriter.Purge, without opening the log file.  This is meant for tools
+// cleaning up after another process.
+func PurgeArchives(c FileWriterConfig, olderThan time.Duration) (int, error) {
+	w := &FileWriter{
+		config:              c,
+		logFileNameFullPath: filepath.Join(c.LogDirName, c.LogFileName),
+	}
+	return w.purge(olderThan)
+}
+
+// Purge purges the archives of the file writers of the package logger, see
+// FileWriter.Purge.
+func Purge(olderThan time.Duration) (int, error) {
+	removed := 0
+	var errs []error
+	for _, fw := range currentFileWriters() {
+		n, err := fw.Purge(olderThan)
+		removed += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return removed, errors.Join(errs...)
+}
+
+func (w *FileWriter) purge(olderThan time.Duration) (int, error) {
+	archives, err := w.archives()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	if olderThan > 0 {
+		for _, archive := range archives {
+			if w.clock().Now().Sub(archive.modTime) <= olderThan || w.isCompressing(archive.path) {
+				continue
+			}
+			err := w.fs().Remove(archive.path)
+			if err != nil {
+				fmt.Printf("error removing %s: %v\n", archive.path, err)
+				continue
+			}
+			removed++
+			fmt.Printf("%s removed by purge\n", archive.path)
+		}
+	}
+
+	n, err := w.enforceRetention()
+	return removed + n, err
+}
@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"sort"
+	"time"
+)
+
+// Archive describes a rotated log file for a RetentionPolicy.
+type Archive struct {
+	// Name is the file name of the archive in the log directory.
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// RetentionPolicy decides which archives a FileWriter deletes.  It is asked
+// at startup, after each rotation and every CleanupInterval.  Implement this
+// interface to encode custom rules, eg. keeping archives for a compliance
+// period.
+type RetentionPolicy interface {
+	// Expired returns the archives to delete.  The archives are sorted
+	// oldest first, and current describes the active log file.
+	Expired(archives []Archive, current FileStats) []Archive
+}
+
+// RetentionPolicyFunc adapts a function to a RetentionPolicy.
+type RetentionPolicyFunc func(archives []Archive, current FileStats) []Archive
+
+// Expired calls f.
+func (f RetentionPolicyFunc) Expired(archives []Archive, current FileStats) []Archive {
+	return f(archives, current)
+}
+
+// ageRetention deletes archives older than a duration.
+type ageRetention time.Duration
+
+// AgeRetention deletes archives last modified more than maxAge ago.
+func AgeRetention(maxAge time.Duration) RetentionPolicy {
+	return ageRetention(maxAge)
+}
+
+func (p ageRetention) Expired(archives []Archive, current FileStats) []Archive {
+	var expired []Archive
+	for _, archive := range archives {
+		if current.Now.Sub(archive.ModTime) > time.Duration(p) {
+			expired = append(expired, archive)
+		}
+	}
+	return expired
+}
+
+// countRetention keeps a number of archives.
+type countRetention int
+
+// CountRetention keeps the maxArchives most recent archives and deletes the
+// rest.
+func CountRetention(maxArchives int) RetentionPolicy {
+	return countRetention(maxArchives)
+}
+
+func (p countRetention) Expired(archives []Archive, current FileStats) []Archive {
+	if len(archives) <= int(p) {
+		return nil
+	}
+	return archives[:len(archives)-int(p)]
+}
+
+// totalSizeRetention limits the size of the log file and its archives.
+type totalSizeRetention int64
+
+// TotalSizeRetention deletes the oldest archives until the active log file
+// and the archives take up no more than maxBytes.
+func TotalSizeRetention(maxBytes int64) RetentionPolicy {
+	return totalSizeRetention(maxBytes)
+}
+
+func (p totalSizeRetention) Expired(archives []Archive, current FileStats) []Archive {
+	total := current.Size
+	for _, archive := range archives {
+		total += archive.Size
+	}
+
+	n := 0
+	for n < len(archives) && total > int64(p) {
+		total -= archives[n].Size
+		n++
+	}
+	return archives[:n]
+}
+
+// anyRetention deletes the archives expired by any of its policies.
+type anyRetention []RetentionPolicy
+
+// AnyRetention deletes the archives expired by any of policies, eg.
+// AnyRetention(AgeRetention(30*24*time.Hour), TotalSizeRetention(10<<30)).
+func AnyRetention(policies ...RetentionPolicy) RetentionPolicy {
+	return anyRetention(policies)
+}
+
+func (p anyRetention) Expired(archives []Archive, current FileStats) []Archive {
+	seen := map[string]bool{}
+	var expired []Archive
+	for _, policy := range p {
+		for _, archive := range policy.Expired(archives, current) {
+			if !seen[archive.Name] {
+				seen[archive.Name] = true
+				expired = append(expired, archive)
+			}
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].ModTime.Before(expired[j].ModTime)
+	})
+	return expired
+}
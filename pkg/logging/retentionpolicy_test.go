@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionPolicies(t *testing.T) {
+	now := time.Now()
+	archives := []Archive{
+		{Name: "a", Size: 100, ModTime: now.Add(-72 * time.Hour)},
+		{Name: "b", Size: 100, ModTime: now.Add(-48 * time.Hour)},
+		{Name: "c", Size: 100, ModTime: now.Add(-time.Hour)},
+	}
+	current := FileStats{Size: 50, Now: now}
+
+	names := func(archives []Archive) []string {
+		var names []string
+		for _, a := range archives {
+			names = append(names, a.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"a", "b"}, names(AgeRetention(24*time.Hour).Expired(archives, current)))
+	assert.Equal(t, []string{"a"}, names(CountRetention(2).Expired(archives, current)))
+	assert.Empty(t, CountRetention(3).Expired(archives, current))
+	// the active log file counts towards the total size
+	assert.Equal(t, []string{"a", "b"}, names(TotalSizeRetention(200).Expired(archives, current)))
+	assert.Equal(t, []string{"a", "b"}, names(AnyRetention(CountRetention(2), AgeRetention(24*time.Hour)).Expired(archives, current)))
+}
+
+func TestFileWriterRetentionPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// keep only the archives of the last rotations, ignoring MaxArchivedFiles
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		MaxLogFileSizeBytes: 100,
+		MaxArchivedFiles:    1,
+	}, WithRetentionPolicy(RetentionPolicyFunc(func(archives []Archive, current FileStats) []Archive {
+		for _, a := range archives {
+			assert.True(t, strings.HasPrefix(a.Name, "logfile-"))
+		}
+		return CountRetention(3).Expired(archives, current)
+	})), withMinSize(1))
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	for i := 0; i < 10; i++ {
+		_, err := fw.Write([]byte(randomString(60)))
+		assert.NoError(t, err)
+		// make sure the archives get distinct names and modification times
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "logfile-*"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+}
@@ -0,0 +1,93 @@
+package logging
+
+import "time"
+
+// FileStats describes the current log file for a RotationPolicy.
+type FileStats struct {
+	// Path is the path of the log file.
+	Path string
+	// Size is the size of the log file in bytes, including the header.
+	Size int64
+	// OpenedAt is when the log file was opened, at startup or after the
+	// last rotation.
+	OpenedAt time.Time
+	// Now is the current time according to the Clock of the FileWriter.
+	Now time.Time
+}
+
+// RotationPolicy decides when a FileWriter rotates the log file.  It is
+// asked after every write, so it should be cheap.  Implement this interface
+// for custom triggers, eg. a marker file dropped by an operator.
+type RotationPolicy interface {
+	ShouldRotate(stats FileStats) bool
+}
+
+// RotationPolicyFunc adapts a function to a RotationPolicy.
+type RotationPolicyFunc func(stats FileStats) bool
+
+// ShouldRotate calls f.
+func (f RotationPolicyFunc) ShouldRotate(stats FileStats) bool {
+	return f(stats)
+}
+
+// sizePolicy rotates the log file once it is larger than a number of bytes.
+type sizePolicy int64
+
+// SizePolicy rotates the log file once it is larger than maxBytes.  This is
+// the policy used if FileWriterConfig.RotationPolicy is unset.
+func SizePolicy(maxBytes int64) RotationPolicy {
+	return sizePolicy(maxBytes)
+}
+
+func (p sizePolicy) ShouldRotate(stats FileStats) bool {
+	return stats.Size > int64(p)
+}
+
+// agePolicy rotates the log file once it has been open for a duration.
+type agePolicy time.Duration
+
+// AgePolicy rotates the log file on the first write after it has been open
+// for maxAge.  Use RotateInterval to rotate without waiting for a write.
+func AgePolicy(maxAge time.Duration) RotationPolicy {
+	return agePolicy(maxAge)
+}
+
+func (p agePolicy) ShouldRotate(stats FileStats) bool {
+	return stats.Now.Sub(stats.OpenedAt) >= time.Duration(p)
+}
+
+// anyPolicy rotates the log file when one of its policies does.
+type anyPolicy []RotationPolicy
+
+// AnyPolicy rotates the log file when any of policies does, eg.
+// AnyPolicy(SizePolicy(100<<20), AgePolicy(24*time.Hour)).
+func AnyPolicy(policies ...RotationPolicy) RotationPolicy {
+	return anyPolicy(policies)
+}
+
+func (p anyPolicy) ShouldRotate(stats FileStats) bool {
+	for _, policy := range p {
+		if policy.ShouldRotate(stats) {
+			return true
+		}
+	}
+	return false
+}
+
+// allPolicy rotates the log file when all of its policies do.
+type allPolicy []RotationPolicy
+
+// AllPolicy rotates the log file when all of policies do, eg. when it is
+// both a day old and larger than a megabyte.
+func AllPolicy(policies ...RotationPolicy) RotationPolicy {
+	return allPolicy(policies)
+}
+
+func (p allPolicy) ShouldRotate(stats FileStats) bool {
+	for _, policy := range p {
+		if !policy.ShouldRotate(stats) {
+			return false
+		}
+	}
+	return len(p) > 0
+}
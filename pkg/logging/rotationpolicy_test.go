@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotationPolicies(t *testing.T) {
+	now := time.Now()
+	small := FileStats{Size: 10, OpenedAt: now.Add(-time.Minute), Now: now}
+	old := FileStats{Size: 10, OpenedAt: now.Add(-2 * time.Hour), Now: now}
+	large := FileStats{Size: 2000, OpenedAt: now, Now: now}
+
+	size := SizePolicy(1000)
+	assert.False(t, size.ShouldRotate(small))
+	assert.True(t, size.ShouldRotate(large))
+
+	age := AgePolicy(time.Hour)
+	assert.False(t, age.ShouldRotate(small))
+	assert.True(t, age.ShouldRotate(old))
+
+	anyOf := AnyPolicy(size, age)
+	assert.False(t, anyOf.ShouldRotate(small))
+	assert.True(t, anyOf.ShouldRotate(old))
+	assert.True(t, anyOf.ShouldRotate(large))
+
+	allOf := AllPolicy(size, age)
+	assert.False(t, allOf.ShouldRotate(old))
+	assert.True(t, allOf.ShouldRotate(FileStats{Size: 2000, OpenedAt: old.OpenedAt, Now: now}))
+	assert.False(t, AllPolicy().ShouldRotate(large))
+}
+
+func TestFileWriterRotationPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// rotate when an operator drops a marker file
+	marker := filepath.Join(dir, "rotate-now")
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:  dir,
+		LogFileName: "logfile.log",
+	}, WithRotationPolicy(RotationPolicyFunc(func(FileStats) bool {
+		return os.Remove(marker) == nil
+	})))
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	_, err = fw.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, fw.Stats().Archives)
+
+	assert.NoError(t, ioutil.WriteFile(marker, nil, 0644))
+	_, err = fw.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fw.Stats().Archives)
+}
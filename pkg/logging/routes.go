@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"io"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FileRoute routes log entries to a file of its own.
+type FileRoute struct {
+	// Level decides which entries are written to the file, eg.
+	// zapcore.ErrorLevel for Error and above.  If it is nil all entries
+	// enabled by the log level are written.
+	Level zapcore.LevelEnabler
+	// Loggers lists the named loggers, eg. "access", whose entries are
+	// written to the file.  Entries of their child loggers, eg.
+	// "access.admin", are written as well.  Routes without Loggers get the
+	// entries of all loggers not listed by other routes.
+	Loggers []string
+	// FileWriter configures the file.
+	FileWriter FileWriterConfig
+}
+
+// fileCores returns the core writing to the files of the configuration along
+// with their FileWriters.
+func fileCores(c Config) (zapcore.Core, []io.Closer, error) {
+	routes := c.FileRoutes
+	if len(routes) == 0 {
+		routes = []FileRoute{{FileWriter: c.FileWriter}}
+	}
+
+	// loggers listed by some route are kept out of the routes without
+	// loggers
+	var routed []string
+	for _, route := range routes {
+		routed = append(routed, route.Loggers...)
+	}
+
+	cores := make([]zapcore.Core, 0, len(routes))
+	closers := make([]io.Closer, 0, len(routes))
+	for _, route := range routes {
+		enc := structuredEncoder(c)
+		if c.FileHeader && route.FileWriter.Header == nil {
+			route.FileWriter.Header = fileHeader(c, route.FileWriter, enc.Clone())
+		}
+		fw, err := newFileWriter(route.FileWriter)
+		if err != nil {
+			for _, closer := range closers {
+				closer.Close()
+			}
+			return nil, nil, err
+		}
+		closers = append(closers, fw)
+		core := fileCore(route, fw, enc, c.levelEnabler())
+		if len(route.Loggers) > 0 {
+			core = &loggerFilterCore{Core: core, loggers: route.Loggers, include: true}
+		} else if len(routed) > 0 {
+			core = &loggerFilterCore{Core: core, loggers: routed, include: false}
+		}
+		cores = append(cores, core)
+	}
+
+	return newLevelTee(cores...), closers, nil
+}
+
+// fileCore returns the core writing the entries of route enabled by level to
+// fw.
+func fileCore(route FileRoute, fw *FileWriter, enc zapcore.Encoder, level zapcore.LevelEnabler) zapcore.Core {
+	enab := level
+	if route.Level != nil {
+		enab = zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return route.Level.Enabled(l) && level.Enabled(l)
+		})
+	}
+
+	core := newFileWriterCore(enc, fw, enab)
+	if route.FileWriter.FsyncOnError {
+		core = syncOnErrorCore{core}
+	}
+	return core
+}
+
+// loggerFilterCore passes on the entries of the given named loggers and their
+// children, or, if include is false, the entries of all other loggers.
+type loggerFilterCore struct {
+	zapcore.Core
+	loggers []string
+	include bool
+}
+
+func (c *loggerFilterCore) match(name string) bool {
+	for _, l := range c.loggers {
+		if name == l || strings.HasPrefix(name, l+".") {
+			return c.include
+		}
+	}
+	return !c.include
+}
+
+func (c *loggerFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &loggerFilterCore{Core: c.Core.With(fields), loggers: c.loggers, include: c.include}
+}
+
+func (c *loggerFilterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.match(ent.LoggerName) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+func (c *loggerFilterCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.match(ent.LoggerName) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
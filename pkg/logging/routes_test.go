@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestFileRoutes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer Configure(Config{})
+	assert.NoError(t, Configure(Config{
+		Mode: "file",
+		FileRoutes: []FileRoute{
+			{FileWriter: FileWriterConfig{LogDirName: dir, LogFileName: "all.log"}},
+			{Level: zapcore.ErrorLevel, FileWriter: FileWriterConfig{LogDirName: dir, LogFileName: "errors.log"}},
+		},
+	}))
+
+	Get().Info("info message")
+	Get().Error("error message")
+	assert.NoError(t, Configure(Config{}))
+
+	all, err := ioutil.ReadFile(filepath.Join(dir, "all.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(all), "info message")
+	assert.Contains(t, string(all), "error message")
+
+	errors, err := ioutil.ReadFile(filepath.Join(dir, "errors.log"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(errors), "info message")
+	assert.Contains(t, string(errors), "error message")
+}
+
+func TestFileRoutesLoggers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer Configure(Config{})
+	assert.NoError(t, Configure(Config{
+		Mode: "file",
+		FileRoutes: []FileRoute{
+			{Loggers: []string{"access"}, FileWriter: FileWriterConfig{LogDirName: dir, LogFileName: "access.log"}},
+			{FileWriter: FileWriterConfig{LogDirName: dir, LogFileName: "app.log"}},
+		},
+	}))
+
+	Get().Named("access").Info("GET /")
+	Get().Named("access").Named("admin").Info("GET /admin")
+	Get().Named("accessory").Info("app message")
+	Get().Info("unnamed message")
+	assert.NoError(t, Configure(Config{}))
+
+	access, err := ioutil.ReadFile(filepath.Join(dir, "access.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(access), "GET /")
+	assert.Contains(t, string(access), "GET /admin")
+	assert.NotContains(t, string(access), "message")
+
+	app, err := ioutil.ReadFile(filepath.Join(dir, "app.log"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(app), "GET")
+	assert.Contains(t, string(app), "app message")
+	assert.Contains(t, string(app), "unnamed message")
+}
+
+func TestFileConfigRoutes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configfile-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logging.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+mode: file
+file:
+  dir: /var/log/app
+  maxSizeMB: 5
+routes:
+  - file:
+      name: all.log
+  - level: error
+    loggers: [access]
+    file:
+      name: errors.log
+      maxSizeMB: 1
+`), 0644))
+
+	fc, err := ReadConfigFile(path)
+	assert.NoError(t, err)
+
+	c := fc.Config()
+	assert.Len(t, c.FileRoutes, 2)
+	assert.Nil(t, c.FileRoutes[0].Level)
+	assert.Equal(t, "all.log", c.FileRoutes[0].FileWriter.LogFileName)
+	assert.Equal(t, int64(5*1024*1024), c.FileRoutes[0].FileWriter.MaxLogFileSizeBytes)
+	assert.Equal(t, zapcore.ErrorLevel, c.FileRoutes[1].Level)
+	assert.Equal(t, []string{"access"}, c.FileRoutes[1].Loggers)
+	assert.Equal(t, "/var/log/app", c.FileRoutes[1].FileWriter.LogDirName)
+	assert.Equal(t, "errors.log", c.FileRoutes[1].FileWriter.LogFileName)
+	assert.Equal(t, int64(1024*1024), c.FileRoutes[1].FileWriter.MaxLogFileSizeBytes)
+
+	assert.NoError(t, os.WriteFile(path, []byte("routes:\n  - level: loud\n"), 0644))
+	_, err = ReadConfigFile(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingConfig configures sampling of log entries.  Within each Tick the
+// first Initial entries with a given level and message are logged, after that
+// only every Thereafter'th entry is logged.  This keeps log storms from hot
+// loops from overwhelming the sinks.
+type SamplingConfig struct {
+	// Initial is the number of entries logged per Tick before sampling
+	// kicks in.  Sampling is disabled if Initial is 0.
+	Initial int
+	// Thereafter is the sampling rate once Initial entries have been
+	// logged.  If Thereafter is 0 all further entries within the Tick are
+	// dropped.
+	Thereafter int
+	// Tick is the sampling interval.  Defaults to one second.
+	Tick time.Duration
+}
+
+const defaultSamplingTick = time.Second
+
+// sampledCore wraps core in a sampler if sampling is enabled.
+func sampledCore(c SamplingConfig, core zapcore.Core) zapcore.Core {
+	if c.Initial <= 0 {
+		return core
+	}
+
+	tick := c.Tick
+	if tick <= 0 {
+		tick = defaultSamplingTick
+	}
+	return zapcore.NewSamplerWithOptions(core, tick, c.Initial, c.Thereafter)
+}
@@ -0,0 +1,24 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSampledCore(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	assert.Equal(t, core, sampledCore(SamplingConfig{}, core))
+
+	l := zap.New(sampledCore(SamplingConfig{Initial: 3, Thereafter: 5}, core))
+	for i := 0; i < 20; i++ {
+		l.Info("hot loop")
+	}
+	l.Info("other message")
+
+	// 3 initial entries, then entries 8, 13 and 18 plus the other message
+	assert.Equal(t, 7, logs.Len())
+}
@@ -0,0 +1,45 @@
+package logging
+
+import "time"
+
+// RotationSchedule decides when a FileWriter rotates log files on calendar
+// boundaries.  Implement this interface to use other schedules, eg. one
+// based on a cron expression.
+type RotationSchedule interface {
+	// Next returns the first rotation time after t.
+	Next(t time.Time) time.Time
+	// PeriodName returns the name of the period ending at end.  It is used
+	// to name the archive, eg. "2024-05-01" gives log-2024-05-01.log.
+	PeriodName(end time.Time) string
+}
+
+var (
+	// DailyUTC rotates at midnight UTC and names archives by date.
+	DailyUTC = EveryUTC(24 * time.Hour)
+	// HourlyUTC rotates at the start of every hour UTC.
+	HourlyUTC = EveryUTC(time.Hour)
+)
+
+// everySchedule rotates at multiples of period counted from midnight UTC.
+type everySchedule struct {
+	period time.Duration
+}
+
+// EveryUTC returns a schedule rotating at every multiple of period counted
+// from midnight UTC.  The period should divide 24 hours evenly for the
+// rotations to happen at the same times every day.
+func EveryUTC(period time.Duration) RotationSchedule {
+	return everySchedule{period: period}
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.UTC().Truncate(s.period).Add(s.period)
+}
+
+func (s everySchedule) PeriodName(end time.Time) string {
+	start := end.UTC().Add(-s.period)
+	if s.period >= 24*time.Hour {
+		return start.Format("2006-01-02")
+	}
+	return start.Format("2006-01-02T15-04")
+}
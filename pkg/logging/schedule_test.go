@@ -0,0 +1,25 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEveryUTC(t *testing.T) {
+	now := time.Date(2024, 5, 1, 13, 37, 0, 0, time.UTC)
+
+	next := DailyUTC.Next(now)
+	assert.Equal(t, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), next)
+	assert.Equal(t, "2024-05-01", DailyUTC.PeriodName(next))
+
+	next = HourlyUTC.Next(now)
+	assert.Equal(t, time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC), next)
+	assert.Equal(t, "2024-05-01T13-00", HourlyUTC.PeriodName(next))
+
+	// times in other zones are aligned to UTC boundaries
+	oslo := time.FixedZone("CEST", 2*60*60)
+	next = DailyUTC.Next(time.Date(2024, 5, 1, 1, 0, 0, 0, oslo))
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), next)
+}
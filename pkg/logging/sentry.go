@@ -0,0 +1,167 @@
+package logging
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap/zapcore"
+)
+
+// SentryConfig configures sending entries to Sentry.
+type SentryConfig struct {
+	// DSN is the DSN of the Sentry project.  Configure only sends entries
+	// to Sentry if it is set.
+	DSN string
+	// Environment is the environment reported to Sentry, eg. "production".
+	Environment string
+	// Release is the release reported to Sentry.  Defaults to the version
+	// of the binary.
+	Release string
+	// SampleRate is the fraction of the entries sent, between 0 and 1.  All
+	// entries are sent if it is 0.
+	SampleRate float64
+	// RateLimit limits the number of identical entries sent.
+	RateLimit RateLimitConfig
+	// Transport replaces the HTTP transport of the Sentry client, eg. in
+	// tests.
+	Transport sentry.Transport
+}
+
+// sentryFlushTimeout is how long Sync waits for the entries to be sent.
+const sentryFlushTimeout = 2 * time.Second
+
+// sentryCore is a zapcore.Core which sends entries at Error level and above
+// to Sentry.
+type sentryCore struct {
+	client *sentry.Client
+	fields []zapcore.Field
+}
+
+// NewSentryCore returns a core which sends entries at Error level and above
+// to Sentry, along with their fields, the errors logged and the stack trace
+// of the caller.  Add it with AddCore, or set Config.Sentry to have Configure
+// add it.  Sync waits for the entries to be sent.
+func NewSentryCore(c SentryConfig) (zapcore.Core, error) {
+	release := c.Release
+	if release == "" {
+		release = buildVersion()
+	}
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         c.DSN,
+		Environment: c.Environment,
+		Release:     release,
+		SampleRate:  c.SampleRate,
+		Transport:   c.Transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewRateLimitCore(&sentryCore{client: client}, c.RateLimit), nil
+}
+
+func (c *sentryCore) Enabled(l zapcore.Level) bool {
+	return l >= zapcore.ErrorLevel
+}
+
+func (c *sentryCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sentryCore{
+		client: c.client,
+		fields: append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *sentryCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *sentryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	event := sentry.NewEvent()
+	for _, fields := range [][]zapcore.Field{c.fields, fields} {
+		for _, f := range fields {
+			f.AddTo(enc)
+			if f.Type != zapcore.ErrorType {
+				continue
+			}
+			// typed nil errors are only reported as "<nil>" in the extras
+			if msg, ok := safeString(f.Interface); ok {
+				err := f.Interface.(error)
+				event.Exception = append(event.Exception, sentry.Exception{
+					Type:       reflect.TypeOf(err).String(),
+					Value:      msg,
+					Stacktrace: sentry.ExtractStacktrace(err),
+				})
+			}
+		}
+	}
+
+	event.Level = sentryLevel(ent.Level)
+	event.Message = ent.Message
+	event.Logger = ent.LoggerName
+	event.Timestamp = ent.Time
+	event.Extra = enc.Fields
+	event.Threads = []sentry.Thread{{
+		Stacktrace: callerStacktrace(),
+		Current:    true,
+		Crashed:    ent.Level >= zapcore.PanicLevel,
+	}}
+
+	c.client.CaptureEvent(event, nil, nil)
+	return nil
+}
+
+func (c *sentryCore) Sync() error {
+	c.client.Flush(sentryFlushTimeout)
+	return nil
+}
+
+// callerStacktrace returns the stack trace of the code which logged the
+// entry, without the frames of zap and this package.
+func callerStacktrace() *sentry.Stacktrace {
+	stacktrace := sentry.NewStacktrace()
+	if stacktrace == nil {
+		return nil
+	}
+
+	frames := stacktrace.Frames[:0]
+	for _, frame := range stacktrace.Frames {
+		if frame.Module == ourPackage || strings.HasPrefix(frame.Module, "go.uber.org/zap") {
+			continue
+		}
+		frames = append(frames, frame)
+	}
+	stacktrace.Frames = frames
+	return stacktrace
+}
+
+func sentryLevel(l zapcore.Level) sentry.Level {
+	switch {
+	case l >= zapcore.PanicLevel:
+		return sentry.LevelFatal
+	case l >= zapcore.ErrorLevel:
+		return sentry.LevelError
+	case l == zapcore.WarnLevel:
+		return sentry.LevelWarning
+	case l == zapcore.InfoLevel:
+		return sentry.LevelInfo
+	default:
+		return sentry.LevelDebug
+	}
+}
+
+// sentryConfigFromEnv returns the Sentry configuration given by the
+// environment variables.
+func sentryConfigFromEnv() SentryConfig {
+	rate, _ := strconv.ParseFloat(getenv(LogSentrySampleRateEnvVar), 64)
+	return SentryConfig{
+		DSN:        getenv(LogSentryDSNEnvVar),
+		SampleRate: rate,
+	}
+}
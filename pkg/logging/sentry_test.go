@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type testSentryTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *testSentryTransport) Configure(sentry.ClientOptions) {}
+
+func (t *testSentryTransport) Flush(time.Duration) bool { return true }
+
+func (t *testSentryTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func TestSentryCore(t *testing.T) {
+	transport := &testSentryTransport{}
+	core, err := NewSentryCore(SentryConfig{
+		Release:   "1.2.3",
+		RateLimit: RateLimitConfig{Limit: 1},
+		Transport: transport,
+	})
+	assert.NoError(t, err)
+
+	log := zap.New(core).Named("billing").With(zap.String("service", "billing"))
+	log.Info("not sent")
+	log.Error("payment failed", zap.Int("user", 42), zap.Error(errors.New("card declined")))
+	log.Error("payment failed", zap.Int("user", 43))
+	assert.NoError(t, log.Sync())
+
+	assert.Len(t, transport.events, 1)
+	event := transport.events[0]
+	assert.Equal(t, "payment failed", event.Message)
+	assert.Equal(t, sentry.LevelError, event.Level)
+	assert.Equal(t, "billing", event.Logger)
+	assert.Equal(t, "1.2.3", event.Release)
+	assert.Equal(t, "billing", event.Extra["service"])
+	assert.Equal(t, int64(42), event.Extra["user"])
+	assert.Len(t, event.Exception, 1)
+	assert.Equal(t, "card declined", event.Exception[0].Value)
+	assert.Len(t, event.Threads, 1)
+}
+
+func TestSentryCoreNilError(t *testing.T) {
+	transport := &testSentryTransport{}
+	core, err := NewSentryCore(SentryConfig{Transport: transport})
+	assert.NoError(t, err)
+
+	log := zap.New(core)
+	assert.NotPanics(t, func() {
+		log.Error("nil error", zap.Error((*maskStringer)(nil)))
+	})
+	assert.NoError(t, log.Sync())
+
+	assert.Len(t, transport.events, 1)
+	assert.Empty(t, transport.events[0].Exception)
+	assert.Equal(t, "<nil>", transport.events[0].Extra["error"])
+}
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogHandler(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	sl := slog.New(newSlogHandler(core))
+
+	sl.Debug("not logged")
+	sl.Info("hello", "count", 3, "name", "world")
+	sl.With("component", "test").WithGroup("req").Warn("grouped", "id", 42)
+	sl.Error("nested", slog.Group("user", slog.String("name", "bob")))
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 3)
+
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, "hello", entries[0].Message)
+	assert.Equal(t, map[string]interface{}{"count": int64(3), "name": "world"}, entries[0].ContextMap())
+	assert.True(t, entries[0].Caller.Defined)
+
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	assert.Equal(t, map[string]interface{}{
+		"component": "test",
+		"req":       map[string]interface{}{"id": int64(42)},
+	}, entries[1].ContextMap())
+
+	assert.Equal(t, zapcore.ErrorLevel, entries[2].Level)
+	assert.Equal(t, map[string]interface{}{
+		"user": map[string]interface{}{"name": "bob"},
+	}, entries[2].ContextMap())
+}
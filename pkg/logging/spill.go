@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const defaultSpillRetryInterval = time.Second
+
+// ErrSpillBufferFull is returned when a write fails and there is no room left
+// in the spill buffer.
+var ErrSpillBufferFull = errors.New("log spill buffer is full")
+
+// spillBuffer holds entries in memory while the log file can't be written.
+// It is protected by the mutex of the FileWriter.
+type spillBuffer struct {
+	buf       []byte
+	max       int
+	lastRetry time.Time
+}
+
+// add appends p to the buffer if there is room for it.
+func (s *spillBuffer) add(p []byte) bool {
+	if len(s.buf)+len(p) > s.max {
+		return false
+	}
+	s.buf = append(s.buf, p...)
+	return true
+}
+
+// pending reports whether there are entries waiting to be replayed.
+func (s *spillBuffer) pending() bool {
+	return len(s.buf) > 0
+}
+
+// spill buffers what is left of msg after a failed write.  It returns false
+// if spilling is disabled or the buffer is full.  This assumes that w.mu is
+// locked.
+func (w *FileWriter) spill(msg []byte) bool {
+	if w.config.SpillBufferSizeBytes <= 0 {
+		return false
+	}
+	if w.spillBuf.max == 0 {
+		w.spillBuf.max = w.config.SpillBufferSizeBytes
+	}
+
+	if !w.spillBuf.pending() {
+		w.spillBuf.lastRetry = w.clock().Now()
+	}
+	return w.spillBuf.add(msg)
+}
+
+// replaySpill writes the spilled entries to the log file.  The log file is
+// reopened first since whatever made writes fail may have left it unusable.
+// We only retry every SpillRetryInterval.  It returns true if the spill
+// buffer is empty afterwards.  This assumes that w.mu is locked.
+func (w *FileWriter) replaySpill() bool {
+	if !w.spillBuf.pending() {
+		return true
+	}
+
+	interval := w.config.SpillRetryInterval
+	if interval <= 0 {
+		interval = defaultSpillRetryInterval
+	}
+	if w.clock().Now().Sub(w.spillBuf.lastRetry) < interval {
+		return false
+	}
+	w.spillBuf.lastRetry = w.clock().Now()
+
+	w.flushBuffer()
+	w.logFile.Close()
+	err := w.openLogFile()
+	if err != nil {
+		w.recordError(err)
+		return false
+	}
+	if info, err := w.logFile.Stat(); err == nil {
+		w.byteCounter = info.Size()
+	}
+
+	n, err := w.logFile.Write(w.spillBuf.buf)
+	w.byteCounter += int64(n)
+	w.stats.bytesWritten.Add(int64(n))
+	w.spillBuf.buf = w.spillBuf.buf[n:]
+	if err != nil {
+		w.recordError(err)
+		return false
+	}
+
+	w.spillBuf.buf = nil
+	w.stats.lastWrite.Store(w.clock().Now().UnixNano())
+	fmt.Printf("replayed %d spilled bytes to logfile %s\n", n, w.logFileNameFullPath)
+	return true
+}
@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileWriterSpill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:           dir,
+		LogFileName:          "logfile.log",
+		SpillBufferSizeBytes: 16,
+		SpillRetryInterval:   50 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	_, err = fw.Write([]byte("first\n"))
+	assert.NoError(t, err)
+
+	// close the file behind the FileWriter's back to make writes fail
+	fw.logFile.Close()
+
+	_, err = fw.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	_, err = fw.Write([]byte("third\n"))
+	assert.NoError(t, err)
+	_, err = fw.Write([]byte("no room\n"))
+	assert.ErrorIs(t, err, ErrSpillBufferFull)
+
+	// the spilled entries are replayed in order once the retry interval
+	// has passed
+	time.Sleep(60 * time.Millisecond)
+	_, err = fw.Write([]byte("fourth\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, fw.Close())
+
+	data, err := os.ReadFile(fw.logFileNameFullPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "first\nsecond\nthird\nfourth\n", string(data))
+}
@@ -0,0 +1,208 @@
+package logging
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	// registers the "sqlite" database driver
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteConfig configures writing entries to a SQLite database.
+type SQLiteConfig struct {
+	// Path is the database file.  Configure only writes entries to the
+	// database if it is set.
+	Path string
+	// MaxSizeBytes is the size the entries may take up in the database.
+	// When it is exceeded the oldest tenth of the entries is deleted.
+	// Defaults to 100MB.
+	MaxSizeBytes int64
+}
+
+const (
+	defaultSQLiteMaxSizeBytes = 100 * 1024 * 1024
+
+	// sqlitePruneInterval is the number of entries inserted between checks
+	// of the database size.
+	sqlitePruneInterval = 1000
+)
+
+// sqliteSchema is the schema of the database.  The fields are stored as a
+// JSON object, so they can be queried with json_extract.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS entries (
+	id      INTEGER PRIMARY KEY AUTOINCREMENT,
+	time    TEXT NOT NULL,
+	level   TEXT NOT NULL,
+	logger  TEXT NOT NULL,
+	message TEXT NOT NULL,
+	caller  TEXT NOT NULL,
+	stack   TEXT NOT NULL,
+	fields  TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS entries_time ON entries (time);
+CREATE INDEX IF NOT EXISTS entries_level ON entries (level);
+`
+
+// sqliteDB is the database shared by a SQLite core and the cores derived
+// from it.
+type sqliteDB struct {
+	config SQLiteConfig
+	db     *sql.DB
+	insert *sql.Stmt
+
+	mu       sync.Mutex
+	inserted int
+}
+
+// sqliteCore is a zapcore.Core which inserts entries into a SQLite
+// database.
+type sqliteCore struct {
+	zapcore.LevelEnabler
+	db     *sqliteDB
+	fields []zapcore.Field
+}
+
+// NewSQLiteCore returns a core inserting entries enabled by enab into the
+// entries table of a SQLite database, which is created if it does not exist.
+// This lets support engineers query the recent entries with SQL on machines
+// without network access, eg.
+//
+//	SELECT time, message FROM entries
+//	WHERE level = 'error' AND json_extract(fields, '$.user') = 42;
+//
+// The oldest entries are deleted once the database grows beyond
+// c.MaxSizeBytes.  The returned closer must be closed when the core is no
+// longer in use.
+func NewSQLiteCore(c SQLiteConfig, enab zapcore.LevelEnabler) (zapcore.Core, io.Closer, error) {
+	if c.MaxSizeBytes <= 0 {
+		c.MaxSizeBytes = defaultSQLiteMaxSizeBytes
+	}
+
+	db, err := sql.Open("sqlite", c.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	// SQLite allows a single writer at a time
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range []string{"PRAGMA journal_mode = WAL", "PRAGMA synchronous = NORMAL", sqliteSchema} {
+		_, err = db.Exec(stmt)
+		if err != nil {
+			db.Close()
+			return nil, nil, fmt.Errorf("error setting up log database %s: %w", c.Path, err)
+		}
+	}
+
+	insert, err := db.Prepare("INSERT INTO entries (time, level, logger, message, caller, stack, fields) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	s := &sqliteDB{config: c, db: db, insert: insert}
+	return &sqliteCore{LevelEnabler: enab, db: s}, s, nil
+}
+
+func (c *sqliteCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sqliteCore{
+		LevelEnabler: c.LevelEnabler,
+		db:           c.db,
+		fields:       append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *sqliteCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *sqliteCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	encodedFields, err := json.Marshal(enc.Fields)
+	if err != nil {
+		return err
+	}
+
+	caller := ""
+	if ent.Caller.Defined {
+		caller = ent.Caller.TrimmedPath()
+	}
+	return c.db.write(
+		ent.Time.UTC().Format(time.RFC3339Nano),
+		ent.Level.String(),
+		ent.LoggerName,
+		ent.Message,
+		caller,
+		ent.Stack,
+		string(encodedFields),
+	)
+}
+
+func (c *sqliteCore) Sync() error {
+	return nil
+}
+
+func (s *sqliteDB) write(args ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.insert.Exec(args...)
+	if err != nil {
+		return err
+	}
+
+	s.inserted++
+	if s.inserted%sqlitePruneInterval == 0 {
+		return s.prune()
+	}
+	return nil
+}
+
+// prune deletes the oldest tenth of the entries if the entries take up more
+// than MaxSizeBytes.  Deleted pages are reused by SQLite, so the file stops
+// growing.  This assumes s.mu is locked.
+func (s *sqliteDB) prune() error {
+	var size int64
+	err := s.db.QueryRow("SELECT (page_count - freelist_count) * page_size FROM pragma_page_count(), pragma_freelist_count(), pragma_page_size()").Scan(&size)
+	if err != nil || size <= s.config.MaxSizeBytes {
+		return err
+	}
+
+	_, err = s.db.Exec("DELETE FROM entries WHERE id <= (SELECT MIN(id) + (MAX(id) - MIN(id)) / 10 FROM entries)")
+	return err
+}
+
+// Close closes the database.
+func (s *sqliteDB) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.insert.Close()
+	return s.db.Close()
+}
+
+// sqliteConfigFromEnv returns the SQLite configuration given by the
+// environment variables.
+func sqliteConfigFromEnv() SQLiteConfig {
+	maxSizeMB, _ := strconv.ParseInt(getenv(LogSQLiteMaxSizeMBEnvVar), 10, 64)
+	return SQLiteConfig{
+		Path:         getenv(LogSQLitePathEnvVar),
+		MaxSizeBytes: maxSizeMB * 1024 * 1024,
+	}
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSQLiteCore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logs.db")
+	core, closer, err := NewSQLiteCore(SQLiteConfig{Path: path}, zapcore.InfoLevel)
+	assert.NoError(t, err)
+
+	log := zap.New(core, zap.AddCaller()).Named("billing")
+	log.Debug("not stored")
+	log.Info("payment received", zap.Int("user", 42))
+	log.Error("payment failed", zap.Int("user", 43))
+	assert.NoError(t, closer.Close())
+
+	db, err := sql.Open("sqlite", path)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM entries").Scan(&count))
+	assert.Equal(t, 2, count)
+
+	var level, logger, message, caller string
+	assert.NoError(t, db.QueryRow("SELECT level, logger, message, caller FROM entries WHERE json_extract(fields, '$.user') = 43").Scan(&level, &logger, &message, &caller))
+	assert.Equal(t, "error", level)
+	assert.Equal(t, "billing", logger)
+	assert.Equal(t, "payment failed", message)
+	assert.Contains(t, caller, "sqlite_test.go")
+}
+
+func TestSQLiteCorePrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logs.db")
+	core, closer, err := NewSQLiteCore(SQLiteConfig{Path: path, MaxSizeBytes: 64 * 1024}, zapcore.InfoLevel)
+	assert.NoError(t, err)
+
+	log := zap.New(core)
+	for i := 0; i < 3*sqlitePruneInterval; i++ {
+		log.Info("an entry which takes up some room in the database", zap.Int("i", i))
+	}
+	assert.NoError(t, closer.Close())
+
+	db, err := sql.Open("sqlite", path)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var count, first int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*), MIN(json_extract(fields, '$.i')) FROM entries").Scan(&count, &first))
+	assert.Less(t, count, 3*sqlitePruneInterval)
+	assert.Greater(t, first, 0)
+}
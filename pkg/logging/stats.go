@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+)
+
+// Stats contains internal statistics of the package logger.
+type Stats struct {
+	// Level is the current log level.
+	Level string `json:"level"`
+	// FileWriters contains the statistics of each file writer of the
+	// current configuration.
+	FileWriters []FileWriterStats `json:"fileWriters,omitempty"`
+}
+
+// FileWriterStats contains the statistics of a FileWriter.
+type FileWriterStats struct {
+	// Path is the path of the active log file.
+	Path string `json:"path"`
+	// BytesWritten is the number of bytes written since the FileWriter was
+	// created.
+	BytesWritten int64 `json:"bytesWritten"`
+	// FileSize is the size of the active log file.
+	FileSize int64 `json:"fileSize"`
+	// Archives is the number of archived log files.
+	Archives int `json:"archives"`
+	// Dropped is the number of entries dropped in DropOnOverload mode.
+	Dropped uint64 `json:"dropped"`
+	// CompressionBacklog is the number of archives waiting to be
+	// compressed.
+	CompressionBacklog int `json:"compressionBacklog"`
+	// LastError is the last write error, if any.
+	LastError string `json:"lastError,omitempty"`
+	// LastErrorTime is when LastError happened.
+	LastErrorTime time.Time `json:"lastErrorTime,omitempty"`
+}
+
+// writerError is an error along with the time it happened.
+type writerError struct {
+	err  error
+	time time.Time
+}
+
+// writerStats holds the counters behind FileWriterStats.
+type writerStats struct {
+	bytesWritten atomic.Int64
+	lastError    atomic.Pointer[writerError]
+	// lastWrite is the time of the last successful write in unix nanoseconds
+	lastWrite   atomic.Int64
+	compressing atomic.Int32
+}
+
+func init() {
+	expvar.Publish("logging", expvar.Func(func() interface{} {
+		return GetStats()
+	}))
+}
+
+// recordError records err as the last error of the FileWriter and passes it
+// on to the OnError hook.
+func (w *FileWriter) recordError(err error) {
+	w.stats.lastError.Store(&writerError{err: err, time: w.clock().Now()})
+	if w.config.OnError != nil {
+		w.config.OnError(err)
+	}
+}
+
+// Stats returns the statistics of the FileWriter.
+func (w *FileWriter) Stats() FileWriterStats {
+	w.mu.Lock()
+	fileSize := w.byteCounter
+	w.mu.Unlock()
+
+	stats := FileWriterStats{
+		Path:         w.logFileNameFullPath,
+		BytesWritten: w.stats.bytesWritten.Load(),
+		FileSize:     fileSize,
+		Dropped:      w.drops.total.Load(),
+
+		CompressionBacklog: int(w.stats.compressing.Load()),
+	}
+
+	archives, err := w.archives()
+	if err == nil {
+		stats.Archives = len(archives)
+	}
+
+	if lastError := w.stats.lastError.Load(); lastError != nil {
+		stats.LastError = lastError.err.Error()
+		stats.LastErrorTime = lastError.time
+	}
+	return stats
+}
+
+// GetStats returns the statistics of the package logger.  They are also
+// published through expvar as "logging".
+func GetStats() Stats {
+	stats := Stats{Level: GetLevel().CapitalString()}
+	for _, fw := range currentFileWriters() {
+		stats.FileWriters = append(stats.FileWriters, fw.Stats())
+	}
+	return stats
+}
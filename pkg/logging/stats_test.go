@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"errors"
+	"expvar"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileWriterStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fw, err := NewFileWriter(FileWriterConfig{
+		LogDirName:          dir,
+		LogFileName:         "logfile.log",
+		MaxLogFileSizeBytes: 100,
+	}, withMinSize(1))
+	assert.NoError(t, err)
+	defer fw.Close()
+
+	_, err = fw.Write([]byte(strings.Repeat("x", 150)))
+	assert.NoError(t, err)
+	_, err = fw.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+
+	stats := fw.Stats()
+	assert.Equal(t, filepath.Join(dir, "logfile.log"), stats.Path)
+	assert.Equal(t, int64(156), stats.BytesWritten)
+	assert.Equal(t, int64(6), stats.FileSize)
+	assert.Equal(t, 1, stats.Archives)
+	assert.Empty(t, stats.LastError)
+
+	fw.recordError(errors.New("disk full"))
+	assert.Equal(t, "disk full", fw.Stats().LastError)
+
+	assert.Contains(t, expvar.Get("logging").String(), `"level":"INFO"`)
+}
@@ -0,0 +1,196 @@
+package logging
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SupportBundleOptions configures WriteSupportBundle.
+type SupportBundleOptions struct {
+	// Archives is the number of most recent archives of each log file
+	// included in the bundle.
+	Archives int
+	// RecentEntries limits the number of recent entries included.  All
+	// entries kept are included if it is 0.
+	RecentEntries int
+}
+
+// bundleSummary is summary.json in a support bundle.
+type bundleSummary struct {
+	Time        time.Time           `json:"time"`
+	Host        string              `json:"host"`
+	Version     string              `json:"version"`
+	Revision    string              `json:"revision,omitempty"`
+	GoVersion   string              `json:"goVersion"`
+	Stats       Stats               `json:"stats"`
+	FileWriters []fileWriterSummary `json:"fileWriters,omitempty"`
+}
+
+// fileWriterSummary is the configuration of a file writer in a support
+// bundle.  It leaves out secrets such as encryption keys.
+type fileWriterSummary struct {
+	Path                string        `json:"path"`
+	MaxLogFileSizeBytes int64         `json:"maxLogFileSizeBytes"`
+	MaxTimeToKeep       time.Duration `json:"maxTimeToKeep"`
+	MaxTotalSizeBytes   int64         `json:"maxTotalSizeBytes,omitempty"`
+	MaxArchivedFiles    int           `json:"maxArchivedFiles,omitempty"`
+	Compress            bool          `json:"compress"`
+	CompressionCodec    Codec         `json:"compressionCodec,omitempty"`
+	Encrypted           bool          `json:"encrypted"`
+	Async               bool          `json:"async"`
+}
+
+// WriteSupportBundle writes a tar.gz archive for attaching to support
+// tickets to w.  It contains
+//
+//	summary.json   the version of the binary, the logger statistics and
+//	               the configuration of the log files
+//	recent.log     the recent entries kept in memory, see Recent
+//	logs/          the log files of the package logger and their most
+//	               recent archives
+func WriteSupportBundle(w io.Writer, opts SupportBundleOptions) error {
+	// make sure what has been logged so far is in the log files
+	Flush()
+
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	now := time.Now()
+
+	fileWriters := currentFileWriters()
+	host, _ := os.Hostname()
+	summary := bundleSummary{
+		Time:      now,
+		Host:      host,
+		Version:   buildVersion(),
+		Revision:  buildSetting("vcs.revision"),
+		GoVersion: runtime.Version(),
+		Stats:     GetStats(),
+	}
+	for _, fw := range fileWriters {
+		summary.FileWriters = append(summary.FileWriters, fw.summary())
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = writeBundleFile(tw, "summary.json", now, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+
+	var recent bytes.Buffer
+	enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	for _, e := range Recent(opts.RecentEntries) {
+		buf, err := enc.EncodeEntry(e.Entry, e.Fields)
+		if err != nil {
+			continue
+		}
+		recent.Write(buf.Bytes())
+		buf.Free()
+	}
+	err = writeBundleFile(tw, "recent.log", now, &recent, int64(recent.Len()))
+	if err != nil {
+		return err
+	}
+
+	for _, fw := range fileWriters {
+		err := fw.writeBundleFiles(tw, opts.Archives)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// summary returns the configuration of w for a support bundle.
+func (w *FileWriter) summary() fileWriterSummary {
+	return fileWriterSummary{
+		Path:                w.logFileNameFullPath,
+		MaxLogFileSizeBytes: w.config.MaxLogFileSizeBytes,
+		MaxTimeToKeep:       w.config.MaxTimeTimeToKeep,
+		MaxTotalSizeBytes:   w.config.MaxTotalSizeBytes,
+		MaxArchivedFiles:    w.config.MaxArchivedFiles,
+		Compress:            w.config.Compress,
+		CompressionCodec:    w.config.CompressionCodec,
+		Encrypted:           w.config.Encryption.enabled(),
+		Async:               w.config.Async || w.config.DropOnOverload,
+	}
+}
+
+// writeBundleFiles adds the last n archives and the log file to a support
+// bundle.
+func (w *FileWriter) writeBundleFiles(tw *tar.Writer, n int) error {
+	archives, err := w.ListArchives()
+	if err != nil {
+		return err
+	}
+	if len(archives) > n {
+		archives = archives[len(archives)-n:]
+	}
+
+	paths := make([]string, 0, len(archives)+1)
+	for _, archive := range archives {
+		paths = append(paths, archive.Path)
+	}
+	paths = append(paths, w.logFileNameFullPath)
+
+	for _, fn := range paths {
+		err := w.writeBundleFile(tw, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBundleFile adds the file fn to a support bundle as it is now.  The
+// log file may grow while we copy it, so we stop at the size we started
+// with.
+func (w *FileWriter) writeBundleFile(tw *tar.Writer, fn string) error {
+	f, err := w.fs().OpenFile(fn, os.O_RDONLY, 0)
+	if os.IsNotExist(err) {
+		// eg. deleted by retention since we listed it
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return writeBundleFile(tw, path.Join("logs", info.Name()), info.ModTime(), io.LimitReader(f, info.Size()), info.Size())
+}
+
+// writeBundleFile adds a file with the size bytes read from r to a support
+// bundle.
+func writeBundleFile(tw *tar.Writer, name string, modTime time.Time, r io.Reader, size int64) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, r)
+	return err
+}
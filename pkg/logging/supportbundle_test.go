@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSupportBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewriter-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer Configure(Config{})
+	assert.NoError(t, Configure(Config{
+		Mode:          "file",
+		RecentEntries: 10,
+		FileWriter: FileWriterConfig{
+			LogDirName:          dir,
+			LogFileName:         "bundle.log",
+			MaxLogFileSizeBytes: 300,
+			minSizeBytes:        1,
+		},
+	}))
+
+	for i := 0; i < 10; i++ {
+		Get().Info("bundled entry")
+		// make sure the archives get distinct names and modification times
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteSupportBundle(&buf, SupportBundleOptions{Archives: 2, RecentEntries: 5}))
+
+	zr, err := gzip.NewReader(&buf)
+	assert.NoError(t, err)
+	tr := tar.NewReader(zr)
+	files := map[string][]byte{}
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		assert.NoError(t, err)
+		files[hdr.Name] = data
+		names = append(names, hdr.Name)
+	}
+
+	// summary, recent entries, two archives and the log file
+	assert.Len(t, names, 5)
+	assert.Equal(t, []string{"summary.json", "recent.log"}, names[:2])
+	assert.Equal(t, "logs/bundle.log", names[4])
+
+	var summary bundleSummary
+	assert.NoError(t, json.Unmarshal(files["summary.json"], &summary))
+	assert.Len(t, summary.FileWriters, 1)
+	assert.Equal(t, int64(300), summary.FileWriters[0].MaxLogFileSizeBytes)
+
+	assert.Equal(t, 5, bytes.Count(files["recent.log"], []byte("bundled entry")))
+	assert.Contains(t, string(files["logs/bundle.log"]), "bundled entry")
+}
@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// swapCore is a zapcore.Core which delegates to a core that can be replaced at
+// runtime.  All cores derived from it through With() follow the replacement,
+// which means loggers that were created before the logger was reconfigured
+// keep working and write to the new sinks.
+type swapCore struct {
+	root   *swapRoot
+	fields []zapcore.Field
+	cache  atomic.Pointer[swapCached]
+}
+
+// swapRoot holds the current core shared by all swapCores derived from the
+// same root.
+type swapRoot struct {
+	current atomic.Pointer[swapVersion]
+
+	// start is called before the first entry is checked if pending is
+	// set, see deferStart.
+	start   func()
+	pending atomic.Bool
+}
+
+// swapVersion wraps a core so we can tell replacements apart by pointer.
+type swapVersion struct {
+	core zapcore.Core
+}
+
+// swapCached is the current core with the fields of a derived swapCore
+// applied to it.
+type swapCached struct {
+	version *swapVersion
+	core    zapcore.Core
+}
+
+func newSwapCore(core zapcore.Core) *swapCore {
+	root := &swapRoot{}
+	root.current.Store(&swapVersion{core: core})
+	return &swapCore{root: root}
+}
+
+// swap replaces the underlying core and returns the previous one.
+func (c *swapCore) swap(core zapcore.Core) zapcore.Core {
+	return c.root.current.Swap(&swapVersion{core: core}).core
+}
+
+// deferStart makes the core call start before the first entry is checked,
+// unless claimStart is called before.
+func (c *swapCore) deferStart(start func()) {
+	c.root.start = start
+	c.root.pending.Store(true)
+}
+
+// claimStart cancels the deferred start function.  It reports whether the
+// function was still pending, in which case the caller takes over starting.
+func (c *swapCore) claimStart() bool {
+	return c.root.pending.CompareAndSwap(true, false)
+}
+
+// current returns the underlying core with our fields applied.
+func (c *swapCore) current() zapcore.Core {
+	version := c.root.current.Load()
+	if len(c.fields) == 0 {
+		return version.core
+	}
+
+	cached := c.cache.Load()
+	if cached != nil && cached.version == version {
+		return cached.core
+	}
+
+	core := version.core.With(c.fields)
+	c.cache.Store(&swapCached{version: version, core: core})
+	return core
+}
+
+func (c *swapCore) Enabled(level zapcore.Level) bool {
+	return c.current().Enabled(level)
+}
+
+func (c *swapCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	return &swapCore{root: c.root, fields: all}
+}
+
+func (c *swapCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	// entries logged while starting go to the core we have now
+	if c.root.pending.Load() && c.claimStart() {
+		c.root.start()
+	}
+	return c.current().Check(ent, ce)
+}
+
+func (c *swapCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.current().Write(ent, fields)
+}
+
+func (c *swapCore) Sync() error {
+	return c.current().Sync()
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSwapCore(t *testing.T) {
+	first, firstLogs := observer.New(zapcore.InfoLevel)
+	second, secondLogs := observer.New(zapcore.DebugLevel)
+
+	sc := newSwapCore(first)
+	l := zap.New(sc).With(zap.String("component", "test"))
+
+	l.Info("first")
+	l.Debug("dropped")
+
+	sc.swap(second)
+	l.Debug("second")
+
+	assert.Equal(t, 1, firstLogs.Len())
+	assert.Equal(t, 1, secondLogs.Len())
+	assert.Equal(t, "second", secondLogs.All()[0].Message)
+	assert.Equal(t, map[string]interface{}{"component": "test"}, secondLogs.All()[0].ContextMap())
+}
+
+func TestSwapCoreDeferStart(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	root := newSwapCore(core)
+
+	started := 0
+	root.deferStart(func() {
+		started++
+		// entries logged while starting must not start again
+		zap.New(root).Info("starting")
+	})
+
+	log := zap.New(root).With(zap.String("component", "test"))
+	log.Debug("disabled")
+	assert.Equal(t, 0, started)
+
+	log.Info("first")
+	log.Info("second")
+	assert.Equal(t, 1, started)
+	assert.Equal(t, 3, logs.Len())
+	assert.False(t, root.claimStart())
+}
@@ -0,0 +1,375 @@
+package logging
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SyslogConfig contains the configuration for a SyslogWriter.
+type SyslogConfig struct {
+	// Network is the network used to reach the syslog server, eg. "udp",
+	// "tcp" or "tls" (RFC5425).  If both Network and Address are empty we log
+	// to the local syslog daemon over a unix socket.
+	Network string
+	// Address is the address of the syslog server, eg. "logs.example.com:514".
+	Address string
+	// Facility is the syslog facility code.  Defaults to 1 (user-level messages).
+	Facility int
+	// AppName is the APP-NAME of the messages.  Defaults to the name of the binary.
+	AppName string
+	// Hostname is the HOSTNAME of the messages.  Defaults to os.Hostname().
+	Hostname string
+	// TLSConfig is the TLS configuration used with the "tls" network.  If it
+	// is nil it is built from CAFile, CertFile and KeyFile.
+	TLSConfig *tls.Config
+	// CAFile is a PEM file with the certificates used to verify the server.
+	// Defaults to the system roots.
+	CAFile string
+	// CertFile and KeyFile are the PEM encoded client certificate and key
+	// used for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// MaxReconnectBackoff is the maximum time we wait between attempts to
+	// reconnect to the server after the connection is lost.  Defaults to one
+	// minute.
+	MaxReconnectBackoff time.Duration
+}
+
+const (
+	defaultSyslogFacility         = 1
+	minSyslogReconnectBackoff     = 500 * time.Millisecond
+	defaultSyslogReconnectBackoff = time.Minute
+	syslogTimestampFormat         = "2006-01-02T15:04:05.000000Z07:00"
+	syslogNilValue                = "-"
+)
+
+// syslog severities as defined in RFC5424 section 6.2.1
+const (
+	syslogEmergency = iota
+	syslogAlert
+	syslogCritical
+	syslogError
+	syslogWarning
+	syslogNotice
+	syslogInformational
+	syslogDebug
+)
+
+// localSyslogSockets are the unix sockets the local syslog daemon usually listens to.
+var localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// ErrNoLocalSyslog is returned if we are unable to find a local syslog daemon.
+var ErrNoLocalSyslog = errors.New("unable to connect to local syslog daemon")
+
+// ErrSyslogDisconnected is returned when the connection to the syslog server
+// has been lost and we are waiting to reconnect.
+var ErrSyslogDisconnected = errors.New("disconnected from syslog server")
+
+// SyslogWriter writes RFC5424 formatted messages to a syslog server.
+type SyslogWriter struct {
+	config SyslogConfig
+	mu     sync.Mutex
+	conn   net.Conn
+	stream bool
+	// octetCounting is set for TLS which uses the framing of RFC5425
+	octetCounting bool
+	pid           string
+	closed        bool
+	backoff       time.Duration
+	nextRetry     time.Time
+}
+
+// NewSyslogWriter creates a new SyslogWriter and connects to the syslog server.
+func NewSyslogWriter(c SyslogConfig) (*SyslogWriter, error) {
+	if c.Facility == 0 {
+		c.Facility = defaultSyslogFacility
+	}
+	if c.AppName == "" {
+		c.AppName = filepath.Base(os.Args[0])
+	}
+	if c.Hostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = syslogNilValue
+		}
+		c.Hostname = hostname
+	}
+	if c.MaxReconnectBackoff <= 0 {
+		c.MaxReconnectBackoff = defaultSyslogReconnectBackoff
+	}
+
+	w := &SyslogWriter{
+		config: c,
+		pid:    fmt.Sprint(os.Getpid()),
+	}
+
+	err := w.connect()
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *SyslogWriter) connect() error {
+	if w.config.Network == "" && w.config.Address == "" {
+		for _, network := range []string{"unixgram", "unix"} {
+			for _, path := range localSyslogSockets {
+				conn, err := net.Dial(network, path)
+				if err == nil {
+					w.conn = conn
+					w.stream = network == "unix"
+					return nil
+				}
+			}
+		}
+		return ErrNoLocalSyslog
+	}
+
+	network := w.config.Network
+	if network == "" {
+		network = "udp"
+	}
+
+	if network == "tls" {
+		tlsConfig, err := w.tlsConfig()
+		if err != nil {
+			return err
+		}
+		conn, err := tls.Dial("tcp", w.config.Address, tlsConfig)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+		w.stream = true
+		w.octetCounting = true
+		return nil
+	}
+
+	conn, err := net.Dial(network, w.config.Address)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	w.stream = strings.HasPrefix(network, "tcp") || network == "unix"
+	return nil
+}
+
+// tlsConfig returns the TLS configuration for the "tls" network.
+func (w *SyslogWriter) tlsConfig() (*tls.Config, error) {
+	if w.config.TLSConfig != nil {
+		return w.config.TLSConfig, nil
+	}
+
+	host, _, err := net.SplitHostPort(w.config.Address)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{ServerName: host}
+
+	if w.config.CAFile != "" {
+		pem, err := os.ReadFile(w.config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", w.config.CAFile)
+		}
+	}
+
+	if w.config.CertFile != "" || w.config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(w.config.CertFile, w.config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// WriteMessage writes a single message with the given level and timestamp.
+// If the connection to the server has been lost we reconnect, backing off
+// exponentially while the server is unavailable.
+func (w *SyslogWriter) WriteMessage(level zapcore.Level, t time.Time, msg string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	line := []byte(w.format(level, t, msg))
+
+	if w.conn != nil {
+		_, err := w.conn.Write(line)
+		if err == nil {
+			return nil
+		}
+		// the server may have restarted, so we try to reconnect right away
+		w.conn.Close()
+		w.conn = nil
+		w.nextRetry = time.Time{}
+	}
+
+	err := w.reconnect()
+	if err != nil {
+		return err
+	}
+	_, err = w.conn.Write(line)
+	return err
+}
+
+// reconnect connects to the server unless we are backing off.  This assumes
+// that w.mu is locked.
+func (w *SyslogWriter) reconnect() error {
+	if time.Now().Before(w.nextRetry) {
+		return ErrSyslogDisconnected
+	}
+
+	err := w.connect()
+	if err != nil {
+		w.backoff *= 2
+		if w.backoff < minSyslogReconnectBackoff {
+			w.backoff = minSyslogReconnectBackoff
+		}
+		if w.backoff > w.config.MaxReconnectBackoff {
+			w.backoff = w.config.MaxReconnectBackoff
+		}
+		w.nextRetry = time.Now().Add(w.backoff)
+		return fmt.Errorf("%w: %v", ErrSyslogDisconnected, err)
+	}
+
+	w.backoff = 0
+	return nil
+}
+
+// format formats a message according to RFC5424.  We do not use MSGID or
+// STRUCTURED-DATA.
+func (w *SyslogWriter) format(level zapcore.Level, t time.Time, msg string) string {
+	msg = strings.TrimSuffix(msg, "\n")
+	line := fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		w.config.Facility*8+syslogSeverity(level),
+		t.Format(syslogTimestampFormat),
+		w.config.Hostname,
+		w.config.AppName,
+		w.pid,
+		syslogNilValue,
+		syslogNilValue,
+		msg)
+
+	// stream transports need framing to separate messages
+	if w.octetCounting {
+		return fmt.Sprintf("%d %s", len(line), line)
+	}
+	if w.stream {
+		line += "\n"
+	}
+	return line
+}
+
+// Close the connection to the syslog server.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// syslogSeverity maps zap levels to syslog severities.
+func syslogSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return syslogDebug
+	case zapcore.InfoLevel:
+		return syslogInformational
+	case zapcore.WarnLevel:
+		return syslogWarning
+	case zapcore.ErrorLevel:
+		return syslogError
+	case zapcore.DPanicLevel, zapcore.PanicLevel:
+		return syslogCritical
+	case zapcore.FatalLevel:
+		return syslogEmergency
+	default:
+		if level < zapcore.DebugLevel {
+			return syslogDebug
+		}
+		return syslogError
+	}
+}
+
+// syslogEncoder returns the encoder we use for the message part of syslog
+// messages.  The timestamp is part of the syslog header so we leave it out.
+func syslogEncoder() zapcore.Encoder {
+	ec := zap.NewProductionEncoderConfig()
+	ec.TimeKey = ""
+	return zapcore.NewJSONEncoder(ec)
+}
+
+// syslogCore is a zapcore.Core that writes entries to a SyslogWriter.  We need
+// a core rather than a WriteSyncer since the severity is part of the message
+// header.
+type syslogCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	w   *SyslogWriter
+}
+
+// NewSyslogCore returns a zapcore.Core writing to w.  Use this to combine
+// syslog output with other cores.
+func NewSyslogCore(w *SyslogWriter, enab zapcore.LevelEnabler) zapcore.Core {
+	return newSyslogCore(syslogEncoder(), w, enab)
+}
+
+func newSyslogCore(enc zapcore.Encoder, w *SyslogWriter, enab zapcore.LevelEnabler) *syslogCore {
+	return &syslogCore{
+		LevelEnabler: enab,
+		enc:          enc,
+		w:            w,
+	}
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := newSyslogCore(c.enc.Clone(), c.w, c.LevelEnabler)
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *syslogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *syslogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	return c.w.WriteMessage(ent.Level, ent.Time, buf.String())
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSyslogWriter(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	sw, err := NewSyslogWriter(SyslogConfig{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		AppName:  "testapp",
+		Hostname: "testhost",
+	})
+	assert.NoError(t, err)
+	defer sw.Close()
+
+	l := zap.New(NewSyslogCore(sw, zapcore.InfoLevel))
+	l.Debug("not sent")
+	l.Warn("something happened", zap.Int("count", 3))
+
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+
+	// facility 1 * 8 + severity 4 (warning) = 12
+	pattern := regexp.MustCompile(`^<12>1 \S+ testhost testapp \d+ - - \{"level":"warn","msg":"something happened","count":3\}$`)
+	assert.Regexp(t, pattern, string(buf[:n]))
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	assert.Equal(t, syslogDebug, syslogSeverity(zapcore.DebugLevel))
+	assert.Equal(t, syslogInformational, syslogSeverity(zapcore.InfoLevel))
+	assert.Equal(t, syslogError, syslogSeverity(zapcore.ErrorLevel))
+	assert.Equal(t, syslogEmergency, syslogSeverity(zapcore.FatalLevel))
+}
+
+func TestSyslogWriterTLS(t *testing.T) {
+	// borrow the test certificate of httptest
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	serverConfig := ts.TLS.Clone()
+	roots := x509.NewCertPool()
+	roots.AddCert(ts.Certificate())
+	ts.Close()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			// the client waits for the handshake to complete
+			conn.(*tls.Conn).Handshake()
+			conns <- conn
+		}
+	}()
+
+	sw, err := NewSyslogWriter(SyslogConfig{
+		Network:   "tls",
+		Address:   ln.Addr().String(),
+		TLSConfig: &tls.Config{RootCAs: roots, ServerName: "example.com"},
+		AppName:   "testapp",
+		Hostname:  "testhost",
+	})
+	assert.NoError(t, err)
+	defer sw.Close()
+
+	readMessage := func(conn net.Conn) string {
+		r := bufio.NewReader(conn)
+		prefix, err := r.ReadString(' ')
+		assert.NoError(t, err)
+		length, err := strconv.Atoi(strings.TrimSpace(prefix))
+		assert.NoError(t, err)
+		buf := make([]byte, length)
+		_, err = io.ReadFull(r, buf)
+		assert.NoError(t, err)
+		return string(buf)
+	}
+
+	assert.NoError(t, sw.WriteMessage(zapcore.InfoLevel, time.Now(), "first"))
+	conn := <-conns
+	assert.Regexp(t, `^<14>1 \S+ testhost testapp \d+ - - first$`, readMessage(conn))
+	conn.Close()
+
+	// break the connection to make the writer reconnect
+	sw.mu.Lock()
+	sw.conn.Close()
+	sw.mu.Unlock()
+
+	assert.NoError(t, sw.WriteMessage(zapcore.InfoLevel, time.Now(), "second"))
+	conn = <-conns
+	assert.Regexp(t, `second$`, readMessage(conn))
+	conn.Close()
+
+	// back off while the server is unavailable
+	ln.Close()
+	sw.mu.Lock()
+	sw.conn.Close()
+	sw.mu.Unlock()
+
+	err = sw.WriteMessage(zapcore.InfoLevel, time.Now(), "lost")
+	assert.ErrorIs(t, err, ErrSyslogDisconnected)
+	assert.Equal(t, minSyslogReconnectBackoff, sw.backoff)
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelTee is like the core returned by zapcore.NewTee, except that Write
+// only writes to the cores enabled at the level of the entry.  The wrapping
+// cores, such as the redact and dedup cores, call Write directly instead of
+// going through Check, so with zapcore's tee an entry would reach cores that
+// are restricted to other levels.
+type levelTee []zapcore.Core
+
+// newLevelTee returns a core duplicating entries to the given cores.
+func newLevelTee(cores ...zapcore.Core) zapcore.Core {
+	if len(cores) == 1 {
+		return cores[0]
+	}
+	return levelTee(cores)
+}
+
+func (t levelTee) Enabled(level zapcore.Level) bool {
+	for _, c := range t {
+		if c.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t levelTee) With(fields []zapcore.Field) zapcore.Core {
+	clone := make(levelTee, len(t))
+	for i, c := range t {
+		clone[i] = c.With(fields)
+	}
+	return clone
+}
+
+func (t levelTee) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	for _, c := range t {
+		ce = c.Check(ent, ce)
+	}
+	return ce
+}
+
+func (t levelTee) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var err error
+	for _, c := range t {
+		if c.Enabled(ent.Level) {
+			err = multierr.Append(err, c.Write(ent, fields))
+		}
+	}
+	return err
+}
+
+func (t levelTee) Sync() error {
+	var err error
+	for _, c := range t {
+		err = multierr.Append(err, c.Sync())
+	}
+	return err
+}
@@ -0,0 +1,25 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLevelTee(t *testing.T) {
+	all, allLogs := observer.New(zapcore.DebugLevel)
+	errors, errorLogs := observer.New(zapcore.ErrorLevel)
+
+	// wrapping cores call Write directly, which must respect the levels of
+	// the cores in the tee
+	core := NewRedactCore(newLevelTee(all, errors))
+	assert.NoError(t, core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "info"}, nil))
+	assert.NoError(t, core.Write(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "error"}, nil))
+
+	assert.Equal(t, 2, allLogs.Len())
+	assert.Equal(t, 1, errorLogs.Len())
+	assert.Equal(t, "error", errorLogs.All()[0].Message)
+	assert.True(t, core.Enabled(zapcore.DebugLevel))
+}
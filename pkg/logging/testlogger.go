@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestLogger captures log entries in memory for use in tests.
+type TestLogger struct {
+	*zap.Logger
+	t    testing.TB
+	logs *observer.ObservedLogs
+}
+
+// NewTestLogger returns a logger which captures all entries in memory.  The
+// package logger writes to it as well until the test finishes, so entries
+// logged through Get, FromContext and friends are captured.  Since the
+// package logger is shared, tests using NewTestLogger must not run in
+// parallel.
+func NewTestLogger(t testing.TB) *TestLogger {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	// create the sinks now so they don't replace our core later
+	Start()
+	old := rootCore.swap(core)
+	t.Cleanup(func() {
+		rootCore.swap(old)
+	})
+
+	return &TestLogger{
+		Logger: zap.New(core, zap.AddCaller()),
+		t:      t,
+		logs:   logs,
+	}
+}
+
+// Entries returns the captured entries.
+func (l *TestLogger) Entries() []observer.LoggedEntry {
+	return l.logs.All()
+}
+
+// Reset discards the captured entries.
+func (l *TestLogger) Reset() {
+	l.logs.TakeAll()
+}
+
+// Logged returns the entries with the given level whose message contains
+// msgContains and which have all the given fields.
+func (l *TestLogger) Logged(level zapcore.Level, msgContains string, fields ...zap.Field) []observer.LoggedEntry {
+	want := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(want)
+	}
+
+	var matches []observer.LoggedEntry
+	for _, e := range l.logs.All() {
+		if e.Level != level || !strings.Contains(e.Message, msgContains) {
+			continue
+		}
+		if hasFields(e.ContextMap(), want.Fields) {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+// AssertLogged fails the test unless an entry with the given level, a
+// message containing msgContains and all the given fields has been logged.
+func (l *TestLogger) AssertLogged(level zapcore.Level, msgContains string, fields ...zap.Field) bool {
+	l.t.Helper()
+
+	if len(l.Logged(level, msgContains, fields...)) == 0 {
+		l.t.Errorf("no %s entry containing %q with fields %v was logged, got:\n%s", level, msgContains, fieldMap(fields), l.dump())
+		return false
+	}
+	return true
+}
+
+// AssertNotLogged fails the test if an entry with the given level, a message
+// containing msgContains and all the given fields has been logged.
+func (l *TestLogger) AssertNotLogged(level zapcore.Level, msgContains string, fields ...zap.Field) bool {
+	l.t.Helper()
+
+	if len(l.Logged(level, msgContains, fields...)) > 0 {
+		l.t.Errorf("unexpected %s entry containing %q with fields %v was logged, got:\n%s", level, msgContains, fieldMap(fields), l.dump())
+		return false
+	}
+	return true
+}
+
+// dump formats the captured entries for failure messages.
+func (l *TestLogger) dump() string {
+	var b strings.Builder
+	for _, e := range l.logs.All() {
+		fmt.Fprintf(&b, "  %s %q %v\n", e.Level, e.Message, e.ContextMap())
+	}
+	return b.String()
+}
+
+// hasFields reports whether got contains all the fields in want.
+func hasFields(got, want map[string]interface{}) bool {
+	for k, v := range want {
+		if !reflect.DeepEqual(got[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+func fieldMap(fields []zap.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
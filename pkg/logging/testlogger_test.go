@@ -0,0 +1,30 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewTestLogger(t *testing.T) {
+	tl := NewTestLogger(t)
+
+	lg.Infow("user logged in", "user", "bob", "attempts", 3)
+	tl.Warn("direct", zap.Bool("ok", false))
+
+	tl.AssertLogged(zapcore.InfoLevel, "logged in", zap.String("user", "bob"), zap.Int("attempts", 3))
+	tl.AssertLogged(zapcore.WarnLevel, "direct")
+	tl.AssertNotLogged(zapcore.InfoLevel, "logged in", zap.String("user", "alice"))
+	assert.Len(t, tl.Entries(), 2)
+
+	// failing assertions are reported to the test
+	inner := &testing.T{}
+	innerLogger := &TestLogger{Logger: tl.Logger, t: inner, logs: tl.logs}
+	assert.False(t, innerLogger.AssertLogged(zapcore.ErrorLevel, "missing"))
+	assert.True(t, inner.Failed())
+
+	tl.Reset()
+	assert.Empty(t, tl.Entries())
+}
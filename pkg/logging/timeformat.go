@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"time"
+
+	"github.com/ebobo/logging_lab5e_go/internal/logfile"
+	"go.uber.org/zap/zapcore"
+)
+
+// TimeConfig configures the timestamps of the console and structured outputs.
+type TimeConfig struct {
+	// Format is the format of the timestamps.  Valid values are "rfc3339",
+	// "rfc3339nano", "iso8601", "epoch" (seconds as a float), "epochmillis",
+	// "epochnanos" or a Go time layout such as "2006-01-02 15:04:05".  If it
+	// is empty each encoder uses its own default.
+	Format string
+	// If UTC is set timestamps are converted to UTC before they are
+	// formatted.  Otherwise they are in local time.
+	UTC bool
+}
+
+// timeEncoder returns the time encoder for the configuration.  def is used if
+// no format is given.
+func (c TimeConfig) timeEncoder(def zapcore.TimeEncoder) zapcore.TimeEncoder {
+	enc := def
+	switch c.Format {
+	case "":
+	case "rfc3339":
+		enc = zapcore.RFC3339TimeEncoder
+	case "rfc3339nano":
+		enc = zapcore.RFC3339NanoTimeEncoder
+	case "iso8601":
+		enc = zapcore.ISO8601TimeEncoder
+	case "epoch":
+		enc = zapcore.EpochTimeEncoder
+	case "epochmillis":
+		enc = zapcore.EpochMillisTimeEncoder
+	case "epochnanos":
+		enc = zapcore.EpochNanosTimeEncoder
+	default:
+		c.registerLayout()
+		enc = zapcore.TimeEncoderOfLayout(c.Format)
+	}
+
+	if !c.UTC || enc == nil {
+		return enc
+	}
+	return func(t time.Time, pae zapcore.PrimitiveArrayEncoder) {
+		enc(t.UTC(), pae)
+	}
+}
+
+// registerLayout makes the tools reading the log files recognize the
+// timestamps if Format is a custom layout.
+func (c TimeConfig) registerLayout() {
+	switch c.Format {
+	case "", "rfc3339", "rfc3339nano", "iso8601", "epoch", "epochmillis", "epochnanos":
+		return
+	}
+	logfile.RegisterLayout(c.Format)
+}
+
+// apply returns ec with the time encoder replaced according to the
+// configuration.
+func (c TimeConfig) apply(ec zapcore.EncoderConfig) zapcore.EncoderConfig {
+	ec.EncodeTime = c.timeEncoder(ec.EncodeTime)
+	return ec
+}
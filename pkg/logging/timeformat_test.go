@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ebobo/logging_lab5e_go/internal/logfile"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestTimeConfig(t *testing.T) {
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 890000000, time.FixedZone("CET", 3600))
+
+	encode := func(c TimeConfig) interface{} {
+		var buf bytes.Buffer
+		enc := structuredEncoder(Config{Time: c})
+		out, err := enc.EncodeEntry(zapcore.Entry{Time: ts, Message: "hello"}, nil)
+		assert.NoError(t, err)
+		buf.Write(out.Bytes())
+
+		var m map[string]interface{}
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+		return m["ts"]
+	}
+
+	assert.Equal(t, float64(ts.UnixNano())/1e9, encode(TimeConfig{}))
+	assert.Equal(t, "2021-03-04T04:06:07Z", encode(TimeConfig{Format: "rfc3339", UTC: true}))
+	assert.Equal(t, "2021-03-04T05:06:07.89+01:00", encode(TimeConfig{Format: "rfc3339nano"}))
+	assert.InDelta(t, float64(ts.UnixNano()/1e6), encode(TimeConfig{Format: "epochmillis"}), 0.001)
+	assert.Equal(t, "2021-03-04 04:06", encode(TimeConfig{Format: "2006-01-02 15:04", UTC: true}))
+
+	// UTC without a format keeps the default format of the encoder
+	out, err := structuredEncoder(Config{Encoding: "logfmt", Time: TimeConfig{UTC: true}}).EncodeEntry(zapcore.Entry{Time: ts, Message: "hello"}, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "ts=2021-03-04T04:06:07.890Z")
+}
+
+func TestTimeConfigRoundTrip(t *testing.T) {
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 890123000, time.UTC)
+
+	for _, tt := range []struct {
+		format string
+		prec   time.Duration
+	}{
+		{"", time.Millisecond},
+		{"rfc3339", time.Second},
+		{"rfc3339nano", 0},
+		{"iso8601", time.Millisecond},
+		{"epoch", time.Microsecond},
+		{"epochmillis", time.Microsecond},
+		{"epochnanos", time.Microsecond},
+		{"2006-01-02 15:04:05.000000 MST", 0},
+		{"Jan 2 2006 15:04:05.000000Z07:00", 0},
+	} {
+		c := TimeConfig{Format: tt.format, UTC: true}
+		for _, enc := range []zapcore.Encoder{structuredEncoder(Config{Time: c}), ConsoleConfig{}.encoder(c)} {
+			out, err := enc.EncodeEntry(zapcore.Entry{Time: ts, Message: "hello"}, nil)
+			assert.NoError(t, err)
+			line := strings.TrimSpace(out.String())
+
+			parsed, ok := logfile.Time(line)
+			assert.True(t, ok, line)
+			assert.WithinDuration(t, ts, parsed, tt.prec, line)
+		}
+	}
+}
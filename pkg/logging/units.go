@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeUnits are the units accepted by parseSize.  KB, MB, GB and TB are
+// powers of 1000, KiB, MiB, GiB and TiB powers of 1024.  Note that the
+// legacy *_MB variables are in MiB.
+var sizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// parseSize parses a size such as "500MB", "2GiB" or "1.5 GB".  A number
+// without a unit is a number of bytes.
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, s[i:])
+	}
+	return int64(n * unit), nil
+}
+
+// parseDuration parses a duration like time.ParseDuration and also accepts
+// a leading number of days, eg. "7d" or "1d12h".
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	days, rest, ok := strings.Cut(s, "d")
+	if !ok {
+		return time.ParseDuration(s)
+	}
+
+	n, err := strconv.ParseFloat(days, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	d := time.Duration(n * float64(24*time.Hour))
+	if rest != "" {
+		r, err := time.ParseDuration(rest)
+		if err != nil || strings.HasPrefix(rest, "-") || strings.HasPrefix(rest, "+") {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d += r
+	}
+	return d, nil
+}
+
+// sizeFromEnv returns the size given by the environment variable name, or
+// by the deprecated variable legacyMB in megabytes.  Invalid values are
+// logged and ignored.
+func sizeFromEnv(name, legacyMB string) int64 {
+	if value := getenv(name); value != "" {
+		size, err := parseSize(value)
+		if err != nil {
+			lg.Errorw("ignoring invalid size", "env", EnvVar(name), "err", err)
+		}
+		return size
+	}
+
+	if value := getenv(legacyMB); value != "" {
+		mb, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			lg.Errorw("ignoring invalid size in megabytes", "env", EnvVar(legacyMB), "value", value)
+		}
+		return mb * 1024 * 1024
+	}
+	return 0
+}
+
+// durationFromEnv returns the duration given by the environment variable
+// name, or by the deprecated variable legacyDays in days.  Invalid values
+// are logged and ignored.
+func durationFromEnv(name, legacyDays string) time.Duration {
+	if value := getenv(name); value != "" {
+		d, err := parseDuration(value)
+		if err != nil {
+			lg.Errorw("ignoring invalid duration", "env", EnvVar(name), "err", err)
+		}
+		return d
+	}
+
+	if value := getenv(legacyDays); value != "" {
+		days, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			lg.Errorw("ignoring invalid number of days", "env", EnvVar(legacyDays), "value", value)
+		}
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int64{
+		"1024":   1024,
+		"500MB":  500 * 1000 * 1000,
+		"500mb":  500 * 1000 * 1000,
+		"2GiB":   2 << 30,
+		"1.5 KB": 1500,
+		"10k":    10000,
+	} {
+		size, err := parseSize(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, size, s)
+	}
+
+	for _, s := range []string{"", "MB", "500XB", "-1MB"} {
+		_, err := parseSize(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"72h":   72 * time.Hour,
+		"7d":    7 * 24 * time.Hour,
+		"0.5d":  12 * time.Hour,
+		"90m":   90 * time.Minute,
+		"1d12h": 36 * time.Hour,
+		"2d30m": 48*time.Hour + 30*time.Minute,
+	} {
+		d, err := parseDuration(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, d, s)
+	}
+
+	for _, s := range []string{"", "7", "d", "7days", "1d12", "1d-1h", "1d2d"} {
+		_, err := parseDuration(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestFileWriterConfigFromEnvUnits(t *testing.T) {
+	t.Setenv(EnvVar(LogFileSizeEnvVar), "500MB")
+	t.Setenv(EnvVar(LogFileMaxAgeEnvVar), "7d")
+	c := fileWriterConfigFromEnv()
+	assert.Equal(t, int64(500*1000*1000), c.MaxLogFileSizeBytes)
+	assert.Equal(t, 7*24*time.Hour, c.MaxTimeTimeToKeep)
+
+	// the deprecated variables still work
+	t.Setenv(EnvVar(LogFileSizeEnvVar), "")
+	t.Setenv(EnvVar(LogFileMaxAgeEnvVar), "")
+	t.Setenv(EnvVar(LogFileSizeMBEnvVar), "10")
+	t.Setenv(EnvVar(LogFileMaxAgeDaysEnvVar), "3")
+	c = fileWriterConfigFromEnv()
+	assert.Equal(t, int64(10*1024*1024), c.MaxLogFileSizeBytes)
+	assert.Equal(t, 3*24*time.Hour, c.MaxTimeTimeToKeep)
+
+	// invalid values are ignored
+	t.Setenv(EnvVar(LogFileSizeEnvVar), "lots")
+	t.Setenv(EnvVar(LogFileMaxAgeEnvVar), "forever")
+	c = fileWriterConfigFromEnv()
+	assert.Zero(t, c.MaxLogFileSizeBytes)
+	assert.Zero(t, c.MaxTimeTimeToKeep)
+}
@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidMaxSize is returned for a MaxLogFileSizeBytes that is
+	// negative or below the minimum log file size.
+	ErrInvalidMaxSize = errors.New("invalid maximum log file size")
+	// ErrBadDirectory is returned when the log directory is not a
+	// directory or can't be created.
+	ErrBadDirectory = errors.New("bad log directory")
+)
+
+// Validate checks the configuration.  Zero values are valid and replaced
+// with the defaults by NewFileWriter.  The errors wrap ErrInvalidMaxSize or
+// ErrBadDirectory.
+func (c FileWriterConfig) Validate() error {
+	minSize := minLogFileSizeBytes
+	if c.minSizeBytes > 0 {
+		minSize = c.minSizeBytes
+	}
+	if c.MaxLogFileSizeBytes < 0 || (c.MaxLogFileSizeBytes > 0 && c.MaxLogFileSizeBytes < minSize) {
+		return fmt.Errorf("%w: %d bytes, the minimum is %d bytes", ErrInvalidMaxSize, c.MaxLogFileSizeBytes, minSize)
+	}
+
+	if c.LogDirName != "" {
+		fs := c.FS
+		if fs == nil {
+			fs = OSFS
+		}
+		info, err := fs.Stat(c.LogDirName)
+		if err == nil && !info.IsDir() {
+			return fmt.Errorf("%w: %s is not a directory", ErrBadDirectory, c.LogDirName)
+		}
+	}
+	return nil
+}
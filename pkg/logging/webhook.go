@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WebhookConfig configures the webhook notified of entries at Panic and
+// Fatal level.
+type WebhookConfig struct {
+	// URL is the URL the entries are posted to.  Configure only adds the
+	// webhook if it is set.
+	URL string
+	// Timeout limits how long posting an entry may delay the panic or exit.
+	// Defaults to five seconds.
+	Timeout time.Duration
+}
+
+const defaultWebhookTimeout = 5 * time.Second
+
+// webhookPayload is the JSON document posted to the webhook.
+type webhookPayload struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Logger  string                 `json:"logger,omitempty"`
+	Message string                 `json:"message"`
+	Caller  string                 `json:"caller,omitempty"`
+	Stack   string                 `json:"stack,omitempty"`
+	Host    string                 `json:"host"`
+	Pid     int                    `json:"pid"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// webhookCore is a zapcore.Core which posts entries at Panic and Fatal level
+// to a webhook.
+type webhookCore struct {
+	config WebhookConfig
+	client *http.Client
+	fields []zapcore.Field
+}
+
+// NewWebhookCore returns a core which posts entries at Panic and Fatal level
+// as JSON to a webhook, eg. of a paging system, so crashes are known right
+// away.  The entries are posted before zap panics or exits the process.
+func NewWebhookCore(c WebhookConfig) zapcore.Core {
+	if c.Timeout <= 0 {
+		c.Timeout = defaultWebhookTimeout
+	}
+	return &webhookCore{
+		config: c,
+		client: &http.Client{Timeout: c.Timeout},
+	}
+}
+
+func (c *webhookCore) Enabled(l zapcore.Level) bool {
+	return l >= zapcore.PanicLevel
+}
+
+func (c *webhookCore) With(fields []zapcore.Field) zapcore.Core {
+	return &webhookCore{
+		config: c.config,
+		client: c.client,
+		fields: append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *webhookCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *webhookCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	host, _ := os.Hostname()
+	payload := webhookPayload{
+		Time:    ent.Time,
+		Level:   ent.Level.String(),
+		Logger:  ent.LoggerName,
+		Message: ent.Message,
+		Stack:   ent.Stack,
+		Host:    host,
+		Pid:     os.Getpid(),
+		Fields:  enc.Fields,
+	}
+	if ent.Caller.Defined {
+		payload.Caller = ent.Caller.TrimmedPath()
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned %s", c.config.URL, resp.Status)
+	}
+	return nil
+}
+
+func (c *webhookCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestWebhookCore(t *testing.T) {
+	var payloads []webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	log := zap.New(NewWebhookCore(WebhookConfig{URL: server.URL}), zap.AddCaller()).Named("billing")
+	log.Error("not posted")
+	assert.Panics(t, func() {
+		log.Panic("out of money", zap.Int("balance", -1))
+	})
+
+	assert.Len(t, payloads, 1)
+	assert.Equal(t, "panic", payloads[0].Level)
+	assert.Equal(t, "billing", payloads[0].Logger)
+	assert.Equal(t, "out of money", payloads[0].Message)
+	assert.Contains(t, payloads[0].Caller, "webhook_test.go")
+	assert.Equal(t, map[string]interface{}{"balance": float64(-1)}, payloads[0].Fields)
+}
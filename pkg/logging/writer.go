@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"io"
+	"log"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zapio"
+)
+
+// Writer returns a writer which logs each line written to it as an entry at
+// level, for libraries that only accept an io.Writer.  A trailing partial
+// line is logged when the writer is closed; it implements io.Closer.  The
+// caller is not logged since it would always be inside the library.
+//
+//	server := &http.Server{ErrorLog: log.New(logging.Writer(zapcore.WarnLevel), "", 0)}
+func Writer(level zapcore.Level) io.Writer {
+	return &zapio.Writer{
+		Log:   logger.WithOptions(zap.WithCaller(false)),
+		Level: level,
+	}
+}
+
+// StdLogAt returns a standard library logger which logs at level through the
+// package logger named name, so legacy components can be redirected at
+// different severities.  If name is empty the package logger is used as is.
+func StdLogAt(level zapcore.Level, name string) *log.Logger {
+	l := logger
+	if name != "" {
+		l = l.Named(name)
+	}
+
+	std, err := zap.NewStdLogAt(l, level)
+	if err != nil {
+		lg.Errorw("invalid level for standard library logger", "level", level, "err", err)
+		return zap.NewStdLog(l)
+	}
+	return std
+}
@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWriter(t *testing.T) {
+	tl := NewTestLogger(t)
+
+	w := Writer(zapcore.WarnLevel)
+	_, err := io.WriteString(w, "first line\nsecond ")
+	assert.NoError(t, err)
+	_, err = io.WriteString(w, "line\npartial")
+	assert.NoError(t, err)
+
+	tl.AssertLogged(zapcore.WarnLevel, "first line")
+	tl.AssertLogged(zapcore.WarnLevel, "second line")
+	tl.AssertNotLogged(zapcore.WarnLevel, "partial")
+
+	assert.NoError(t, w.(io.Closer).Close())
+	tl.AssertLogged(zapcore.WarnLevel, "partial")
+}
+
+func TestStdLogAt(t *testing.T) {
+	tl := NewTestLogger(t)
+
+	StdLogAt(zapcore.ErrorLevel, "legacy").Print("connection reset")
+
+	entries := tl.Logged(zapcore.ErrorLevel, "connection reset")
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "legacy", entries[0].LoggerName)
+	assert.Contains(t, entries[0].Caller.File, "writer_test.go")
+}